@@ -0,0 +1,193 @@
+package arseeding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// receipts in these status can be handled by admin
+var adminReceiptStatus = []string{schema.UnRefund, schema.RefundErr}
+
+func (s *Arseeding) adminAudit(operator, action, target string, req interface{}, result string, err error) {
+	reqJs, _ := json.Marshal(req)
+	audit := schema.AdminAudit{
+		Operator: operator,
+		Action:   action,
+		Target:   target,
+		Request:  reqJs,
+		Result:   result,
+	}
+	if err != nil {
+		audit.ErrMsg = err.Error()
+	}
+	if err := s.wdb.InsertAdminAudit(audit); err != nil {
+		log.Error("s.wdb.InsertAdminAudit(audit)", "err", err, "operator", operator, "action", action, "target", target)
+	}
+}
+
+func (s *Arseeding) lockAdminReceipt(everHash, toStatus string) (schema.ReceiptEverTx, error) {
+	rpt, err := s.wdb.GetReceiptByEverHash(everHash)
+	if err != nil {
+		return rpt, err
+	}
+	ok, err := s.wdb.LockReceiptStatus(rpt.RawId, adminReceiptStatus, toStatus, nil)
+	if err != nil {
+		return rpt, err
+	}
+	if !ok {
+		return rpt, errors.New("receipt status must be unrefund or refundErr")
+	}
+	return rpt, nil
+}
+
+// AdminRetryRefund refund the receipt immediately through its payment provider
+func (s *Arseeding) AdminRetryRefund(operator, everHash string) (refundHash string, err error) {
+	defer func() {
+		s.adminAudit(operator, schema.AdminActionRetryRefund, everHash, nil, refundHash, err)
+	}()
+
+	rpt, err := s.lockAdminReceipt(everHash, schema.Refund)
+	if err != nil {
+		return "", err
+	}
+	provider, err := s.getPaymentProvider(rpt.Provider)
+	if err == nil {
+		refundHash, err = provider.Refund(rpt)
+	}
+	if err != nil {
+		if err := s.wdb.UpdateRefundErr(rpt.RawId, err.Error()); err != nil {
+			log.Error("s.wdb.UpdateRefundErr(rpt.RawId, err.Error())", "err", err, "id", rpt.RawId)
+		}
+		return "", err
+	}
+	if err = s.wdb.UpdateRefundHash(rpt.RawId, refundHash); err != nil {
+		log.Error("s.wdb.UpdateRefundHash(rpt.RawId, refundHash)", "err", err, "id", rpt.RawId)
+	}
+	return refundHash, nil
+}
+
+// AdminManualRefund mark the receipt refunded by an external tx
+func (s *Arseeding) AdminManualRefund(operator, everHash string, req schema.ReqAdminManualRefund) (err error) {
+	defer func() {
+		s.adminAudit(operator, schema.AdminActionManualRefund, everHash, req, req.RefundHash, err)
+	}()
+
+	if req.RefundHash == "" {
+		return errors.New("refundHash can not be empty")
+	}
+	rpt, err := s.lockAdminReceipt(everHash, schema.Refund)
+	if err != nil {
+		return err
+	}
+	return s.wdb.UpdateRefundHash(rpt.RawId, req.RefundHash)
+}
+
+// AdminCredit credit the receipt amount to apikey balance instead of refunding it
+func (s *Arseeding) AdminCredit(operator, everHash string, req schema.ReqAdminCredit) (address string, err error) {
+	defer func() {
+		s.adminAudit(operator, schema.AdminActionCredit, everHash, req, address, err)
+	}()
+
+	rpt, err := s.wdb.GetReceiptByEverHash(everHash)
+	if err != nil {
+		return "", err
+	}
+	if s.GetPerFee(rpt.Symbol) == nil {
+		return "", errors.New("not support the currency: " + rpt.Symbol)
+	}
+	amount, err := decimal.NewFromString(rpt.Amount)
+	if err != nil {
+		return "", err
+	}
+
+	if req.Address != "" {
+		address = req.Address
+		if common.IsHexAddress(address) {
+			address = common.HexToAddress(address).String()
+		}
+		if exist, _ := s.wdb.ExistApikey(address); !exist {
+			return "", errors.New("apikey not exist")
+		}
+	} else {
+		if rpt.Provider != schema.PaymentProviderEverPay || !common.IsHexAddress(rpt.From) {
+			return "", errors.New("can not create apikey for the payer, please specify the address")
+		}
+		if address, err = getOrCreatePayerApikey(s.wdb, rpt); err != nil {
+			return "", err
+		}
+	}
+
+	dbTx := s.wdb.Db.Begin()
+	ok, err := s.wdb.LockReceiptStatus(rpt.RawId, adminReceiptStatus, schema.Spent, dbTx)
+	if err != nil || !ok {
+		dbTx.Rollback()
+		if err == nil {
+			err = errors.New("receipt status must be unrefund or refundErr")
+		}
+		return "", err
+	}
+	if _, err = postLedgerEntry(s.wdb, address, rpt.Symbol, schema.LedgerCredit, amount, rpt.EverHash, "", nil, dbTx); err != nil {
+		dbTx.Rollback()
+		return "", err
+	}
+	if err = dbTx.Commit().Error; err != nil {
+		return "", err
+	}
+	return address, nil
+}
+
+// AdminUsdCredit credit USD to apikey balance, e.g. the invoice paid by bank transfer
+func (s *Arseeding) AdminUsdCredit(operator, address string, req schema.ReqAdminUsdCredit) (balance string, err error) {
+	defer func() {
+		s.adminAudit(operator, schema.AdminActionUsdCredit, address, req, balance, err)
+	}()
+
+	if req.Reference == "" {
+		return "", errors.New("reference can not be empty")
+	}
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil {
+		return "", err
+	}
+	if !amount.IsPositive() {
+		return "", errors.New("amount must be positive")
+	}
+	if common.IsHexAddress(address) {
+		address = common.HexToAddress(address).String()
+	}
+	if exist, _ := s.wdb.ExistApikey(address); !exist {
+		return "", errors.New("apikey not exist")
+	}
+	// balance in micro USD
+	amount = amount.Shift(schema.UsdDecimals).Round(0)
+	entry, err := postLedgerEntryTx(s.wdb, address, schema.UsdCurrency, schema.LedgerCredit, amount, req.Reference, nil)
+	if err != nil {
+		return "", err
+	}
+	return entry.Balance, nil
+}
+
+// AdminSetPrice set the token price after a big move which the price sources do not agree on
+func (s *Arseeding) AdminSetPrice(operator, symbol string, req schema.ReqAdminSetPrice) (err error) {
+	symbol = strings.ToUpper(symbol)
+	defer func() {
+		s.adminAudit(operator, schema.AdminActionSetPrice, symbol, req, fmt.Sprintf("%v", req.Price), err)
+	}()
+
+	if req.Price <= 0 {
+		return errors.New("price must be positive")
+	}
+	if err = s.wdb.SetPrice(symbol, req.Price, req.ManualSet); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("token price of %s not exist", symbol)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/schema"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestAdminReceipt(t *testing.T) {
+	sqliteDir := "./data/admin"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	aa := &Arseeding{wdb: wdb, bundlePerFeeMap: map[string]schema.Fee{"USDC": {Currency: "USDC"}}}
+
+	operator := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	payer := "0x61EbF673c200646236B2c53465bcA0699455d5FA"
+	assert.NoError(t, wdb.InsertApiKey(schema.AutoApiKey{ApiKey: "apikey-test", Address: payer, TokenBalance: map[string]interface{}{}}))
+	assert.NoError(t, wdb.InsertReceiptTx(schema.ReceiptEverTx{RawId: 1, EverHash: "everHash-1", Symbol: "USDC", From: payer, Amount: "100", Status: schema.RefundErr}))
+	assert.NoError(t, wdb.InsertReceiptTx(schema.ReceiptEverTx{RawId: 2, EverHash: "everHash-2", Symbol: "USDC", From: payer, Amount: "50", Status: schema.RefundErr}))
+	assert.NoError(t, wdb.InsertReceiptTx(schema.ReceiptEverTx{RawId: 3, EverHash: "everHash-3", Symbol: "USDC", From: payer, Amount: "50", Status: schema.Spent}))
+
+	rpts, err := wdb.GetReceiptsByStatusPage(schema.RefundErr, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(rpts))
+
+	assert.Error(t, aa.AdminManualRefund(operator, "everHash-1", schema.ReqAdminManualRefund{}))
+	assert.NoError(t, aa.AdminManualRefund(operator, "everHash-1", schema.ReqAdminManualRefund{RefundHash: "refundHash-1"}))
+	rpt, err := wdb.GetReceiptByEverHash("everHash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.Refund, rpt.Status)
+	assert.Equal(t, "refundHash-1", rpt.RefundHash)
+
+	address, err := aa.AdminCredit(operator, "everHash-2", schema.ReqAdminCredit{})
+	assert.NoError(t, err)
+	assert.Equal(t, payer, address)
+	apikey, err := wdb.GetApiKeyDetail("apikey-test")
+	assert.NoError(t, err)
+	assert.Equal(t, "50", apikey.TokenBalance["USDC"])
+
+	// spent receipt can not be credited again
+	_, err = aa.AdminCredit(operator, "everHash-3", schema.ReqAdminCredit{})
+	assert.Error(t, err)
+
+	audits, err := wdb.GetAdminAudits("", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(audits))
+	assert.Equal(t, schema.AdminActionCredit, audits[0].Action)
+	assert.NotEmpty(t, audits[0].ErrMsg)
+	assert.Equal(t, operator, audits[3].Operator)
+
+	// the price set by admin skips the price move check
+	assert.NoError(t, wdb.InsertPrices([]schema.TokenPrice{{Symbol: "AR", Decimals: 12, Price: 10}}))
+	assert.Error(t, aa.AdminSetPrice(operator, "ar", schema.ReqAdminSetPrice{Price: 0}))
+	assert.Error(t, aa.AdminSetPrice(operator, "eth", schema.ReqAdminSetPrice{Price: 2000}))
+	assert.NoError(t, aa.AdminSetPrice(operator, "ar", schema.ReqAdminSetPrice{Price: 30}))
+	price, err := wdb.GetArPrice()
+	assert.NoError(t, err)
+	assert.Equal(t, 30.0, price)
+}
@@ -19,9 +19,13 @@ import (
 	"io"
 	"io/ioutil"
 	gLog "log"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httputil"
 	"os"
+	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -83,14 +87,20 @@ func (s *Arseeding) runAPI(port string) {
 
 		// ANS-104 bundle Data api
 		v1.GET("/bundle/bundler", s.getBundler)
-		v1.POST("/bundle/tx/:currency", s.submitItem)
+		v1.POST("/bundle/tx/:currency", IdempotencyMiddleware(s), s.submitItem)
 
 		v1.GET("/bundle/tx/:itemId", s.getItemMeta) // get item meta, without data
 		v1.GET("/bundle/tx/:itemId/:field", s.getItemField)
 		v1.GET("/bundle/itemIds/:arId", s.getItemIdsByArId)
 		v1.GET("/bundle/fees", s.bundleFees)
 		v1.GET("/bundle/fee/:size/:currency", s.bundleFee)
-		v1.GET("/bundle/orders/:signer", s.getOrders)
+		v1.POST("/bundle/quote/:size/:currency", s.createFeeQuote)              // need X-API-KEY or query signer, the quoteId is used by http header X-FEE-QUOTE when submit
+		v1.GET("/bundle/orders/:signer", SessionAuthMiddleware(s), s.getOrders) // need the login session of signer if legacy auth is disabled
+		v1.POST("/bundle/invoice", s.createInvoice)                             // pay the invoice with everTx data: {"appName":"arseeding","action":"invoicePayment","invoiceId":"..."}
+		v1.GET("/bundle/invoice/:invoiceId", s.getInvoice)
+		v1.POST("/bundle/order/:itemId/cancel", RequireSessionMiddleware(s), s.cancelOrder) // need the login session of item signer
+		v1.GET("/bundle/proof/:itemId", s.getItemProof)
+		v1.GET("/bundle/receipt/:itemId", s.getUploadReceipt)
 		v1.GET("/:id", s.dataRoute)  // get arTx data or bundleItem data
 		v1.HEAD("/:id", s.dataRoute) // get arTx data or bundleItem data
 
@@ -99,17 +109,53 @@ func (s *Arseeding) runAPI(port string) {
 		}
 
 		// submit native data with X-API-KEY
-		v1.POST("/bundle/data/:currency", s.submitNativeData)
-		v1.GET("/bundle/orders", s.getOrdersByApiKey) // http header need X-API-KEY
+		v1.POST("/bundle/data/:currency", IdempotencyMiddleware(s), s.submitNativeData)
+		v1.GET("/bundle/orders", SessionAuthMiddleware(s), s.getOrdersByApiKey) // http header need X-API-KEY or Authorization
+
+		// resumable upload session
+		v1.POST("/bundle/upload/:currency", s.createUploadSession)          // http header need X-API-KEY
+		v1.POST("/bundle/upload_data/:currency", s.createDataUploadSession) // http header need X-API-KEY
+		v1.PUT("/bundle/upload_part/:sessionId/:partNum", s.putUploadPart)
+		v1.GET("/bundle/upload_parts/:sessionId", s.getUploadSession)
+		v1.POST("/bundle/upload_finalize/:sessionId", s.finalizeUploadSession)
 
 		// apikey
 		v1.GET("/apikey_info/:address", s.getApiKeyInfo)
-		v1.GET("/apikey/:timestamp/:signature", s.getApiKey)
-		v1.GET("/apikey_records/deposit/:address", s.getApikeyDepositRecords)
+		v1.GET("/apikey/:timestamp/:signature", s.getApiKey) // deprecated, use /auth/apikey
+		v1.GET("/apikey_records/deposit/:address", RequireSessionMiddleware(s), s.getApikeyDepositRecords)
+		v1.GET("/apikey_records/ledger/:address", RequireSessionMiddleware(s), s.getApikeyLedger)
+		// monthly statement, month: 200601, query format: json(default), csv
+		v1.GET("/apikey_statement/:address/:month", RequireSessionMiddleware(s), s.getApikeyStatement)
+		v1.POST("/apikey/upload_token", s.createUploadToken) // http header need X-API-KEY
+		// apikey management, need the login session of apikey owner
+		v1.POST("/apikey/rotate", RequireSessionMiddleware(s), s.rotateApiKey)
+		v1.POST("/apikey/revoke", RequireSessionMiddleware(s), s.revokeApiKey)
+		v1.POST("/apikey/subkey", RequireSessionMiddleware(s), s.createApiSubKey)
+		v1.POST("/apikey/subkey/revoke", RequireSessionMiddleware(s), s.revokeApiSubKey) // body: {"name":"..."}
+		v1.GET("/apikey/subkeys", RequireSessionMiddleware(s), s.getApiSubKeys)
+
+		// wallet login, the session token is used by Authorization header: "Bearer <token>"
+		v1.GET("/auth/nonce/:address", s.getAuthNonce) // query sigType: ethereum(default), arweave, solana
+		v1.POST("/auth/login", s.authLogin)
+		v1.POST("/auth/logout", s.authLogout)
+		v1.GET("/auth/apikey", RequireSessionMiddleware(s), s.getSessionApiKey)
+
+		// admin, the session address must be in the config admin list
+		admin := v1.Group("/admin", RequireSessionMiddleware(s), AdminMiddleware(s))
+		admin.GET("/receipts", s.adminGetReceipts) // query status, cursorId, num
+		admin.POST("/receipts/:everHash/refund", s.adminRetryRefund)
+		admin.POST("/receipts/:everHash/manual_refund", s.adminManualRefund)
+		admin.POST("/receipts/:everHash/credit", s.adminCredit)
+		admin.POST("/apikey/:address/usd_credit", s.adminUsdCredit)
+		admin.POST("/prices/:symbol", s.adminSetPrice)
+		admin.GET("/audits", s.adminGetAudits)        // query target, cursorId, num
+		admin.GET("/fee_sweeps", s.adminGetFeeSweeps) // query cursorId, num
+		admin.GET("/fee_sweeps/dry_run", s.adminDryRunFeeSweep)
 
 		// statistic
 		v1.GET("/statistic/realtime", s.getRealTimeOrderStatistic)
 		v1.GET("/statistic/range", s.getOrderStatisticByDate)
+		v1.GET("/statistic/economics", s.getEconomicsReport) // query start, end
 	}
 
 	go func() {
@@ -124,10 +170,11 @@ func (s *Arseeding) runAPI(port string) {
 
 func (s *Arseeding) arseedInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"Name":          "Arseeding",
-		"Version":       "v1.2.0",
-		"Documentation": "https://web3infra.dev",
-		"ConcurrentNum": s.config.Param.ChunkConcurrentNum,
+		"Name":             "Arseeding",
+		"Version":          Version,
+		"Documentation":    "https://web3infra.dev",
+		"ConcurrentNum":    s.config.Param.ChunkConcurrentNum,
+		"BundleTagsPreset": s.bundleTagsPreset(),
 	})
 }
 
@@ -504,43 +551,41 @@ func (s *Arseeding) getBundler(c *gin.Context) {
 	c.JSON(http.StatusOK, schema.ResBundler{Bundler: s.bundler.Signer.Address})
 }
 
-func (s *Arseeding) processApikeySpendBal(currency, apikey string, dataSize int64) error {
-	apikeyDetail, err := s.wdb.GetApiKeyDetail(apikey)
+// processApikeySpendBal debit the item fee from apikey balance, the returned ledger entry can be refunded if the item is not accepted.
+// The returned fee is the fee of order, it must not be calculated again
+func (s *Arseeding) processApikeySpendBal(currency, apikey, itemId string, dataSize int64, quoteId, lane string) (*schema.LedgerEntry, *schema.RespFee, error) {
+	apikeyDetail, subKey, err := s.checkApiKey(apikey)
 	if err != nil {
-		return err
-	}
-
-	// exist currency balance
-	curBal, ok := apikeyDetail.TokenBalance[strings.ToUpper(currency)]
-	if !ok {
-		return errors.New("apiKey not the currency balance")
+		return nil, nil, err
 	}
-	curBalDe, err := decimal.NewFromString(curBal.(string))
-	if err != nil {
-		return err
+	if subKey != nil {
+		if err = checkSubKeyCurrency(*subKey, currency); err != nil {
+			return nil, nil, err
+		}
 	}
 	// calc fee
-	fee, err := s.CalcItemFee(currency, dataSize)
+	fee, err := s.calcOrderFee(currency, dataSize, apikeyDetail.Address, quoteId, itemId, lane)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	feeDe, err := decimal.NewFromString(fee.FinalFee)
 	if err != nil {
-		return err
-	}
-	endBalDe := curBalDe.Sub(feeDe)
-	if endBalDe.LessThan(decimal.NewFromInt(0)) {
-		// balance is insufficient
-		return errors.New("balance is insufficient")
+		return nil, nil, err
 	}
 
-	// update db tokenBalance
-	apikeyDetail.TokenBalance[strings.ToUpper(currency)] = endBalDe.String()
-	if err = s.wdb.UpdateApikeyTokenBal(apikeyDetail.Address, apikeyDetail.TokenBalance); err != nil {
-		log.Error("UpdateApikeyTokenBal", "err", err)
-		return err
+	var entry *schema.LedgerEntry
+	if subKey != nil {
+		// sub-key draw on the parent apikey balance
+		entry, err = postSubKeyDebit(s.wdb, subKey.SubKey, currency, feeDe, itemId, fee)
+	} else {
+		entry, err = postLedgerEntryTx(s.wdb, apikeyDetail.Address, currency, schema.LedgerDebit, feeDe, itemId, fee)
 	}
-	return nil
+	if err != nil {
+		log.Error("postLedgerEntryTx(debit)", "err", err, "address", apikeyDetail.Address, "itemId", itemId)
+		s.releaseFeeQuote(quoteId, itemId)
+		return nil, nil, err
+	}
+	return entry, fee, nil
 }
 
 func (s *Arseeding) submitItem(c *gin.Context) {
@@ -594,73 +639,70 @@ func (s *Arseeding) submitItem(c *gin.Context) {
 		return
 	}
 
+	lane, err := parseLane(c.GetHeader("X-LANE"))
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
 	currency := c.Param("currency")
-	// check whether noFee mode
-	noFee := false
-	// if has apikey
 	apikey := c.GetHeader("X-API-KEY")
-	hasApikey := false
-	if len(apikey) > 0 {
-		if err := s.processApikeySpendBal(currency, apikey, size); err != nil {
+	// upload token is paid by the apikey which minted it
+	uploadToken := c.GetHeader("X-UPLOAD-TOKEN")
+	if len(uploadToken) > 0 {
+		apikey, err = s.UseItemUploadToken(uploadToken, currency, size, item.Tags)
+		if err != nil {
 			errorResponse(c, err.Error())
 			return
 		}
-		// currency has balance
-		hasApikey = true
-	}
-
-	if s.NoFee || hasApikey {
-		noFee = true
 	}
-
-	// process bundleItem
 	needSort := isSortItems(c)
-	ord, err := s.ProcessSubmitItem(*item, currency, noFee, apikey, needSort, size)
+	respOrd, err := s.submitItemOrder(*item, currency, apikey, needSort, lane, size, c.GetHeader("X-FEE-QUOTE"))
+	if len(uploadToken) > 0 {
+		s.ReleaseUploadToken(uploadToken, err == nil)
+	}
 	if err != nil {
 		errorResponse(c, err.Error())
 		return
 	}
-
-	c.JSON(http.StatusOK, schema.RespOrder{
-		ItemId:             ord.ItemId,
-		Size:               ord.Size,
-		Bundler:            s.bundler.Signer.Address,
-		Currency:           ord.Currency,
-		Decimals:           ord.Decimals,
-		Fee:                ord.Fee,
-		PaymentExpiredTime: ord.PaymentExpiredTime,
-		ExpectedBlock:      ord.ExpectedBlock,
-	})
+	c.JSON(http.StatusOK, respOrd)
 }
 
 func (s *Arseeding) submitNativeData(c *gin.Context) {
 	apiKey := c.GetHeader("X-API-KEY")
-	if len(apiKey) == 0 {
-		errorResponse(c, "Wrong X-API-KEY")
-		return
+	uploadToken := c.GetHeader("X-UPLOAD-TOKEN")
+	var err error
+	if len(uploadToken) > 0 {
+		// data size is checked after the data received
+		if _, err = s.CheckUploadToken(uploadToken, c.Param("currency"), 0); err != nil {
+			errorResponse(c, err.Error())
+			return
+		}
+	} else {
+		if len(apiKey) == 0 {
+			errorResponse(c, "Wrong X-API-KEY")
+			return
+		}
+		if _, _, err = s.checkApiKey(apiKey); err != nil {
+			errorResponse(c, fmt.Sprintf("Wrong X-API-KEY: %s", err.Error()))
+			return
+		}
 	}
-	_, err := s.wdb.GetApiKeyDetail(apiKey)
+
+	needSort := isSortItems(c)
+	lane, err := parseLane(c.GetHeader("X-LANE"))
 	if err != nil {
-		errorResponse(c, fmt.Sprintf("Wrong X-API-KEY: %s", err.Error()))
+		errorResponse(c, err.Error())
 		return
 	}
-
-	// get all query and assemble tags
-	queryMap := c.Request.URL.Query()
-	// query key must include "Content-Type"
-	if _, ok := queryMap["Content-Type"]; !ok {
-		errorResponse(c, "Query params must include Content-Type")
+	if c.ContentType() == gin.MIMEMultipartPOSTForm {
+		s.submitNativeFormData(c, apiKey, uploadToken, needSort, lane)
 		return
 	}
-	needSort := isSortItems(c)
-	tags := make([]types.Tag, 0, len(queryMap))
-	for k, values := range queryMap {
-		for _, val := range values {
-			tags = append(tags, types.Tag{
-				Name:  k,
-				Value: val,
-			})
-		}
+
+	tags, err := nativeDataTags(c)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
 	}
 
 	if c.Request.Body == nil {
@@ -681,6 +723,7 @@ func (s *Arseeding) submitNativeData(c *gin.Context) {
 	}()
 	var dataBuf bytes.Buffer
 	var item types.BundleItem
+	var respItemId *schema.RespItemId
 	// write up to schema.AllowMaxNativeDataSize to memory
 	size, err := setItemData(c, dataFile, &dataBuf)
 	if err != nil && err != io.EOF {
@@ -691,6 +734,16 @@ func (s *Arseeding) submitNativeData(c *gin.Context) {
 		errorResponse(c, schema.ErrDataTooBig.Error())
 		return
 	}
+	currency := c.Param("currency")
+	if len(uploadToken) > 0 {
+		if apiKey, tags, err = s.UseDataUploadToken(uploadToken, currency, size, tags); err != nil {
+			errorResponse(c, err.Error())
+			return
+		}
+		defer func() {
+			s.ReleaseUploadToken(uploadToken, respItemId != nil)
+		}()
+	}
 
 	if size > schema.AllowStreamMinItemSize { // the body size > schema.AllowStreamMinItemSize, need write to tmp file
 		item, err = s.bundlerItemSigner.CreateAndSignItemStream(dataFile, "", "", tags)
@@ -703,26 +756,276 @@ func (s *Arseeding) submitNativeData(c *gin.Context) {
 		log.Error("s.bundlerItemSigner.CreateAndSignItem", "err", err)
 		return
 	}
+	respItemId, err = s.submitNativeItem(item, currency, apiKey, needSort, lane, size, c.GetHeader("X-FEE-QUOTE"))
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, respItemId)
+}
+
+// submitNativeFormData every file part of the multipart form will be signed as a bundle item,
+// the other form fields and query params are used as tags of all items
+func (s *Arseeding) submitNativeFormData(c *gin.Context, apiKey, uploadToken string, needSort bool, lane string) {
+	// reject the too big body before it is parsed
+	maxBodySize := int64(schema.SubmitMaxSize + schema.FormDataMaxOverhead)
+	if c.Request.ContentLength > maxBodySize {
+		errorResponse(c, schema.ErrDataTooBig.Error())
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize)
+	// files bigger than schema.AllowStreamMinItemSize are stored in tmp files
+	if err := c.Request.ParseMultipartForm(schema.AllowStreamMinItemSize); err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	form := c.Request.MultipartForm
+	defer form.RemoveAll()
+
+	// keep the items in the order of form field names
+	fields := make([]string, 0, len(form.File))
+	for field := range form.File {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	files := make([]*multipart.FileHeader, 0)
+	for _, field := range fields {
+		files = append(files, form.File[field]...)
+	}
+	if len(files) == 0 {
+		errorResponse(c, "can not submit null native data")
+		return
+	}
+	totalSize := int64(0)
+	for _, fh := range files {
+		if fh.Size == 0 {
+			errorResponse(c, fmt.Sprintf("file %s is null", fh.Filename))
+			return
+		}
+		totalSize += fh.Size
+	}
+	if totalSize > schema.SubmitMaxSize {
+		errorResponse(c, schema.ErrDataTooBig.Error())
+		return
+	}
+
 	currency := c.Param("currency")
-	// cal apikey balance
-	if err := s.processApikeySpendBal(currency, apiKey, size); err != nil {
+	tags := formDataTags(c.Request.URL.Query(), form.Value)
+	resp := schema.RespItemIds{Items: make([]schema.RespItemId, 0, len(files))}
+	if len(uploadToken) > 0 {
+		var err error
+		if apiKey, tags, err = s.UseDataUploadToken(uploadToken, currency, totalSize, tags); err != nil {
+			errorResponse(c, err.Error())
+			return
+		}
+		// the token is consumed once any item submitted
+		defer func() {
+			s.ReleaseUploadToken(uploadToken, len(resp.Items) > 0)
+		}()
+	}
+
+	// the items are submitted all or nothing
+	quoteId := c.GetHeader("X-FEE-QUOTE")
+	if len(quoteId) > 0 && len(files) > 1 {
+		errorResponse(c, "fee quote can only pay for one item")
+		return
+	}
+	sizes := make([]int64, 0, len(files))
+	for _, fh := range files {
+		sizes = append(sizes, fh.Size)
+	}
+	if err := s.checkApikeyFee(apiKey, currency, lane, quoteId, sizes); err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+
+	items := make([]types.BundleItem, 0, len(files))
+	for _, fh := range files {
+		item, err := s.signFormFile(fh, tags)
+		if err != nil {
+			errorResponse(c, err.Error())
+			return
+		}
+		items = append(items, item)
+	}
+
+	orders := make([]schema.Order, 0, len(items))
+	debits := make([]*schema.LedgerEntry, 0, len(items))
+	for i, item := range items {
+		order, debit, err := s.acceptNativeItem(item, currency, apiKey, needSort, lane, files[i].Size, quoteId)
+		if err != nil {
+			for j := range orders {
+				s.rollbackNativeItem(orders[j], debits[j])
+			}
+			errorResponse(c, fmt.Sprintf("submit file %s failed: %s", files[i].Filename, err.Error()))
+			return
+		}
+		orders = append(orders, order)
+		debits = append(debits, debit)
+	}
+	for _, order := range orders {
+		resp.Items = append(resp.Items, schema.RespItemId{ItemId: order.ItemId, Size: order.Size})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Arseeding) signFormFile(fh *multipart.FileHeader, tags []types.Tag) (item types.BundleItem, err error) {
+	file, err := fh.Open()
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	if getTagValue(tags, "Content-Type") == "" {
+		contentType, err := formFileContentType(fh, file)
+		if err != nil {
+			return item, err
+		}
+		tags = append([]types.Tag{{Name: "Content-Type", Value: contentType}}, tags...)
+	}
+
+	if fh.Size > schema.AllowStreamMinItemSize {
+		item, err = s.bundlerItemSigner.CreateAndSignItemStream(file, "", "", tags)
+	} else {
+		var data []byte
+		if data, err = io.ReadAll(file); err == nil {
+			item, err = s.bundlerItemSigner.CreateAndSignItem(data, "", "", tags)
+		}
+	}
+	if err != nil {
+		log.Error("s.bundlerItemSigner.CreateAndSignItem", "err", err, "file", fh.Filename)
+		return item, errors.New("assemble bundle item failed")
+	}
+	return
+}
+
+// formFileContentType use the part header first, then the file extension, then sniff the file content
+func formFileContentType(fh *multipart.FileHeader, file multipart.File) (string, error) {
+	if contentType := fh.Header.Get("Content-Type"); contentType != "" && contentType != "application/octet-stream" {
+		return contentType, nil
+	}
+	if contentType := mime.TypeByExtension(path.Ext(fh.Filename)); contentType != "" {
+		return contentType, nil
+	}
+	buf := make([]byte, 512)
+	n, err := file.Read(buf)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	// reset io stream to origin of the file
+	if _, err = file.Seek(0, 0); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+func (s *Arseeding) createUploadSession(c *gin.Context) {
+	// the parts are kept on disk until the session expired, only apikey owner can create session
+	apiKey := c.GetHeader("X-API-KEY")
+	if len(apiKey) == 0 {
+		errorResponse(c, "Wrong X-API-KEY")
+		return
+	}
+	if _, _, err := s.checkApiKey(apiKey); err != nil {
+		errorResponse(c, fmt.Sprintf("Wrong X-API-KEY: %s", err.Error()))
+		return
+	}
+	session, err := s.CreateUploadSession(schema.UploadSessionItem, c.Param("currency"), apiKey, isSortItems(c), nil)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	s.respUploadSession(c, session.SessionId)
+}
+
+func (s *Arseeding) createDataUploadSession(c *gin.Context) {
+	apiKey := c.GetHeader("X-API-KEY")
+	if len(apiKey) == 0 {
+		errorResponse(c, "Wrong X-API-KEY")
+		return
+	}
+	if _, _, err := s.checkApiKey(apiKey); err != nil {
+		errorResponse(c, fmt.Sprintf("Wrong X-API-KEY: %s", err.Error()))
+		return
+	}
+	tags, err := nativeDataTags(c)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	session, err := s.CreateUploadSession(schema.UploadSessionData, c.Param("currency"), apiKey, isSortItems(c), tags)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	s.respUploadSession(c, session.SessionId)
+}
+
+func (s *Arseeding) putUploadPart(c *gin.Context) {
+	partNum, err := strconv.Atoi(c.Param("partNum"))
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	if c.Request.Body == nil {
+		errorResponse(c, "can not submit null upload part")
+		return
+	}
+	defer c.Request.Body.Close()
+
+	part, err := s.SaveUploadPart(c.Param("sessionId"), partNum, c.Request.Body)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			notFoundResponse(c, "upload session not found")
+			return
+		}
 		errorResponse(c, err.Error())
 		return
 	}
+	c.JSON(http.StatusOK, part)
+}
+
+func (s *Arseeding) getUploadSession(c *gin.Context) {
+	s.respUploadSession(c, c.Param("sessionId"))
+}
 
-	// process submit item
-	order, err := s.ProcessSubmitItem(item, currency, true, apiKey, needSort, size)
+func (s *Arseeding) finalizeUploadSession(c *gin.Context) {
+	resp, err := s.FinalizeUploadSession(c.Param("sessionId"))
 	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			notFoundResponse(c, "upload session not found")
+			return
+		}
 		errorResponse(c, err.Error())
 		return
 	}
+	c.JSON(http.StatusOK, resp)
+}
 
-	c.JSON(http.StatusOK, schema.RespItemId{ItemId: order.ItemId, Size: order.Size})
+func (s *Arseeding) respUploadSession(c *gin.Context, sessionId string) {
+	resp, err := s.GetUploadSession(sessionId)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			notFoundResponse(c, "upload session not found")
+			return
+		}
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 func (s *Arseeding) getOrdersByApiKey(c *gin.Context) {
 	apiKey := c.GetHeader("X-API-KEY")
-	_, err := s.wdb.GetApiKeyDetail(apiKey)
+	if authAddr := c.GetString("authAddress"); len(apiKey) == 0 && len(authAddr) > 0 {
+		detail, err := s.wdb.GetApiKeyDetailByAddress(authAddr)
+		if err != nil {
+			notFoundResponse(c, "apikey not exist")
+			return
+		}
+		apiKey = detail.ApiKey
+	}
+	_, _, err := s.checkApiKey(apiKey)
 	if err != nil {
 		errorResponse(c, "Wrong X-API-KEY")
 		return
@@ -763,6 +1066,7 @@ func (s *Arseeding) getOrdersByApiKey(c *gin.Context) {
 				Fee:                od.Fee,
 				PaymentExpiredTime: od.PaymentExpiredTime,
 				ExpectedBlock:      od.ExpectedBlock,
+				Lane:               od.Lane,
 			},
 			PaymentStatus: od.PaymentStatus,
 			PaymentId:     od.PaymentId,
@@ -820,6 +1124,38 @@ func (s *Arseeding) getItemField(c *gin.Context) {
 	}
 }
 
+func (s *Arseeding) getItemProof(c *gin.Context) {
+	itemId := c.Param("itemId")
+	proof, err := s.GetItemProof(itemId)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			notFoundResponse(c, "item not on chain")
+			return
+		}
+		if err == schema.ErrBundleSyncing {
+			c.JSON(http.StatusServiceUnavailable, schema.RespErr{Err: err.Error()})
+			return
+		}
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, proof)
+}
+
+func (s *Arseeding) getUploadReceipt(c *gin.Context) {
+	itemId := c.Param("itemId")
+	receipt, err := s.GetUploadReceipt(itemId)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			notFoundResponse(c, "receipt not found")
+			return
+		}
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, receipt)
+}
+
 func (s *Arseeding) getItemIdsByArId(c *gin.Context) {
 	arId := c.Param("arId")
 	itemIds, err := s.store.LoadArIdToItemIds(arId)
@@ -838,42 +1174,141 @@ func (s *Arseeding) bundleFee(c *gin.Context) {
 		errorResponse(c, err.Error())
 		return
 	}
-	respFee, err := s.CalcItemFee(symbol, int64(numSize))
+	// return the effective price of the apikey pricing plan
+	owner := ""
+	if apiKey := c.GetHeader("X-API-KEY"); len(apiKey) > 0 {
+		detail, _, err := s.checkApiKey(apiKey)
+		if err != nil {
+			errorResponse(c, err.Error())
+			return
+		}
+		owner = detail.Address
+	}
+	respFee, err := s.CalcPlanItemFee(symbol, int64(numSize), owner)
 	if err != nil {
 		internalErrorResponse(c, err.Error())
 		return
 	}
+	if err = s.quoteExpressFee(respFee); err != nil {
+		internalErrorResponse(c, err.Error())
+		return
+	}
 	c.JSON(http.StatusOK, respFee)
 }
 
-func (s *Arseeding) getOrders(c *gin.Context) {
-	signer := c.Param("signer")
-	_, signerAddr, err := account.IDCheck(signer)
+func (s *Arseeding) createFeeQuote(c *gin.Context) {
+	size, err := strconv.ParseInt(c.Param("size"), 10, 64)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	// the quote can only be used by the apikey or the item signer
+	owner := ""
+	if apiKey := c.GetHeader("X-API-KEY"); len(apiKey) > 0 {
+		detail, _, err := s.checkApiKey(apiKey)
+		if err != nil {
+			errorResponse(c, err.Error())
+			return
+		}
+		owner = detail.Address
+	} else if signer := c.Query("signer"); len(signer) > 0 {
+		_, accId, err := account.IDCheck(signer)
+		if err != nil {
+			errorResponse(c, err.Error())
+			return
+		}
+		owner = accId
+	} else {
+		errorResponse(c, "http header X-API-KEY or query signer is required")
+		return
+	}
+	quote, err := s.CreateFeeQuote(c.Param("currency"), size, owner)
 	if err != nil {
 		errorResponse(c, err.Error())
 		return
 	}
+	c.JSON(http.StatusOK, quote)
+}
 
-	cursorId, err := strconv.ParseInt(c.DefaultQuery("cursorId", "0"), 10, 64)
+func (s *Arseeding) createInvoice(c *gin.Context) {
+	req := schema.ReqCreateInvoice{}
+	body, err := ioutil.ReadAll(c.Request.Body)
 	if err != nil {
 		errorResponse(c, err.Error())
 		return
 	}
-	num, err := strconv.ParseInt(c.DefaultQuery("num", "20"), 10, 64)
+	if err = json.Unmarshal(body, &req); err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	invoice, err := s.CreateInvoice(req)
 	if err != nil {
 		errorResponse(c, err.Error())
 		return
 	}
+	c.JSON(http.StatusOK, invoice)
+}
 
-	orders, err := s.wdb.GetOrdersBySigner(signerAddr, cursorId, int(num))
+func (s *Arseeding) getInvoice(c *gin.Context) {
+	invoice, err := s.GetInvoice(c.Param("invoiceId"))
 	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			notFoundResponse(c, "invoice not found")
+			return
+		}
 		internalErrorResponse(c, err.Error())
 		return
 	}
-	results := make([]schema.RespGetOrder, 0, len(orders))
-	for _, od := range orders {
-		results = append(results, schema.RespGetOrder{
-			ID: od.ID,
+	c.JSON(http.StatusOK, invoice)
+}
+
+func (s *Arseeding) cancelOrder(c *gin.Context) {
+	authAddr := c.GetString("authAddress")
+	if len(authAddr) == 0 {
+		c.JSON(http.StatusUnauthorized, schema.RespErr{Err: "Authorization can not be null"})
+		return
+	}
+	if err := s.CancelOrder(authAddr, c.Param("itemId")); err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, "ok")
+}
+
+func (s *Arseeding) getOrders(c *gin.Context) {
+	signer := c.Param("signer")
+	_, signerAddr, err := account.IDCheck(signer)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	// the orders can be read without login session until the legacy auth is disabled
+	if len(c.GetString("authAddress")) > 0 || !s.legacyAuthEnabled(c) {
+		if !checkAuthAddress(c, signerAddr) {
+			return
+		}
+	}
+
+	cursorId, err := strconv.ParseInt(c.DefaultQuery("cursorId", "0"), 10, 64)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	num, err := strconv.ParseInt(c.DefaultQuery("num", "20"), 10, 64)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+
+	orders, err := s.wdb.GetOrdersBySigner(signerAddr, cursorId, int(num))
+	if err != nil {
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	results := make([]schema.RespGetOrder, 0, len(orders))
+	for _, od := range orders {
+		results = append(results, schema.RespGetOrder{
+			ID: od.ID,
 			RespOrder: schema.RespOrder{
 				ItemId:             od.ItemId,
 				Size:               od.Size,
@@ -883,6 +1318,7 @@ func (s *Arseeding) getOrders(c *gin.Context) {
 				Fee:                od.Fee,
 				PaymentExpiredTime: od.PaymentExpiredTime,
 				ExpectedBlock:      od.ExpectedBlock,
+				Lane:               od.Lane,
 			},
 			PaymentStatus: od.PaymentStatus,
 			PaymentId:     od.PaymentId,
@@ -894,7 +1330,13 @@ func (s *Arseeding) getOrders(c *gin.Context) {
 }
 
 func (s *Arseeding) bundleFees(c *gin.Context) {
-	c.JSON(http.StatusOK, s.bundlePerFeeMap)
+	surcharge := s.expressSurcharge()
+	fees := make(map[string]schema.Fee, len(s.bundlePerFeeMap))
+	for currency, fee := range s.bundlePerFeeMap {
+		fee.ExpressSurcharge = surcharge
+		fees[currency] = fee
+	}
+	c.JSON(http.StatusOK, fees)
 }
 
 func (s *Arseeding) dataRoute(c *gin.Context) {
@@ -1011,6 +1453,47 @@ func getTagValue(tags []types.Tag, name string) string {
 	return ""
 }
 
+// nativeDataTags assemble item tags by all query params
+func nativeDataTags(c *gin.Context) ([]types.Tag, error) {
+	queryMap := c.Request.URL.Query()
+	// query key must include "Content-Type"
+	if _, ok := queryMap["Content-Type"]; !ok {
+		return nil, errors.New("Query params must include Content-Type")
+	}
+	tags := make([]types.Tag, 0, len(queryMap))
+	for k, values := range queryMap {
+		for _, val := range values {
+			tags = append(tags, types.Tag{
+				Name:  k,
+				Value: val,
+			})
+		}
+	}
+	return tags, nil
+}
+
+// formDataTags assemble item tags by query params and form fields
+func formDataTags(query, fields map[string][]string) []types.Tag {
+	tags := make([]types.Tag, 0, len(query)+len(fields))
+	for _, kvs := range []map[string][]string{query, fields} {
+		// the tags are in the order of names, so the same upload has the same item id
+		names := make([]string, 0, len(kvs))
+		for k := range kvs {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			for _, val := range kvs[k] {
+				tags = append(tags, types.Tag{
+					Name:  k,
+					Value: val,
+				})
+			}
+		}
+	}
+	return tags
+}
+
 func isSortItems(c *gin.Context) bool {
 	if c.GetHeader("Sort") == "true" || c.GetHeader("sort") == "true" {
 		return true
@@ -1237,7 +1720,12 @@ func (s *Arseeding) getApiKeyInfo(c *gin.Context) {
 	})
 }
 
+// getApiKey deprecated: the signed timestamp can be replayed in 60s, use getSessionApiKey instead
 func (s *Arseeding) getApiKey(c *gin.Context) {
+	if !s.legacyAuthEnabled(c) {
+		c.JSON(http.StatusGone, schema.RespErr{Err: "deprecated, login and use /auth/apikey instead"})
+		return
+	}
 	timestamp := c.Param("timestamp")
 	signature := c.Param("signature")
 	timestampNum, err := strconv.ParseInt(timestamp, 10, 64)
@@ -1262,9 +1750,283 @@ func (s *Arseeding) getApiKey(c *gin.Context) {
 		internalErrorResponse(c, err.Error())
 		return
 	}
+	if detail.Revoked {
+		errorResponse(c, "apikey has been revoked, rotate it to get a new one")
+		return
+	}
+	c.JSON(http.StatusOK, detail.ApiKey)
+}
+
+// legacyAuthEnabled the deprecated access without login session is kept until it is disabled by config param
+func (s *Arseeding) legacyAuthEnabled(c *gin.Context) bool {
+	if s.config.Param.DisableLegacyAuth {
+		return false
+	}
+	c.Header("Deprecation", "true")
+	log.Warn("deprecated access without login session, it will be removed", "path", c.FullPath(), "ip", c.ClientIP())
+	return true
+}
+
+func (s *Arseeding) getAuthNonce(c *gin.Context) {
+	nonce, err := s.CreateAuthNonce(c.Param("address"), c.DefaultQuery("sigType", schema.SigTypeEthereum))
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, nonce)
+}
+
+func (s *Arseeding) authLogin(c *gin.Context) {
+	if c.Request.Body == nil {
+		errorResponse(c, "request body can not be null")
+		return
+	}
+	by, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	defer c.Request.Body.Close()
+
+	req := schema.ReqAuthLogin{}
+	if err = json.Unmarshal(by, &req); err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	session, err := s.Login(req)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, schema.RespErr{Err: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+func (s *Arseeding) authLogout(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if len(token) == 0 {
+		errorResponse(c, "Authorization can not be null")
+		return
+	}
+	if err := s.wdb.DelAuthSession(token); err != nil {
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, "ok")
+}
+
+// getSessionApiKey return the apikey of the login wallet
+func (s *Arseeding) getSessionApiKey(c *gin.Context) {
+	authAddr := c.GetString("authAddress")
+	if len(authAddr) == 0 {
+		c.JSON(http.StatusUnauthorized, schema.RespErr{Err: "Authorization can not be null"})
+		return
+	}
+	detail, err := s.wdb.GetApiKeyDetailByAddress(authAddr)
+	if err != nil {
+		notFoundResponse(c, "apikey not exist")
+		return
+	}
+	if detail.Revoked {
+		errorResponse(c, "apikey has been revoked, rotate it to get a new one")
+		return
+	}
 	c.JSON(http.StatusOK, detail.ApiKey)
 }
 
+func (s *Arseeding) rotateApiKey(c *gin.Context) {
+	addr := c.GetString("authAddress")
+	newKey, err := s.RotateApiKey(addr)
+	if err != nil {
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, schema.RespApiKeyAction{Address: addr, ApiKey: newKey})
+}
+
+func (s *Arseeding) revokeApiKey(c *gin.Context) {
+	addr := c.GetString("authAddress")
+	if err := s.RevokeApiKey(addr); err != nil {
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, schema.RespApiKeyAction{Address: addr})
+}
+
+func (s *Arseeding) createApiSubKey(c *gin.Context) {
+	addr := c.GetString("authAddress")
+	req, ok := bindSubKeyReq(c)
+	if !ok {
+		return
+	}
+	subKey, err := s.CreateApiSubKey(addr, req)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, schema.RespApiKeyAction{Address: addr, SubKey: subKey.SubKey, SubKeys: []schema.ApiSubKey{subKey}})
+}
+
+func (s *Arseeding) revokeApiSubKey(c *gin.Context) {
+	addr := c.GetString("authAddress")
+	req, ok := bindSubKeyReq(c)
+	if !ok {
+		return
+	}
+	if err := s.RevokeApiSubKey(addr, req.Name); err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, schema.RespApiKeyAction{Address: addr})
+}
+
+func (s *Arseeding) getApiSubKeys(c *gin.Context) {
+	addr := c.GetString("authAddress")
+	subKeys, err := s.wdb.GetApiSubKeys(addr)
+	if err != nil {
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, schema.RespApiKeyAction{Address: addr, SubKeys: subKeys})
+}
+
+func bindSubKeyReq(c *gin.Context) (schema.ReqSubKey, bool) {
+	req := schema.ReqSubKey{}
+	if c.Request.Body == nil {
+		errorResponse(c, "request body can not be null")
+		return req, false
+	}
+	by, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return req, false
+	}
+	defer c.Request.Body.Close()
+
+	if err = json.Unmarshal(by, &req); err != nil {
+		errorResponse(c, err.Error())
+		return req, false
+	}
+	return req, true
+}
+
+func (s *Arseeding) getApikeyLedger(c *gin.Context) {
+	address := c.Param("address")
+	_, addr, err := account.IDCheck(address)
+	if err != nil {
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	if !checkAuthAddress(c, addr) {
+		return
+	}
+
+	cursorId, err := strconv.ParseInt(c.DefaultQuery("cursorId", "0"), 10, 64)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	num, err := strconv.ParseInt(c.DefaultQuery("num", "20"), 10, 64)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	if num <= 0 || num > 200 {
+		errorResponse(c, "num must be in range (0, 200]")
+		return
+	}
+
+	entries, err := s.wdb.GetLedgerEntries(addr, cursorId, int(num))
+	if err != nil {
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	respEntries := make([]schema.RespLedgerEntry, 0, len(entries))
+	for _, entry := range entries {
+		decimals := 0
+		if perFee := s.GetPerFee(entry.Currency); perFee != nil {
+			decimals = perFee.Decimals
+		}
+		respEntries = append(respEntries, schema.RespLedgerEntry{LedgerEntry: entry, Decimals: decimals})
+	}
+	c.JSON(http.StatusOK, respEntries)
+}
+
+func (s *Arseeding) getApikeyStatement(c *gin.Context) {
+	address := c.Param("address")
+	_, addr, err := account.IDCheck(address)
+	if err != nil {
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	if !checkAuthAddress(c, addr) {
+		return
+	}
+
+	st, err := s.GetBillingStatement(addr, c.Param("month"))
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	switch c.DefaultQuery("format", schema.StatementFormatJSON) {
+	case schema.StatementFormatJSON:
+		c.JSON(http.StatusOK, st)
+	case schema.StatementFormatCSV:
+		data, err := billingStatementCSV(*st)
+		if err != nil {
+			internalErrorResponse(c, err.Error())
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=statement-%s-%s.csv", st.Address, st.Month))
+		c.Data(http.StatusOK, "text/csv", data)
+	default:
+		errorResponse(c, "format must be json or csv")
+	}
+}
+
+func (s *Arseeding) createUploadToken(c *gin.Context) {
+	apiKey := c.GetHeader("X-API-KEY")
+	if len(apiKey) == 0 {
+		errorResponse(c, "Wrong X-API-KEY")
+		return
+	}
+	if _, _, err := s.checkApiKey(apiKey); err != nil {
+		errorResponse(c, fmt.Sprintf("Wrong X-API-KEY: %s", err.Error()))
+		return
+	}
+	if c.Request.Body == nil {
+		errorResponse(c, "request body can not be null")
+		return
+	}
+	by, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	defer c.Request.Body.Close()
+
+	req := schema.ReqUploadToken{}
+	if err = json.Unmarshal(by, &req); err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	token, err := s.CreateUploadToken(apiKey, req)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	tags := req.Tags
+	if tags == nil {
+		tags = make([]types.Tag, 0)
+	}
+	c.JSON(http.StatusOK, schema.RespUploadToken{
+		Token:       token.Token,
+		Currency:    token.Currency,
+		MaxSize:     token.MaxSize,
+		Tags:        tags,
+		ExpiredTime: token.ExpiredTime,
+	})
+}
+
 func (s *Arseeding) getApikeyDepositRecords(c *gin.Context) {
 	address := c.Param("address")
 	_, addr, err := account.IDCheck(address)
@@ -1272,6 +2034,9 @@ func (s *Arseeding) getApikeyDepositRecords(c *gin.Context) {
 		internalErrorResponse(c, err.Error())
 		return
 	}
+	if !checkAuthAddress(c, addr) {
+		return
+	}
 
 	rawId, err := strconv.ParseInt(c.DefaultQuery("rawId", "0"), 10, 64)
 	if err != nil {
@@ -1339,3 +2104,171 @@ func (s *Arseeding) getOrderStatisticByDate(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, results)
 }
+
+func (s *Arseeding) getEconomicsReport(c *gin.Context) {
+	if s.NoFee {
+		errorResponse(c, "the bundler charges no fee")
+		return
+	}
+	report, err := s.BuildEconomicsReport(c.Query("start"), c.Query("end"))
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func parseAdminPage(c *gin.Context) (cursorId int64, num int, ok bool) {
+	cursorId, err := strconv.ParseInt(c.DefaultQuery("cursorId", "0"), 10, 64)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("num", "20"))
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	if pageSize <= 0 || pageSize > schema.MaxAdminPageSize {
+		errorResponse(c, fmt.Sprintf("num must be in range (0, %d]", schema.MaxAdminPageSize))
+		return
+	}
+	return cursorId, pageSize, true
+}
+
+func (s *Arseeding) adminGetReceipts(c *gin.Context) {
+	cursorId, num, ok := parseAdminPage(c)
+	if !ok {
+		return
+	}
+	rpts, err := s.wdb.GetReceiptsByStatusPage(c.DefaultQuery("status", schema.RefundErr), cursorId, num)
+	if err != nil {
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, rpts)
+}
+
+func (s *Arseeding) adminRetryRefund(c *gin.Context) {
+	refundHash, err := s.AdminRetryRefund(c.GetString("authAddress"), c.Param("everHash"))
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"refundHash": refundHash})
+}
+
+func (s *Arseeding) adminManualRefund(c *gin.Context) {
+	req := schema.ReqAdminManualRefund{}
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	if err = json.Unmarshal(body, &req); err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	if err = s.AdminManualRefund(c.GetString("authAddress"), c.Param("everHash"), req); err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, "ok")
+}
+
+func (s *Arseeding) adminCredit(c *gin.Context) {
+	req := schema.ReqAdminCredit{}
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, &req); err != nil {
+			errorResponse(c, err.Error())
+			return
+		}
+	}
+	address, err := s.AdminCredit(c.GetString("authAddress"), c.Param("everHash"), req)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"address": address})
+}
+
+func (s *Arseeding) adminUsdCredit(c *gin.Context) {
+	req := schema.ReqAdminUsdCredit{}
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	if err = json.Unmarshal(body, &req); err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	balance, err := s.AdminUsdCredit(c.GetString("authAddress"), c.Param("address"), req)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"currency": schema.UsdCurrency, "decimals": schema.UsdDecimals, "balance": balance})
+}
+
+func (s *Arseeding) adminSetPrice(c *gin.Context) {
+	req := schema.ReqAdminSetPrice{}
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	if err = json.Unmarshal(body, &req); err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	if err = s.AdminSetPrice(c.GetString("authAddress"), c.Param("symbol"), req); err != nil {
+		errorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, "ok")
+}
+
+func (s *Arseeding) adminGetFeeSweeps(c *gin.Context) {
+	cursorId, num, ok := parseAdminPage(c)
+	if !ok {
+		return
+	}
+	sweeps, err := s.wdb.GetFeeSweeps(cursorId, num)
+	if err != nil {
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, sweeps)
+}
+
+func (s *Arseeding) adminDryRunFeeSweep(c *gin.Context) {
+	if s.NoFee {
+		errorResponse(c, "fee is not collected in no fee mode")
+		return
+	}
+	plans, err := s.PlanFeeSweeps()
+	if err != nil {
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, plans)
+}
+
+func (s *Arseeding) adminGetAudits(c *gin.Context) {
+	cursorId, num, ok := parseAdminPage(c)
+	if !ok {
+		return
+	}
+	audits, err := s.wdb.GetAdminAudits(c.Query("target"), cursorId, num)
+	if err != nil {
+		internalErrorResponse(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, audits)
+}
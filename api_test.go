@@ -0,0 +1,54 @@
+package arseeding
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"mime/multipart"
+	"net/http"
+	"testing"
+)
+
+func TestFormFileContentType(t *testing.T) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	files := map[string][]byte{
+		"a.json":  []byte(`{"a":1}`),
+		"b":       []byte("<html><body>arseeding</body></html>"),
+		"c.notex": []byte("plain text"),
+	}
+	for name, data := range files {
+		part, err := writer.CreateFormFile("file", name)
+		assert.NoError(t, err)
+		_, err = part.Write(data)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.WriteField("App-Name", "arseeding"))
+	assert.NoError(t, writer.WriteField("App-Version", "1.0"))
+	assert.NoError(t, writer.Close())
+
+	req, err := http.NewRequest(http.MethodPost, "/bundle/data/usdc?Content-Type=text/plain", body)
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	assert.NoError(t, req.ParseMultipartForm(1024))
+
+	expected := map[string]string{
+		"a.json":  "application/json",
+		"b":       "text/html; charset=utf-8",
+		"c.notex": "text/plain; charset=utf-8",
+	}
+	for _, fh := range req.MultipartForm.File["file"] {
+		file, err := fh.Open()
+		assert.NoError(t, err)
+		contentType, err := formFileContentType(fh, file)
+		assert.NoError(t, err)
+		assert.Equal(t, expected[fh.Filename], contentType)
+		file.Close()
+	}
+
+	tags := formDataTags(req.URL.Query(), req.MultipartForm.Value)
+	assert.Equal(t, 3, len(tags))
+	assert.Equal(t, "text/plain", getTagValue(tags, "Content-Type"))
+	// the form values are in the order of names
+	assert.Equal(t, "App-Name", tags[1].Name)
+	assert.Equal(t, "App-Version", tags[2].Name)
+}
@@ -0,0 +1,161 @@
+package arseeding
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// newEncryptedApiKey generate a new apikey and encrypt it with the owner public key
+func newEncryptedApiKey(public string) (apiKey, encryptedKey string, err error) {
+	newKey, err := uuid.NewUUID()
+	if err != nil {
+		return
+	}
+	publicKey, err := crypto.UnmarshalPubkey(common.Hex2Bytes(public))
+	if err != nil {
+		return
+	}
+	encPub, err := ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(publicKey), []byte(newKey.String()), nil, nil)
+	if err != nil {
+		return
+	}
+	return newKey.String(), common.Bytes2Hex(encPub), nil
+}
+
+// checkApiKey return the apikey detail, and the sub-key if the key is a sub-key
+func (s *Arseeding) checkApiKey(key string) (schema.AutoApiKey, *schema.ApiSubKey, error) {
+	detail, err := s.wdb.GetApiKeyDetail(key)
+	if err == nil {
+		if detail.Revoked {
+			return detail, nil, errors.New("apikey has been revoked")
+		}
+		return detail, nil, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return detail, nil, err
+	}
+
+	subKey, err := s.wdb.GetApiSubKey(key)
+	if err != nil {
+		return detail, nil, err
+	}
+	if subKey.Revoked {
+		return detail, nil, errors.New("sub-key has been revoked")
+	}
+	if subKey.ExpiredTime > 0 && subKey.ExpiredTime < time.Now().Unix() {
+		return detail, nil, errors.New("sub-key expired")
+	}
+	detail, err = s.wdb.GetApiKeyDetailByAddress(subKey.Address)
+	return detail, &subKey, err
+}
+
+// RotateApiKey replace the apikey with a new one, the old apikey is invalid immediately,
+// the orders of old apikey are moved to the new one
+func (s *Arseeding) RotateApiKey(addr string) (string, error) {
+	detail, err := s.wdb.GetApiKeyDetailByAddress(addr)
+	if err != nil {
+		return "", err
+	}
+	newKey, encKey, err := newEncryptedApiKey(detail.PubKey)
+	if err != nil {
+		return "", err
+	}
+	return newKey, s.wdb.RotateApiKey(addr, newKey, encKey)
+}
+
+// RevokeApiKey the sub-keys are not affected, they can be revoked one by one
+func (s *Arseeding) RevokeApiKey(addr string) error {
+	if _, err := s.wdb.GetApiKeyDetailByAddress(addr); err != nil {
+		return err
+	}
+	return s.wdb.RevokeApiKey(addr)
+}
+
+func (s *Arseeding) CreateApiSubKey(addr string, req schema.ReqSubKey) (schema.ApiSubKey, error) {
+	if _, err := s.wdb.GetApiKeyDetailByAddress(addr); err != nil {
+		return schema.ApiSubKey{}, err
+	}
+	if len(req.Name) == 0 || len(req.Name) > 64 {
+		return schema.ApiSubKey{}, errors.New("sub-key name length must be in range [1, 64]")
+	}
+	if _, err := s.wdb.GetApiSubKeyByName(addr, req.Name); err == nil {
+		return schema.ApiSubKey{}, fmt.Errorf("sub-key %s already exist", req.Name)
+	}
+	if req.ExpiredTime != 0 && req.ExpiredTime < time.Now().Unix() {
+		return schema.ApiSubKey{}, errors.New("expiredTime must be later than now")
+	}
+
+	var currenciesJs []byte
+	if len(req.Currencies) > 0 {
+		currencies := make([]string, 0, len(req.Currencies))
+		for _, currency := range req.Currencies {
+			if s.GetPerFee(currency) == nil {
+				return schema.ApiSubKey{}, fmt.Errorf("not support currency: %s", currency)
+			}
+			currencies = append(currencies, strings.ToUpper(currency))
+		}
+		var err error
+		if currenciesJs, err = json.Marshal(currencies); err != nil {
+			return schema.ApiSubKey{}, err
+		}
+	}
+	spendCap := make(map[string]interface{}, len(req.SpendCap))
+	for currency, amount := range req.SpendCap {
+		amountDe, err := decimal.NewFromString(amount)
+		if err != nil || amountDe.IsNegative() {
+			return schema.ApiSubKey{}, fmt.Errorf("invalid spend cap of %s: %s", currency, amount)
+		}
+		spendCap[strings.ToUpper(currency)] = amountDe.String()
+	}
+
+	newKey, err := uuid.NewUUID()
+	if err != nil {
+		return schema.ApiSubKey{}, err
+	}
+	subKey := schema.ApiSubKey{
+		SubKey:      newKey.String(),
+		Prefix:      newKey.String()[:schema.SubKeyPrefixLen],
+		Address:     addr,
+		Name:        req.Name,
+		Currencies:  currenciesJs,
+		SpendCap:    spendCap,
+		Spent:       map[string]interface{}{},
+		ExpiredTime: req.ExpiredTime,
+	}
+	return subKey, s.wdb.InsertApiSubKey(subKey)
+}
+
+func (s *Arseeding) RevokeApiSubKey(addr, name string) error {
+	if _, err := s.wdb.GetApiSubKeyByName(addr, name); err != nil {
+		return err
+	}
+	return s.wdb.RevokeApiSubKey(addr, name)
+}
+
+// checkSubKeyCurrency the sub-key can only spend the allowed currencies
+func checkSubKeyCurrency(subKey schema.ApiSubKey, currency string) error {
+	currencies, err := subKey.AllowedCurrencies()
+	if err != nil {
+		return err
+	}
+	if len(currencies) == 0 {
+		return nil
+	}
+	for _, c := range currencies {
+		if strings.EqualFold(c, currency) {
+			return nil
+		}
+	}
+	return fmt.Errorf("sub-key %s not allow currency: %s", subKey.Name, currency)
+}
@@ -0,0 +1,88 @@
+package arseeding
+
+import (
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goether"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestApiKeyLifecycle(t *testing.T) {
+	sqliteDir := "./data/apikey"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	aa := &Arseeding{wdb: wdb}
+
+	signer, err := goether.NewSigner("2b8258cde747e3820e56a40aec5cd473150c6078819b45afe61baaf1fa1c75e6") // for test
+	assert.NoError(t, err)
+	addr := signer.Address.String()
+	apiKey, encKey, err := newEncryptedApiKey(common.Bytes2Hex(signer.GetPublicKey()))
+	assert.NoError(t, err)
+	assert.NoError(t, wdb.InsertApiKey(schema.AutoApiKey{
+		ApiKey:       apiKey,
+		EncryptedKey: encKey,
+		Address:      addr,
+		PubKey:       common.Bytes2Hex(signer.GetPublicKey()),
+		TokenBalance: map[string]interface{}{},
+	}))
+	_, err = postLedgerEntryTx(wdb, addr, "USDC", schema.LedgerCredit, decimal.NewFromInt(100), "everHash-1", nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-0", ApiKey: apiKey}))
+
+	// rotate and revoke, the orders are moved to the new apikey
+	newKey, err := aa.RotateApiKey(addr)
+	assert.NoError(t, err)
+	ords, err := wdb.GetOrdersByApiKey(newKey, 0, 10, "ASC")
+	assert.NoError(t, err)
+	assert.Len(t, ords, 1)
+	_, _, err = aa.checkApiKey(apiKey)
+	assert.Error(t, err)
+	_, _, err = aa.checkApiKey(newKey)
+	assert.NoError(t, err)
+	assert.NoError(t, aa.RevokeApiKey(addr))
+	_, _, err = aa.checkApiKey(newKey)
+	assert.Error(t, err)
+
+	// sub-key draw on the parent balance with spend cap
+	subKey, err := aa.CreateApiSubKey(addr, schema.ReqSubKey{Name: "ci", SpendCap: map[string]string{"usdc": "50"}})
+	assert.NoError(t, err)
+	_, err = aa.CreateApiSubKey(addr, schema.ReqSubKey{Name: "ci"})
+	assert.Error(t, err)
+	assert.Error(t, wdb.InsertApiSubKey(schema.ApiSubKey{SubKey: "sub-key-dup", Address: addr, Name: "ci"}))
+	assert.Equal(t, subKey.SubKey[:schema.SubKeyPrefixLen], subKey.Prefix)
+	js, err := json.Marshal(subKey)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(js), subKey.SubKey)
+	detail, sk, err := aa.checkApiKey(subKey.SubKey)
+	assert.NoError(t, err)
+	assert.Equal(t, addr, detail.Address)
+	assert.Equal(t, "ci", sk.Name)
+
+	debit, err := postSubKeyDebit(wdb, subKey.SubKey, "usdc", decimal.NewFromInt(30), "item-1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "70", debit.Balance)
+	assert.Equal(t, "ci", debit.SubKey)
+	_, err = postSubKeyDebit(wdb, subKey.SubKey, "usdc", decimal.NewFromInt(30), "item-2", nil)
+	assert.Error(t, err) // exceeds the spend cap
+	assert.NoError(t, refundLedgerEntry(wdb, debit))
+	sk2, err := wdb.GetApiSubKey(subKey.SubKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "0", sk2.Spent["USDC"])
+	detail, err = wdb.GetApiKeyDetailByAddress(addr)
+	assert.NoError(t, err)
+	assert.Equal(t, "100", detail.TokenBalance["USDC"])
+
+	assert.NoError(t, checkSubKeyCurrency(sk2, "usdc"))
+	sk2.Currencies = []byte(`["AR"]`)
+	assert.Error(t, checkSubKeyCurrency(sk2, "usdc"))
+
+	assert.NoError(t, aa.RevokeApiSubKey(addr, "ci"))
+	_, _, err = aa.checkApiKey(subKey.SubKey)
+	assert.Error(t, err)
+}
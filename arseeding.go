@@ -19,6 +19,8 @@ import (
 	"time"
 )
 
+const Version = "v1.2.0"
+
 var log = common.NewLog("arseeding")
 
 type Arseeding struct {
@@ -38,8 +40,10 @@ type Arseeding struct {
 	// ANS-104 bundle
 	arseedCli           *sdk.ArSeedCli
 	everpaySdk          *paySdk.SDK
+	paymentProviders    map[string]PaymentProvider // key: provider name
 	wdb                 *Wdb
-	bundler             *goar.Wallet
+	bundler             *goar.Wallet // receives payments
+	bundlerPool         *bundlerPool // signs the bundle arTxs, includes bundler
 	bundlerItemSigner   *goar.ItemSigner
 	NoFee               bool // if true, means no bundle fee; default false
 	EnableManifest      bool
@@ -47,6 +51,7 @@ type Arseeding struct {
 	paymentExpiredRange int64                 // default
 	expectedRange       int64                 // default 50 block
 	customTags          []types.Tag
+	bundleTagsTemplates bundleTagsTemplates // the last valid bundle tags of config
 	locker              sync.RWMutex
 	localCache          *cache.Cache
 }
@@ -58,6 +63,7 @@ func New(
 	use4EVER bool, useAliyun bool, aliyunEndpoint, aliyunAccKey, aliyunSecretKey, aliyunPrefix string,
 	useMongoDb bool, mongodbUri string,
 	port string, customTags []types.Tag, useKafka bool, kafkaUri string,
+	enableArPayment bool, poolKeyPaths []string,
 ) *Arseeding {
 	var err error
 	KVDb := &Store{}
@@ -95,10 +101,21 @@ func New(
 	if err = wdb.Migrate(noFee, enableManifest); err != nil {
 		panic(err)
 	}
+	if err = postOpeningBalances(wdb); err != nil {
+		panic(err)
+	}
 	bundler, err := goar.NewWalletFromPath(arWalletKeyPath, arNode)
 	if err != nil {
 		panic(err)
 	}
+	poolWallets := []*goar.Wallet{bundler}
+	for _, keyPath := range poolKeyPaths {
+		w, err := goar.NewWalletFromPath(keyPath, arNode)
+		if err != nil {
+			panic(err)
+		}
+		poolWallets = append(poolWallets, w)
+	}
 
 	itemSigner, err := goar.NewItemSigner(bundler.Signer)
 	if err != nil {
@@ -123,6 +140,7 @@ func New(
 		everpaySdk:          everpaySdk,
 		wdb:                 wdb,
 		bundler:             bundler,
+		bundlerPool:         newBundlerPool(poolWallets...),
 		bundlerItemSigner:   itemSigner,
 		NoFee:               noFee,
 		EnableManifest:      enableManifest,
@@ -131,6 +149,8 @@ func New(
 		expectedRange:       schema.DefaultExpectedRange,
 		customTags:          customTags,
 	}
+	a.initPaymentProviders(enableArPayment)
+	a.updateBundleTags()
 
 	// init cache
 	peerMap, err := KVDb.LoadPeers()
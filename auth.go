@@ -0,0 +1,231 @@
+package arseeding
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar/utils"
+	"github.com/everFinance/goether"
+	"strconv"
+	"time"
+)
+
+// normalizeAuthAddress check the address format of the wallet signature type
+func normalizeAuthAddress(address, sigType string) (string, error) {
+	switch sigType {
+	case schema.SigTypeEthereum:
+		if !common.IsHexAddress(address) {
+			return "", fmt.Errorf("invalid ethereum address: %s", address)
+		}
+		return common.HexToAddress(address).String(), nil
+	case schema.SigTypeArweave:
+		if by, err := utils.Base64Decode(address); err != nil || len(by) != 32 {
+			return "", fmt.Errorf("invalid arweave address: %s", address)
+		}
+		return address, nil
+	case schema.SigTypeSolana:
+		if len(base58.Decode(address)) != ed25519.PublicKeySize {
+			return "", fmt.Errorf("invalid solana address: %s", address)
+		}
+		return address, nil
+	default:
+		return "", fmt.Errorf("not support sigType: %s", sigType)
+	}
+}
+
+// authTypedData is the EIP-712 login message, the bundler address separates the arseeding instances
+func authTypedData(address, bundler, nonce string, expiredTime int64) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+			},
+			"Login": {
+				{Name: "address", Type: "address"},
+				{Name: "bundler", Type: "string"},
+				{Name: "nonce", Type: "string"},
+				{Name: "expiredTime", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Login",
+		Domain: apitypes.TypedDataDomain{
+			Name:    schema.AuthDomainName,
+			Version: schema.AuthDomainVersion,
+		},
+		Message: apitypes.TypedDataMessage{
+			"address":     address,
+			"bundler":     bundler,
+			"nonce":       nonce,
+			"expiredTime": strconv.FormatInt(expiredTime, 10),
+		},
+	}
+}
+
+func (s *Arseeding) CreateAuthNonce(address, sigType string) (*schema.RespAuthNonce, error) {
+	address, err := normalizeAuthAddress(address, sigType)
+	if err != nil {
+		return nil, err
+	}
+	nonceBy := make([]byte, 16)
+	if _, err = rand.Read(nonceBy); err != nil {
+		return nil, err
+	}
+	nonce := schema.AuthNonce{
+		Nonce:       hex.EncodeToString(nonceBy),
+		Address:     address,
+		SigType:     sigType,
+		ExpiredTime: time.Now().Unix() + schema.AuthNonceExpiredRange,
+	}
+	if err = s.wdb.InsertAuthNonce(nonce); err != nil {
+		return nil, err
+	}
+
+	resp := &schema.RespAuthNonce{
+		Address:     nonce.Address,
+		SigType:     nonce.SigType,
+		Nonce:       nonce.Nonce,
+		ExpiredTime: nonce.ExpiredTime,
+	}
+	bundler := s.bundler.Signer.Address
+	if sigType == schema.SigTypeEthereum {
+		typedData := authTypedData(address, bundler, nonce.Nonce, nonce.ExpiredTime)
+		resp.TypedData = &typedData
+	} else {
+		resp.Message = schema.AuthMessage(address, bundler, nonce.Nonce, nonce.ExpiredTime)
+	}
+	return resp, nil
+}
+
+// Login verify the signature of the nonce challenge and create a session
+func (s *Arseeding) Login(req schema.ReqAuthLogin) (*schema.RespAuthSession, error) {
+	address, err := normalizeAuthAddress(req.Address, req.SigType)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := s.wdb.GetAuthNonce(req.Nonce)
+	if err != nil {
+		return nil, errors.New("nonce not exist")
+	}
+	if nonce.Address != address || nonce.SigType != req.SigType {
+		return nil, errors.New("nonce not match the address")
+	}
+	if nonce.Used {
+		return nil, errors.New("nonce has been used")
+	}
+	if nonce.ExpiredTime < time.Now().Unix() {
+		return nil, errors.New("nonce expired")
+	}
+
+	bundler := s.bundler.Signer.Address
+	switch req.SigType {
+	case schema.SigTypeEthereum:
+		err = verifyEthereumAuth(authTypedData(address, bundler, nonce.Nonce, nonce.ExpiredTime), address, req.Signature)
+	case schema.SigTypeArweave:
+		err = verifyArweaveAuth([]byte(schema.AuthMessage(address, bundler, nonce.Nonce, nonce.ExpiredTime)), address, req.PublicKey, req.Signature)
+	case schema.SigTypeSolana:
+		err = verifySolanaAuth([]byte(schema.AuthMessage(address, bundler, nonce.Nonce, nonce.ExpiredTime)), address, req.Signature)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.wdb.UseAuthNonce(nonce.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("nonce has been used")
+	}
+
+	tokenBy := make([]byte, 32)
+	if _, err = rand.Read(tokenBy); err != nil {
+		return nil, err
+	}
+	session := schema.AuthSession{
+		Token:       utils.Base64Encode(tokenBy),
+		Address:     address,
+		SigType:     req.SigType,
+		ExpiredTime: time.Now().Unix() + schema.AuthSessionExpiredRange,
+	}
+	if err = s.wdb.InsertAuthSession(session); err != nil {
+		return nil, err
+	}
+	return &schema.RespAuthSession{
+		Token:       session.Token,
+		Address:     session.Address,
+		SigType:     session.SigType,
+		ExpiredTime: session.ExpiredTime,
+	}, nil
+}
+
+func (s *Arseeding) CheckAuthSession(token string) (schema.AuthSession, error) {
+	session, err := s.wdb.GetAuthSession(token)
+	if err != nil {
+		return session, errors.New("session not exist")
+	}
+	if session.ExpiredTime < time.Now().Unix() {
+		return session, errors.New("session expired")
+	}
+	return session, nil
+}
+
+func verifyEthereumAuth(typedData apitypes.TypedData, address, signature string) error {
+	hash, err := goether.EIP712Hash(typedData)
+	if err != nil {
+		return err
+	}
+	_, addr, err := goether.Ecrecover(hash, common.FromHex(signature))
+	if err != nil {
+		return err
+	}
+	if addr.String() != address {
+		return errors.New("signature not match the address")
+	}
+	return nil
+}
+
+// verifyArweaveAuth arweave wallets may sign the message or the sha256 hash of the message
+func verifyArweaveAuth(msg []byte, address, owner, signature string) error {
+	ownerAddr, err := utils.OwnerToAddress(owner)
+	if err != nil {
+		return err
+	}
+	if ownerAddr != address {
+		return errors.New("publicKey not match the address")
+	}
+	pubKey, err := utils.OwnerToPubKey(owner)
+	if err != nil {
+		return err
+	}
+	sig, err := utils.Base64Decode(signature)
+	if err != nil {
+		return err
+	}
+	if utils.Verify(msg, pubKey, sig) == nil {
+		return nil
+	}
+	hash := sha256.Sum256(msg)
+	if utils.Verify(hash[:], pubKey, sig) == nil {
+		return nil
+	}
+	return errors.New("signature not match the address")
+}
+
+func verifySolanaAuth(msg []byte, address, signature string) error {
+	pubKey := base58.Decode(address)
+	if len(pubKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid solana address: %s", address)
+	}
+	if !ed25519.Verify(pubKey, msg, base58.Decode(signature)) {
+		return errors.New("signature not match the address")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package arseeding
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar"
+	"github.com/everFinance/goar/utils"
+	"github.com/everFinance/goether"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestWalletLogin(t *testing.T) {
+	sqliteDir := "./data/auth"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	prvKey, err := utils.GenerateRsaKey(2048)
+	assert.NoError(t, err)
+	arSigner := goar.NewSignerByPrivateKey(prvKey)
+	aa := &Arseeding{wdb: wdb, bundler: &goar.Wallet{Signer: arSigner}}
+
+	// ethereum EIP-712
+	ethSigner, err := goether.NewSigner("2b8258cde747e3820e56a40aec5cd473150c6078819b45afe61baaf1fa1c75e6") // for test
+	assert.NoError(t, err)
+	nonce, err := aa.CreateAuthNonce(ethSigner.Address.Hex(), schema.SigTypeEthereum)
+	assert.NoError(t, err)
+	sig, err := ethSigner.SignTypedData(*nonce.TypedData)
+	assert.NoError(t, err)
+	req := schema.ReqAuthLogin{Address: ethSigner.Address.Hex(), SigType: schema.SigTypeEthereum, Nonce: nonce.Nonce, Signature: common.Bytes2Hex(sig)}
+	session, err := aa.Login(req)
+	assert.NoError(t, err)
+	s, err := aa.CheckAuthSession(session.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, ethSigner.Address.String(), s.Address)
+	// replay is rejected
+	_, err = aa.Login(req)
+	assert.Error(t, err)
+
+	// the nonce can only be used by the address
+	nonce, err = aa.CreateAuthNonce(ethSigner.Address.Hex(), schema.SigTypeEthereum)
+	assert.NoError(t, err)
+	_, err = aa.Login(schema.ReqAuthLogin{Address: "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", SigType: schema.SigTypeEthereum, Nonce: nonce.Nonce, Signature: common.Bytes2Hex(sig)})
+	assert.Error(t, err)
+
+	// arweave
+	nonce, err = aa.CreateAuthNonce(arSigner.Address, schema.SigTypeArweave)
+	assert.NoError(t, err)
+	sig, err = utils.Sign([]byte(nonce.Message), prvKey)
+	assert.NoError(t, err)
+	session, err = aa.Login(schema.ReqAuthLogin{Address: arSigner.Address, SigType: schema.SigTypeArweave, Nonce: nonce.Nonce, PublicKey: arSigner.Owner(), Signature: utils.Base64Encode(sig)})
+	assert.NoError(t, err)
+	assert.Equal(t, arSigner.Address, session.Address)
+
+	// solana
+	pub, prv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	solAddr := base58.Encode(pub)
+	nonce, err = aa.CreateAuthNonce(solAddr, schema.SigTypeSolana)
+	assert.NoError(t, err)
+	session, err = aa.Login(schema.ReqAuthLogin{Address: solAddr, SigType: schema.SigTypeSolana, Nonce: nonce.Nonce, Signature: base58.Encode(ed25519.Sign(prv, []byte(nonce.Message)))})
+	assert.NoError(t, err)
+	assert.Equal(t, solAddr, session.Address)
+
+	_, err = aa.CheckAuthSession("not-exist")
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package arseeding
 
 import (
+	"errors"
 	"fmt"
 	"github.com/everFinance/arseeding/schema"
 	"github.com/everFinance/go-everpay/account"
@@ -13,10 +14,17 @@ import (
 	"time"
 )
 
-func (s *Arseeding) ProcessSubmitItem(item types.BundleItem, currency string, isNoFeeMode bool, apiKey string, isSort bool, size int64) (schema.Order, error) {
+// ProcessSubmitItem fee is the fee charged from apikey balance, the fee is calculated by quoteId if it is nil
+func (s *Arseeding) ProcessSubmitItem(item types.BundleItem, currency string, isNoFeeMode bool, apiKey string, isSort bool, lane string, size int64, quoteId string, fee *schema.RespFee) (schema.Order, error) {
 	if err := utils.VerifyBundleItem(item); err != nil {
 		return schema.Order{}, err
 	}
+	if isSort && lane == schema.LaneExpress {
+		return schema.Order{}, errors.New("express items can not be uploaded by sequence")
+	}
+	if !isNoFeeMode && strings.EqualFold(currency, schema.UsdCurrency) {
+		return schema.Order{}, errors.New("USD can only be paid by apikey balance")
+	}
 	if item.DataReader != nil { // reset io stream to origin of the file
 		if _, err := item.DataReader.Seek(0, 0); err != nil {
 			return schema.Order{}, err
@@ -44,11 +52,20 @@ func (s *Arseeding) ProcessSubmitItem(item types.BundleItem, currency string, is
 		OnChainStatus: schema.WaitOnChain,
 		ApiKey:        apiKey,
 		Sort:          isSort,
+		Lane:          lane,
 	}
-	// calc fee
-	respFee, err := s.CalcItemFee(currency, order.Size)
-	if err != nil {
-		return schema.Order{}, err
+	// calc fee, the pricing plan owner is the apikey owner or the item signer
+	owner := accId
+	if len(apiKey) > 0 {
+		if detail, _, err := s.checkApiKey(apiKey); err == nil {
+			owner = detail.Address
+		}
+	}
+	respFee := fee
+	if respFee == nil {
+		if respFee, err = s.calcOrderFee(currency, order.Size, owner, quoteId, item.Id, lane); err != nil {
+			return schema.Order{}, err
+		}
 	}
 	order.Decimals = respFee.Decimals
 	order.Fee = respFee.FinalFee
@@ -57,23 +74,169 @@ func (s *Arseeding) ProcessSubmitItem(item types.BundleItem, currency string, is
 	if isNoFeeMode {
 		order.PaymentStatus = schema.SuccPayment
 	} else {
-		order.PaymentExpiredTime = time.Now().Unix() + s.paymentExpiredRange
+		order.PaymentExpiredTime = time.Now().Unix() + s.paymentExpiration(currency)
 		order.PaymentStatus = schema.UnPayment
 	}
 
 	// insert to mysql
 	if err = s.wdb.InsertOrder(order); err != nil {
+		// the quote used by apikey debit is released by caller
+		if fee == nil {
+			s.releaseFeeQuote(quoteId, item.Id)
+		}
 		return schema.Order{}, err
 	}
+	// the unpaid order is counted when it is paid
+	if order.PaymentStatus == schema.SuccPayment {
+		s.addMonthlyUsage(owner, order.Size)
+	}
 	return order, nil
 }
 
+func (s *Arseeding) submitItemOrder(item types.BundleItem, currency, apikey string, needSort bool, lane string, size int64, quoteId string) (*schema.RespOrder, error) {
+	// check whether noFee mode
+	noFee := s.NoFee
+	// if has apikey
+	var debit *schema.LedgerEntry
+	var fee *schema.RespFee
+	if len(apikey) > 0 {
+		var err error
+		if debit, fee, err = s.processApikeySpendBal(currency, apikey, item.Id, size, quoteId, lane); err != nil {
+			return nil, err
+		}
+		// currency has balance
+		noFee = true
+	}
+
+	// process bundleItem
+	ord, err := s.ProcessSubmitItem(item, currency, noFee, apikey, needSort, lane, size, quoteId, fee)
+	if err != nil {
+		s.refundApikeySpend(debit)
+		if fee != nil {
+			s.releaseFeeQuote(quoteId, item.Id)
+		}
+		return nil, err
+	}
+	// the order is accepted and charged, the receipt is signed again when it is queried
+	receipt, err := s.SignUploadReceipt(ord)
+	if err != nil {
+		log.Error("s.SignUploadReceipt(ord)", "err", err, "itemId", ord.ItemId)
+	}
+	return &schema.RespOrder{
+		ItemId:             ord.ItemId,
+		Size:               ord.Size,
+		Bundler:            s.bundler.Signer.Address,
+		Currency:           ord.Currency,
+		Decimals:           ord.Decimals,
+		Fee:                ord.Fee,
+		PaymentExpiredTime: ord.PaymentExpiredTime,
+		ExpectedBlock:      ord.ExpectedBlock,
+		Lane:               ord.Lane,
+		Receipt:            receipt,
+	}, nil
+}
+
+func (s *Arseeding) submitNativeItem(item types.BundleItem, currency, apiKey string, needSort bool, lane string, size int64, quoteId string) (*schema.RespItemId, error) {
+	order, _, err := s.acceptNativeItem(item, currency, apiKey, needSort, lane, size, quoteId)
+	if err != nil {
+		return nil, err
+	}
+	return &schema.RespItemId{ItemId: order.ItemId, Size: order.Size}, nil
+}
+
+// acceptNativeItem return the order and the apikey debit of the item, they are used to roll back the item
+func (s *Arseeding) acceptNativeItem(item types.BundleItem, currency, apiKey string, needSort bool, lane string, size int64, quoteId string) (schema.Order, *schema.LedgerEntry, error) {
+	// cal apikey balance
+	debit, fee, err := s.processApikeySpendBal(currency, apiKey, item.Id, size, quoteId, lane)
+	if err != nil {
+		return schema.Order{}, nil, err
+	}
+
+	// process submit item
+	order, err := s.ProcessSubmitItem(item, currency, true, apiKey, needSort, lane, size, quoteId, fee)
+	if err != nil {
+		s.refundApikeySpend(debit)
+		s.releaseFeeQuote(quoteId, item.Id)
+		return schema.Order{}, nil, err
+	}
+	return order, debit, nil
+}
+
+// rollbackNativeItem delete the accepted item and refund the apikey debit
+func (s *Arseeding) rollbackNativeItem(order schema.Order, debit *schema.LedgerEntry) {
+	if err := s.wdb.DelOrder(order.ID); err != nil {
+		log.Error("s.wdb.DelOrder(order.ID)", "err", err, "itemId", order.ItemId)
+	}
+	if !s.wdb.ExistPaidOrd(order.ItemId) {
+		if err := s.delOrderItem(order.ItemId); err != nil {
+			log.Error("s.delOrderItem(order.ItemId)", "err", err, "itemId", order.ItemId)
+		}
+	}
+	s.refundApikeySpend(debit)
+	if debit != nil {
+		s.addMonthlyUsage(debit.Address, -order.Size)
+	}
+}
+
+// checkApikeyFee the apikey balance and the sub-key spend cap must cover the fee of all the items.
+// the items are debited in a db transaction which is rolled back, so the check is the same as processApikeySpendBal
+func (s *Arseeding) checkApikeyFee(apiKey, currency, lane, quoteId string, sizes []int64) error {
+	detail, subKey, err := s.checkApiKey(apiKey)
+	if err != nil {
+		return err
+	}
+	if subKey != nil {
+		if err = checkSubKeyCurrency(*subKey, currency); err != nil {
+			return err
+		}
+	}
+	dbTx := s.wdb.Db.Begin()
+	defer dbTx.Rollback()
+	total := decimal.Zero
+	for _, size := range sizes {
+		fee, err := s.calcOrderFee(currency, size, detail.Address, quoteId, "", lane)
+		if err != nil {
+			return err
+		}
+		feeDe, err := decimal.NewFromString(fee.FinalFee)
+		if err != nil {
+			return err
+		}
+		total = total.Add(feeDe)
+		if subKey != nil {
+			_, err = updateSubKeySpent(s.wdb, subKey.SubKey, currency, feeDe, "", schema.LedgerDebit, fee, dbTx)
+		} else {
+			_, err = postLedgerEntry(s.wdb, detail.Address, currency, schema.LedgerDebit, feeDe, "", "", fee, dbTx)
+		}
+		if err != nil {
+			return fmt.Errorf("the fee of all the items is %s: %s", total.String(), err.Error())
+		}
+	}
+	return nil
+}
+
+// refundApikeySpend refund the apikey balance if the item is not accepted
+func (s *Arseeding) refundApikeySpend(debit *schema.LedgerEntry) {
+	if debit == nil {
+		return
+	}
+	if err := refundLedgerEntry(s.wdb, debit); err != nil {
+		log.Error("refundLedgerEntry(s.wdb, debit)", "err", err, "address", debit.Address, "itemId", debit.Reference)
+	}
+}
+
 func (s *Arseeding) CalcItemFee(currency string, itemSize int64) (*schema.RespFee, error) {
 	perFee := s.GetPerFee(currency)
 	if perFee == nil {
 		return nil, fmt.Errorf("not support currency: %s", currency)
 	}
+	if perFee.Stale {
+		return nil, fmt.Errorf("the price of currency is stale: %s", currency)
+	}
+	return calcFeeByPerFee(*perFee, itemSize), nil
+}
 
+func calcFeeByPerFee(perFee schema.Fee, itemSize int64) *schema.RespFee {
 	count := int64(0)
 	if itemSize > 0 {
 		count = (itemSize-1)/types.MAX_CHUNK_SIZE + 1
@@ -86,7 +249,18 @@ func (s *Arseeding) CalcItemFee(currency string, itemSize int64) (*schema.RespFe
 		Currency: perFee.Currency,
 		Decimals: perFee.Decimals,
 		FinalFee: finalFee.String(),
-	}, nil
+		UsdFee:   calcUsdFee(finalFee, perFee.Decimals, perFee.UsdPrice),
+		UsdPrice: perFee.UsdPrice,
+		ArPrice:  perFee.ArPrice,
+	}
+}
+
+// calcUsdFee convert the fee to USD, return empty if the price is unknown
+func calcUsdFee(fee decimal.Decimal, decimals int, usdPrice float64) string {
+	if usdPrice <= 0.0 {
+		return ""
+	}
+	return fee.Shift(int32(-decimals)).Mul(decimal.NewFromFloat(usdPrice)).Round(schema.UsdDecimals).String()
 }
 
 func (s *Arseeding) GetBundlePerFees() (map[string]schema.Fee, error) {
@@ -101,6 +275,14 @@ func (s *Arseeding) GetBundlePerFees() (map[string]schema.Fee, error) {
 	arFee := s.cache.GetFee()
 	arFee.Base = arFee.Base + s.config.GetServeFee()         // add base arseeding service fee
 	arFee.PerChunk = arFee.PerChunk + s.config.GetServeFee() // add base arseeding service fee
+	// the fee of all currencies depends on ar price
+	ttl := s.priceTTL()
+	arStale := true
+	for _, tp := range tps {
+		if strings.ToUpper(tp.Symbol) == "AR" {
+			arStale = isPriceStale(tp, ttl)
+		}
+	}
 	res := make(map[string]schema.Fee)
 	for _, tp := range tps {
 		if tp.Price <= 0.0 {
@@ -119,6 +301,24 @@ func (s *Arseeding) GetBundlePerFees() (map[string]schema.Fee, error) {
 			Decimals: tp.Decimals,
 			Base:     baseFee,
 			PerChunk: perChunkFee,
+			Stale:    arStale || isPriceStale(tp, ttl),
+			UsdPrice: tp.Price,
+			ArPrice:  arPrice,
+		}
+	}
+	// USD credit balance is charged at the prevailing AR price
+	if _, ok := res[schema.UsdCurrency]; !ok && arPrice > 0.0 {
+		usdDecimals := decimal.New(1, schema.UsdDecimals)
+		res[schema.UsdCurrency] = schema.Fee{
+			Currency: schema.UsdCurrency,
+			Decimals: schema.UsdDecimals,
+			Base: usdDecimals.Mul(decimal.NewFromFloat(arPrice)).Mul(decimal.NewFromInt(arFee.Base)).
+				Div(decimal.NewFromFloat(math.Pow10(12))).Round(0),
+			PerChunk: usdDecimals.Mul(decimal.NewFromFloat(arPrice)).Mul(decimal.NewFromInt(arFee.PerChunk)).
+				Div(decimal.NewFromFloat(math.Pow10(12))).Round(0),
+			Stale:    arStale,
+			UsdPrice: 1,
+			ArPrice:  arPrice,
 		}
 	}
 	return res, nil
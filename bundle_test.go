@@ -1,6 +1,7 @@
 package arseeding
 
 import (
+	"github.com/everFinance/arseeding/config"
 	"github.com/everFinance/arseeding/schema"
 	"github.com/everFinance/goar"
 	"github.com/everFinance/goar/types"
@@ -43,6 +44,46 @@ func TestBundleCalcItemFee(t *testing.T) {
 	assert.Equal(t, "5", res.FinalFee)
 }
 
+func TestCheckApikeyFee(t *testing.T) {
+	sqliteDir := "./data/apikey-fee"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	aa := &Arseeding{
+		wdb:    wdb,
+		config: &config.Config{},
+		bundlePerFeeMap: map[string]schema.Fee{
+			"AR": {Currency: "AR", Base: decimal.New(5, 0), PerChunk: decimal.New(1, 0)},
+		},
+	}
+	addr := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	assert.NoError(t, wdb.InsertApiKey(schema.AutoApiKey{ApiKey: "apikey-test", Address: addr, TokenBalance: map[string]interface{}{"AR": "12"}}))
+	assert.NoError(t, wdb.InsertApiSubKey(schema.ApiSubKey{SubKey: "sub-key-test", Address: addr, Name: "ci", SpendCap: map[string]interface{}{"AR": "6"}, Spent: map[string]interface{}{}}))
+
+	// the fee of every item is 6
+	assert.NoError(t, aa.checkApikeyFee("apikey-test", "ar", schema.LaneStandard, "", []int64{1, 1}))
+	assert.Error(t, aa.checkApikeyFee("apikey-test", "ar", schema.LaneStandard, "", []int64{1, 1, 1}))
+	assert.NoError(t, aa.checkApikeyFee("sub-key-test", "ar", schema.LaneStandard, "", []int64{1}))
+	assert.Error(t, aa.checkApikeyFee("sub-key-test", "ar", schema.LaneStandard, "", []int64{1, 1}))
+	// the check is not debited
+	detail, err := wdb.GetApiKeyDetailByAddress(addr)
+	assert.NoError(t, err)
+	assert.Equal(t, "12", detail.TokenBalance["AR"])
+
+	// the quoted fee is checked after the price rising
+	quote, err := aa.CreateFeeQuote("ar", 1, addr)
+	assert.NoError(t, err)
+	aa.SetPerFee(map[string]schema.Fee{
+		"AR": {Currency: "AR", Base: decimal.New(13, 0), PerChunk: decimal.New(1, 0)},
+	})
+	assert.Error(t, aa.checkApikeyFee("apikey-test", "ar", schema.LaneStandard, "", []int64{1}))
+	assert.NoError(t, aa.checkApikeyFee("apikey-test", "ar", schema.LaneStandard, quote.QuoteId, []int64{1}))
+	// the quote is not used by the check
+	_, err = aa.calcOrderFee("AR", 1, addr, quote.QuoteId, "item-1", schema.LaneStandard)
+	assert.NoError(t, err)
+	assert.Error(t, aa.checkApikeyFee("apikey-test", "ar", schema.LaneStandard, quote.QuoteId, []int64{1}))
+}
+
 func TestSaveDelItem(t *testing.T) {
 	dbPath := "./data/tmp.db"
 	signer, err := goar.NewSignerFromPath("./test-keyfile.json") // your key file path
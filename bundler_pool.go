@@ -0,0 +1,104 @@
+package arseeding
+
+import (
+	"errors"
+	"github.com/everFinance/goar"
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"math/big"
+	"sync"
+)
+
+// bundlerWallet the balance is nil until it is fetched, inflight is the number of bundles being sent
+type bundlerWallet struct {
+	wallet   *goar.Wallet
+	balance  *big.Int // unit winston
+	inflight int
+}
+
+// bundlerPool the wallets sign the bundle arTxs, the first is the bundler which receives payments
+type bundlerPool struct {
+	lock    sync.Mutex
+	wallets []*bundlerWallet
+}
+
+func newBundlerPool(wallets ...*goar.Wallet) *bundlerPool {
+	p := &bundlerPool{wallets: make([]*bundlerWallet, 0, len(wallets))}
+	visited := make(map[string]bool)
+	for _, w := range wallets {
+		if visited[w.Signer.Address] {
+			continue
+		}
+		visited[w.Signer.Address] = true
+		p.wallets = append(p.wallets, &bundlerWallet{wallet: w})
+	}
+	return p
+}
+
+func (p *bundlerPool) getWallets() []*goar.Wallet {
+	res := make([]*goar.Wallet, 0, len(p.wallets))
+	for _, bw := range p.wallets {
+		res = append(res, bw.wallet)
+	}
+	return res
+}
+
+func (p *bundlerPool) setBalance(addr string, balance *big.Int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, bw := range p.wallets {
+		if bw.wallet.Signer.Address == addr {
+			bw.balance = balance
+		}
+	}
+}
+
+// acquire select the wallet which has enough balance for reward and the least pending txs,
+// pendings is the pending arTx number of wallets on chain. The wallet must be released after the bundle sent
+func (p *bundlerPool) acquire(reward *big.Int, pendings map[string]int) (*goar.Wallet, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var selected *bundlerWallet
+	selectedPending := 0
+	for _, bw := range p.wallets {
+		if bw.balance != nil && bw.balance.Cmp(reward) < 0 {
+			continue
+		}
+		pending := pendings[bw.wallet.Signer.Address] + bw.inflight
+		if selected == nil || pending < selectedPending {
+			selected = bw
+			selectedPending = pending
+		}
+	}
+	if selected == nil {
+		return nil, errors.New("no bundler wallet has enough AR balance")
+	}
+	selected.inflight++
+	return selected.wallet, nil
+}
+
+// release the spent reward is deducted from the balance until the balance is fetched again
+func (p *bundlerPool) release(addr string, spent *big.Int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, bw := range p.wallets {
+		if bw.wallet.Signer.Address != addr {
+			continue
+		}
+		if bw.inflight > 0 {
+			bw.inflight--
+		}
+		if bw.balance != nil && spent != nil {
+			bw.balance = new(big.Int).Sub(bw.balance, spent)
+		}
+	}
+}
+
+// arTxBundler return the address of wallet which signed the arTx
+func arTxBundler(arTx types.Transaction) string {
+	addr, err := utils.OwnerToAddress(arTx.Owner)
+	if err != nil {
+		return ""
+	}
+	return addr
+}
@@ -0,0 +1,65 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar"
+	"github.com/everFinance/goar/utils"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"os"
+	"testing"
+)
+
+func TestBundlerPool(t *testing.T) {
+	wallets := make([]*goar.Wallet, 0, 3)
+	for i := 0; i < 3; i++ {
+		prvKey, err := utils.GenerateRsaKey(2048)
+		assert.NoError(t, err)
+		wallets = append(wallets, &goar.Wallet{Signer: goar.NewSignerByPrivateKey(prvKey)})
+	}
+	addr0, addr1, addr2 := wallets[0].Signer.Address, wallets[1].Signer.Address, wallets[2].Signer.Address
+	p := newBundlerPool(wallets[0], wallets[1], wallets[2], wallets[0])
+	assert.Equal(t, 3, len(p.getWallets()))
+
+	// the balance unknown is not skipped, the first wallet is selected if pendings are equal
+	w, err := p.acquire(big.NewInt(100), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, addr0, w.Signer.Address)
+	// the inflight bundle is counted as pending
+	w, err = p.acquire(big.NewInt(100), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, addr1, w.Signer.Address)
+	p.release(addr0, nil)
+	p.release(addr1, nil)
+
+	// the wallet without enough balance is skipped
+	p.setBalance(addr0, big.NewInt(50))
+	p.setBalance(addr1, big.NewInt(1000))
+	p.setBalance(addr2, big.NewInt(1000))
+	w, err = p.acquire(big.NewInt(100), map[string]int{addr1: 2, addr2: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, addr2, w.Signer.Address)
+	p.release(addr2, big.NewInt(950))
+	w, err = p.acquire(big.NewInt(100), map[string]int{addr1: 2, addr2: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, addr1, w.Signer.Address)
+	p.release(addr1, big.NewInt(950))
+
+	_, err = p.acquire(big.NewInt(100), nil)
+	assert.Error(t, err)
+}
+
+func TestGetPendingArTxNums(t *testing.T) {
+	sqliteDir := "./data/bundlerPool"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+
+	assert.NoError(t, wdb.InsertArTx(schema.OnChainTx{ArId: "tx-1", Status: schema.PendingOnChain, Bundler: "bundler-1"}))
+	assert.NoError(t, wdb.InsertArTx(schema.OnChainTx{ArId: "tx-2", Status: schema.PendingOnChain, Bundler: "bundler-1"}))
+	assert.NoError(t, wdb.InsertArTx(schema.OnChainTx{ArId: "tx-3", Status: schema.PendingOnChain, Bundler: "bundler-2"}))
+	assert.NoError(t, wdb.InsertArTx(schema.OnChainTx{ArId: "tx-4", Status: schema.SuccOnChain, Bundler: "bundler-2"}))
+	nums, err := wdb.GetPendingArTxNums()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"bundler-1": 2, "bundler-2": 1}, nums)
+}
@@ -0,0 +1,120 @@
+package arseeding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar/types"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var bundleTagsPresets = map[string][]types.Tag{
+	schema.BundleTagsPresetArseedingU: {
+		{Name: "App-Name", Value: "arseeding"},
+		{Name: "App-Version", Value: "1.0.0"},
+		{Name: "Action", Value: "Bundle"},
+		{Name: "Protocol-Name", Value: "U"},
+		{Name: "Action", Value: "Burn"},
+		{Name: "App-Name", Value: "SmartWeaveAction"},
+		{Name: "App-Version", Value: "0.3.0"},
+		{Name: "Input", Value: `{"function":"mint"}`},
+		{Name: "Contract", Value: "KTzTXT_ANmF84fWEKHzWURD1LWd9QaFR9yfYUwH2Lxw"},
+	},
+	schema.BundleTagsPresetMinimal: {
+		{Name: "App-Name", Value: "arseeding"},
+		{Name: "App-Version", Value: "1.0.0"},
+		{Name: "Action", Value: "Bundle"},
+	},
+}
+
+// bundleTagsTemplates the last valid template of config, the invalid config is logged once and ignored
+type bundleTagsTemplates struct {
+	lock    sync.Mutex
+	conf    string // preset and template of config which the tags are loaded from
+	invalid string // the last invalid conf
+	preset  string
+	tags    []types.Tag
+}
+
+// loadBundleTagsTemplate the template of config overrides the preset
+func loadBundleTagsTemplate(preset string, template []byte) (string, []types.Tag, error) {
+	if len(template) > 0 {
+		tags := make([]types.Tag, 0)
+		if err := json.Unmarshal(template, &tags); err != nil {
+			return "", nil, fmt.Errorf("bundle tags template incorrect: %v", err)
+		}
+		for _, tag := range tags {
+			if tag.Name == "" {
+				return "", nil, errors.New("bundle tags template incorrect: tag name can not be empty")
+			}
+		}
+		return schema.BundleTagsCustom, tags, nil
+	}
+	preset = strings.ToLower(preset)
+	if preset == "" {
+		preset = schema.BundleTagsPresetArseedingU
+	}
+	tags, ok := bundleTagsPresets[preset]
+	if !ok {
+		return "", nil, fmt.Errorf("bundle tags preset not found: %s", preset)
+	}
+	return preset, tags, nil
+}
+
+// bundleTagsTemplate return the active preset name and template tags.
+// If the config is invalid, the last valid template is kept, or the default preset if there is none
+func (s *Arseeding) bundleTagsTemplate() (string, []types.Tag) {
+	param := s.config.Param
+	conf := param.BundleTagsPreset + "|" + string(param.BundleTagsTemplate)
+	t := &s.bundleTagsTemplates
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.tags != nil && conf == t.conf {
+		return t.preset, t.tags
+	}
+	preset, tags, err := loadBundleTagsTemplate(param.BundleTagsPreset, param.BundleTagsTemplate)
+	if err != nil {
+		if conf != t.invalid {
+			t.invalid = conf
+			log.Error("loadBundleTagsTemplate(param.BundleTagsPreset, param.BundleTagsTemplate)", "err", err, "lastPreset", t.preset)
+		}
+		if t.tags == nil {
+			t.preset, t.tags = schema.BundleTagsPresetArseedingU, bundleTagsPresets[schema.BundleTagsPresetArseedingU]
+		}
+		return t.preset, t.tags
+	}
+	t.conf, t.preset, t.tags = conf, preset, tags
+	return preset, tags
+}
+
+// bundleTagsPreset return the active preset name, "custom" if the template of config is used
+func (s *Arseeding) bundleTagsPreset() string {
+	preset, _ := s.bundleTagsTemplate()
+	return preset
+}
+
+// updateBundleTags validate the bundle tags of config once it is reloaded
+func (s *Arseeding) updateBundleTags() {
+	s.bundleTagsTemplate()
+}
+
+// bundleTags render the template tags of bundle, the custom tags of command line are put in front. bundler is the arTx signer
+func (s *Arseeding) bundleTags(itemCount int, totalSize int64, lane, bundler string) []types.Tag {
+	_, template := s.bundleTagsTemplate()
+	r := strings.NewReplacer(
+		schema.BundleTagItemCount, strconv.Itoa(itemCount),
+		schema.BundleTagTotalSize, strconv.FormatInt(totalSize, 10),
+		schema.BundleTagVersion, Version,
+		schema.BundleTagBundler, bundler,
+		schema.BundleTagLane, lane,
+	)
+	tags := make([]types.Tag, 0, len(s.customTags)+len(template))
+	tags = append(tags, s.customTags...)
+	for _, tag := range template {
+		tags = append(tags, types.Tag{Name: r.Replace(tag.Name), Value: r.Replace(tag.Value)})
+	}
+	return tags
+}
@@ -0,0 +1,61 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/config"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar"
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBundleTags(t *testing.T) {
+	rsaKey, err := utils.GenerateRsaKey(2048)
+	assert.NoError(t, err)
+	signer := goar.NewSignerByPrivateKey(rsaKey) // the pool wallet which signs the bundle
+	aa := &Arseeding{
+		config:     &config.Config{},
+		customTags: []types.Tag{{Name: "Operator", Value: "test"}},
+	}
+
+	// the current tags are the default preset
+	assert.Equal(t, schema.BundleTagsPresetArseedingU, aa.bundleTagsPreset())
+	tags := aa.bundleTags(2, 1024, schema.LaneStandard, signer.Address)
+	assert.Equal(t, 10, len(tags))
+	assert.Equal(t, types.Tag{Name: "Operator", Value: "test"}, tags[0])
+	assert.Equal(t, "KTzTXT_ANmF84fWEKHzWURD1LWd9QaFR9yfYUwH2Lxw", tags[9].Value)
+
+	aa.config.Param.BundleTagsPreset = schema.BundleTagsPresetMinimal
+	tags = aa.bundleTags(2, 1024, schema.LaneStandard, signer.Address)
+	assert.Equal(t, 4, len(tags))
+
+	// the unknown preset keeps the last valid tags
+	aa.config.Param.BundleTagsPreset = "unknown"
+	tags = aa.bundleTags(2, 1024, schema.LaneStandard, signer.Address)
+	assert.Equal(t, 4, len(tags))
+	assert.Equal(t, schema.BundleTagsPresetMinimal, aa.bundleTagsPreset())
+
+	// the template overrides the preset
+	aa.config.Param.BundleTagsTemplate = []byte(`[{"name":"App-Name","value":"arseeding-{version}"},{"name":"Bundle-Info","value":"{itemCount} items, {totalSize} bytes, {lane} lane by {bundler}"}]`)
+	assert.Equal(t, schema.BundleTagsCustom, aa.bundleTagsPreset())
+	tags = aa.bundleTags(2, 1024, schema.LaneExpress, signer.Address)
+	expected := []types.Tag{
+		{Name: "Operator", Value: "test"},
+		{Name: "App-Name", Value: "arseeding-" + Version},
+		{Name: "Bundle-Info", Value: "2 items, 1024 bytes, express lane by " + signer.Address},
+	}
+	assert.Equal(t, expected, tags)
+
+	// the invalid template keeps the last valid template
+	aa.config.Param.BundleTagsTemplate = []byte(`{"name":"App-Name"}`)
+	assert.Equal(t, expected, aa.bundleTags(2, 1024, schema.LaneExpress, signer.Address))
+	aa.config.Param.BundleTagsTemplate = []byte(`[{"value":"arseeding"}]`)
+	assert.Equal(t, expected, aa.bundleTags(2, 1024, schema.LaneExpress, signer.Address))
+
+	// the default preset is used if the config is invalid since start
+	bb := &Arseeding{config: &config.Config{}}
+	bb.config.Param.BundleTagsPreset = "unknown"
+	assert.Equal(t, schema.BundleTagsPresetArseedingU, bb.bundleTagsPreset())
+	assert.Equal(t, 9, len(bb.bundleTags(2, 1024, schema.LaneStandard, signer.Address)))
+}
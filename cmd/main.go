@@ -8,6 +8,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	_ "github.com/mkevac/debugcharts"
@@ -24,9 +25,11 @@ func main() {
 			&cli.StringFlag{Name: "sqlite_dir", Value: "./data/sqlite", Usage: "sqlite db dir path", EnvVars: []string{"SQLITE_DIR"}},
 
 			&cli.StringFlag{Name: "key_path", Value: "./data/bundler-keyfile.json", Usage: "ar keyfile path", EnvVars: []string{"KEY_PATH"}},
+			&cli.StringFlag{Name: "pool_key_paths", Value: "", Usage: "more ar keyfile paths separated by comma, the bundles are signed by them and key_path wallet", EnvVars: []string{"POOL_KEY_PATHS"}},
 			&cli.StringFlag{Name: "ar_node", Value: "https://arweave.net", EnvVars: []string{"AR_NODE"}},
 			&cli.StringFlag{Name: "pay", Value: "https://api-dev.everpay.io", Usage: "pay url", EnvVars: []string{"PAY"}},
 			&cli.BoolFlag{Name: "no_fee", Value: false, EnvVars: []string{"NO_FEE"}},
+			&cli.BoolFlag{Name: "ar_payment", Value: false, Usage: "accept native AR transfers as bundle fee payment", EnvVars: []string{"AR_PAYMENT"}},
 			&cli.BoolFlag{Name: "manifest", Value: true, EnvVars: []string{"MANIFEST"}},
 			&cli.IntFlag{Name: "bundle_interval", Value: 120, Usage: "bundle tx on chain time interval(seconds)", EnvVars: []string{"BUNDLE_INTERVAL"}},
 
@@ -81,6 +84,13 @@ func run(c *cli.Context) error {
 		})
 	}
 
+	poolKeyPaths := make([]string, 0)
+	for _, keyPath := range strings.Split(c.String("pool_key_paths"), ",") {
+		if keyPath = strings.TrimSpace(keyPath); keyPath != "" {
+			poolKeyPaths = append(poolKeyPaths, keyPath)
+		}
+	}
+
 	s := arseeding.New(
 		c.String("db_dir"), c.String("mysql"), c.String("sqlite_dir"), c.Bool("use_sqlite"),
 		c.String("key_path"), c.String("ar_node"), c.String("pay"), c.Bool("no_fee"), c.Bool("manifest"),
@@ -88,7 +98,8 @@ func run(c *cli.Context) error {
 		c.Bool("use_4ever"), c.Bool("use_aliyun"), c.String("aliyun_endpoint"), c.String("aliyun_acc_key"), c.String("aliyun_secret_key"), c.String("aliyun_prefix"),
 		c.Bool("use_mongodb"), c.String("mongodb_uri"),
 		c.String("port"), customTags,
-		c.Bool("use_kafka"), c.String("kafka_uri"))
+		c.Bool("use_kafka"), c.String("kafka_uri"),
+		c.Bool("ar_payment"), poolKeyPaths)
 	s.Run(c.String("port"), c.Int("bundle_interval"))
 
 	common.NewMetricServer()
@@ -4,6 +4,7 @@ import (
 	"github.com/everFinance/arseeding/config/schema"
 	"github.com/everFinance/go-everpay/account"
 	"github.com/go-co-op/gocron"
+	"strings"
 	"time"
 )
 
@@ -12,6 +13,9 @@ type Config struct {
 	speedTxFee     int64
 	bundleServeFee int64
 	ipWhiteList    map[string]struct{}
+	admins         map[string]struct{}           // key: lower case address
+	pricingPlans   map[string]schema.PricingPlan // key: lower case owner address
+	sweepPolicies  map[string]schema.SweepPolicy // key: provider + "-" + upper case symbol
 	scheduler      *gocron.Scheduler
 	Param          schema.Param
 }
@@ -40,6 +44,9 @@ func New(configDSN, sqliteDir string, useSqlite bool) *Config {
 		speedTxFee:     fee.SpeedTxFee,
 		bundleServeFee: fee.BundleServeFee,
 		ipWhiteList:    make(map[string]struct{}),
+		admins:         make(map[string]struct{}),
+		pricingPlans:   make(map[string]schema.PricingPlan),
+		sweepPolicies:  make(map[string]schema.SweepPolicy),
 		scheduler:      gocron.NewScheduler(time.UTC),
 		Param:          param,
 	}
@@ -57,6 +64,35 @@ func (c *Config) GetIPWhiteList() *map[string]struct{} {
 	return &c.ipWhiteList
 }
 
+func (c *Config) IsAdmin(address string) bool {
+	_, ok := c.admins[strings.ToLower(address)]
+	return ok
+}
+
+// GetPricingPlan return the plan assigned to the owner, nil means standard price
+func (c *Config) GetPricingPlan(owner string) *schema.PricingPlan {
+	plan, ok := c.pricingPlans[strings.ToLower(owner)]
+	if !ok {
+		return nil
+	}
+	return &plan
+}
+
+func sweepPolicyKey(provider, symbol string) string {
+	return provider + "-" + strings.ToUpper(symbol)
+}
+
+// GetSweepPolicy return the policy of the token, or the "*" policy of the provider; nil means no policy
+func (c *Config) GetSweepPolicy(provider, symbol string) *schema.SweepPolicy {
+	if policy, ok := c.sweepPolicies[sweepPolicyKey(provider, symbol)]; ok {
+		return &policy
+	}
+	if policy, ok := c.sweepPolicies[sweepPolicyKey(provider, "*")]; ok {
+		return &policy
+	}
+	return nil
+}
+
 func (c *Config) Run() {
 	go c.runJobs()
 }
@@ -1,8 +1,16 @@
 package config
 
+import (
+	"github.com/everFinance/arseeding/config/schema"
+	"strings"
+)
+
 func (c *Config) runJobs() {
 	c.scheduler.Every(1).Minute().SingletonMode().Do(c.updateFee)
 	c.scheduler.Every(1).Minute().SingletonMode().Do(c.updateIPWhiteList)
+	c.scheduler.Every(1).Minute().SingletonMode().Do(c.updateAdmins)
+	c.scheduler.Every(1).Minute().SingletonMode().Do(c.updatePricingPlans)
+	c.scheduler.Every(1).Minute().SingletonMode().Do(c.updateSweepPolicies)
 	c.scheduler.Every(10).Seconds().SingletonMode().Do(c.updateParam)
 
 	c.scheduler.StartAsync()
@@ -38,3 +46,49 @@ func (c *Config) updateParam() {
 	}
 	c.Param = param
 }
+
+func (c *Config) updateAdmins() {
+	admins, err := c.wdb.GetAvailableAdmins()
+	if err != nil {
+		return
+	}
+	adminMap := make(map[string]struct{}, len(admins))
+	for _, admin := range admins {
+		adminMap[strings.ToLower(admin.Address)] = struct{}{}
+	}
+	c.admins = adminMap
+}
+
+func (c *Config) updatePricingPlans() {
+	plans, err := c.wdb.GetAvailablePricingPlans()
+	if err != nil {
+		return
+	}
+	assignments, err := c.wdb.GetPricingPlanAssignments()
+	if err != nil {
+		return
+	}
+	planMap := make(map[string]schema.PricingPlan, len(plans))
+	for _, plan := range plans {
+		planMap[plan.Name] = plan
+	}
+	ownerPlans := make(map[string]schema.PricingPlan, len(assignments))
+	for _, assign := range assignments {
+		if plan, ok := planMap[assign.PlanName]; ok {
+			ownerPlans[strings.ToLower(assign.Owner)] = plan
+		}
+	}
+	c.pricingPlans = ownerPlans
+}
+
+func (c *Config) updateSweepPolicies() {
+	policies, err := c.wdb.GetAvailableSweepPolicies()
+	if err != nil {
+		return
+	}
+	policyMap := make(map[string]schema.SweepPolicy, len(policies))
+	for _, policy := range policies {
+		policyMap[sweepPolicyKey(policy.Provider, policy.Symbol)] = policy
+	}
+	c.sweepPolicies = policyMap
+}
@@ -1,5 +1,7 @@
 package schema
 
+import "gorm.io/datatypes"
+
 type FeeConfig struct {
 	SpeedTxFee        int64  `json:"speedTxFee"`
 	BundleServeFee    int64  `json:"bundleServeFee"`
@@ -12,6 +14,50 @@ type IpRateWhitelist struct {
 	Description string
 }
 
+// Admin the wallet addresses can use the admin api with login session
+type Admin struct {
+	Address     string `gorm:"index:idxAdmin0"`
+	Available   bool   // true means effective
+	Description string
+}
+
+const (
+	// how the unmatched part of item payment is settled
+	SettlementRefund = "refund" // refund the whole payment if any order can not be paid, default
+	SettlementCredit = "credit" // pay the orders can be covered and credit the rest to payer apikey balance
+)
+
 type Param struct {
 	ChunkConcurrentNum int
+	PaymentSettlement  string // "refund" or "credit", empty means "refund"
+
+	// token price oracle
+	PriceSources   string  // comma separated, e.g. "redstone,file:./prices.json,json:https://host/price/{symbol};data.usd", empty means "redstone"
+	PriceMaxChange float64 // percentage, the price move larger than it is rejected; 0 means default 20
+	PriceMinAgree  int     // the price move larger than max change is accepted if at least this number of sources agree on it; 0 means default 2
+	PriceTTL       int64   // unit s, the price is stale if not updated in ttl; 0 means default 1800
+
+	FeeQuoteExpiration int64 // unit s, the fee quote locked time; 0 means default 600
+
+	// unpaid order expiration, unit s
+	PaymentExpiredRange         int64             // 0 means default 30 days
+	CurrencyPaymentExpiredRange datatypes.JSONMap // currency symbol -> range, e.g. {"AR": 86400}, overrides PaymentExpiredRange
+
+	// bundle packing policy of the unsorted items
+	BundleMinSize  int64  // unit byte, the bundle waits for more items if smaller; 0 means no min size
+	BundleMaxSize  int64  // unit byte; 0 means default 2 GB, it can not be larger than 2 GB
+	BundleMaxItems int    // 0 means no limit
+	BundleMaxWait  int64  // unit s, the bundle smaller than min size is forced on chain if the oldest item waited longer; 0 means default 3600
+	BundleOrderBy  string // "age" or "fee", empty means "age"
+
+	// express lane
+	ExpressSurcharge   int64 // percentage of the standard fee added; 0 means default 50
+	ExpressSpeedFactor int64 // percentage of the arTx reward added for the express bundle; 0 means default 50
+
+	// bundle arTx tags
+	BundleTagsPreset   string         // "arseeding-u" or "minimal", empty means "arseeding-u"
+	BundleTagsTemplate datatypes.JSON // e.g. [{"name":"Item-Count","value":"{itemCount}"}], overrides BundleTagsPreset
+
+	// deprecated by the login session: the apikey by signed timestamp and the signer orders without session
+	DisableLegacyAuth bool // false means the deprecated access is kept with a warning log
 }
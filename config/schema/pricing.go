@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"encoding/json"
+	"gorm.io/datatypes"
+	"sort"
+)
+
+// PricingPlan adjust the standard bundle fee for the assigned apikeys and signers
+type PricingPlan struct {
+	ID          uint              `gorm:"primarykey" json:"id"`
+	Name        string            `gorm:"index:idxPricingPlan0,unique" json:"name"`
+	Discount    float64           `json:"discount"` // percentage, 10 means 10% off
+	Tiers       datatypes.JSON    `json:"tiers"`    // json.marshal([]PricingTier)
+	MinFee      datatypes.JSONMap `json:"minFee"`   // key: currency symbol, val: minimum fee of one item
+	Available   bool              `json:"available"`
+	Description string            `json:"description"`
+}
+
+// PricingTier volume discount, it replaces the plan discount when the monthly bytes reach MinMonthlyBytes
+type PricingTier struct {
+	MinMonthlyBytes int64   `json:"minMonthlyBytes"`
+	Discount        float64 `json:"discount"`
+}
+
+// PricingPlanAssignment assign plan to apikey address or item signer address
+type PricingPlanAssignment struct {
+	Owner    string `gorm:"index:idxPlanAssign0,unique"` // apikey owner address or item signer address
+	PlanName string
+}
+
+// TierDiscount return the discount for the monthly bytes
+func (p PricingPlan) TierDiscount(monthlyBytes int64) (float64, error) {
+	tiers := make([]PricingTier, 0)
+	if len(p.Tiers) > 0 {
+		if err := json.Unmarshal(p.Tiers, &tiers); err != nil {
+			return 0, err
+		}
+	}
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].MinMonthlyBytes > tiers[j].MinMonthlyBytes
+	})
+	for _, tier := range tiers {
+		if monthlyBytes >= tier.MinMonthlyBytes {
+			return tier.Discount, nil
+		}
+	}
+	return p.Discount, nil
+}
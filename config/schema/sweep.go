@@ -0,0 +1,14 @@
+package schema
+
+// SweepPolicy control how the collected fee of a token is swept, Symbol "*" matches all tokens of the provider
+type SweepPolicy struct {
+	ID          uint   `gorm:"primarykey" json:"id"`
+	Provider    string `gorm:"index:idxSweepPolicy0" json:"provider"` // "everpay" or "arweave"
+	Symbol      string `json:"symbol"`
+	MinReserve  string `json:"minReserve"`  // the balance kept besides the pending refunds, in the smallest unit
+	Threshold   string `json:"threshold"`   // sweep only if the sweepable amount reach it
+	Destination string `json:"destination"` // empty means the fee collection address
+	Interval    int64  `json:"interval"`    // unit s, the min time between two sweeps; 0 means daily
+	Available   bool   `json:"available"`
+	Description string `json:"description"`
+}
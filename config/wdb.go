@@ -52,7 +52,11 @@ func NewSqliteDb(dbDir string) *Wdb {
 func (w *Wdb) Migrate() error {
 	return w.Db.AutoMigrate(&schema.FeeConfig{},
 		&schema.IpRateWhitelist{},
-		&schema.Param{})
+		&schema.Param{},
+		&schema.Admin{},
+		&schema.PricingPlan{},
+		&schema.PricingPlanAssignment{},
+		&schema.SweepPolicy{})
 }
 
 func (w *Wdb) Close() {
@@ -80,6 +84,30 @@ func (w *Wdb) GetAllAvailableIpRateWhitelist() ([]schema.IpRateWhitelist, error)
 	return res, err
 }
 
+func (w *Wdb) GetAvailableAdmins() ([]schema.Admin, error) {
+	res := make([]schema.Admin, 0, 10)
+	err := w.Db.Where("available = ?", true).Find(&res).Error
+	return res, err
+}
+
+func (w *Wdb) GetAvailablePricingPlans() ([]schema.PricingPlan, error) {
+	res := make([]schema.PricingPlan, 0, 10)
+	err := w.Db.Where("available = ?", true).Find(&res).Error
+	return res, err
+}
+
+func (w *Wdb) GetPricingPlanAssignments() ([]schema.PricingPlanAssignment, error) {
+	res := make([]schema.PricingPlanAssignment, 0, 10)
+	err := w.Db.Find(&res).Error
+	return res, err
+}
+
+func (w *Wdb) GetAvailableSweepPolicies() ([]schema.SweepPolicy, error) {
+	res := make([]schema.SweepPolicy, 0, 10)
+	err := w.Db.Where("available = ?", true).Find(&res).Error
+	return res, err
+}
+
 func (w *Wdb) GetParam() (param schema.Param, err error) {
 	err = w.Db.First(&param).Error
 	if err == gorm.ErrRecordNotFound {
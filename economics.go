@@ -0,0 +1,292 @@
+package arseeding
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/shopspring/decimal"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	winstonPerAr = decimal.New(1, 12)
+	bytesPerGb   = decimal.NewFromInt(1 << 30)
+)
+
+// priceHistory find the USD price of symbol at a time from the snapshots, the current price is used if no snapshot
+type priceHistory struct {
+	snapshots map[string][]schema.TokenPriceSnapshot // sorted by time
+	current   map[string]float64
+}
+
+func newPriceHistory(snapshots []schema.TokenPriceSnapshot, current []schema.TokenPrice) *priceHistory {
+	h := &priceHistory{
+		snapshots: make(map[string][]schema.TokenPriceSnapshot),
+		current:   make(map[string]float64),
+	}
+	for _, snap := range snapshots {
+		symbol := strings.ToUpper(snap.Symbol)
+		h.snapshots[symbol] = append(h.snapshots[symbol], snap)
+	}
+	for symbol := range h.snapshots {
+		snaps := h.snapshots[symbol]
+		sort.Slice(snaps, func(i, j int) bool {
+			return snaps[i].CreatedAt.Before(snaps[j].CreatedAt)
+		})
+	}
+	for _, tp := range current {
+		h.current[strings.ToUpper(tp.Symbol)] = tp.Price
+	}
+	return h
+}
+
+// priceAt return the last price before t, or the earliest price after t
+func (h *priceHistory) priceAt(symbol string, t time.Time) (decimal.Decimal, bool) {
+	symbol = strings.ToUpper(symbol)
+	if symbol == schema.UsdCurrency {
+		return decimal.NewFromInt(1), true
+	}
+	snaps := h.snapshots[symbol]
+	if len(snaps) > 0 {
+		idx := sort.Search(len(snaps), func(i int) bool {
+			return snaps[i].CreatedAt.After(t)
+		})
+		if idx > 0 {
+			idx--
+		}
+		return decimal.NewFromFloat(snaps[idx].Price), true
+	}
+	price, ok := h.current[symbol]
+	return decimal.NewFromFloat(price), ok
+}
+
+func (s *Arseeding) snapshotTokenPrices() {
+	tps, err := s.wdb.GetPrices()
+	if err != nil {
+		log.Error("s.wdb.GetPrices()", "err", err)
+		return
+	}
+	snapshots := make([]schema.TokenPriceSnapshot, 0, len(tps))
+	for _, tp := range tps {
+		if tp.Price <= 0.0 {
+			continue
+		}
+		snapshots = append(snapshots, schema.TokenPriceSnapshot{Symbol: tp.Symbol, Price: tp.Price})
+	}
+	if len(snapshots) == 0 {
+		return
+	}
+	if err = s.wdb.InsertPriceSnapshots(snapshots); err != nil {
+		log.Error("s.wdb.InsertPriceSnapshots(snapshots)", "err", err)
+	}
+}
+
+type economicsCurrencyAcc struct {
+	decimals int
+	items    int64
+	revenue  decimal.Decimal
+	usd      decimal.Decimal
+}
+
+type economicsDayAcc struct {
+	day        schema.EconomicsDay
+	arCost     decimal.Decimal
+	costUsd    decimal.Decimal
+	revenueUsd decimal.Decimal
+	currencies map[string]*economicsCurrencyAcc
+}
+
+func newEconomicsDayAcc(date string) *economicsDayAcc {
+	return &economicsDayAcc{
+		day:        schema.EconomicsDay{Date: date},
+		currencies: make(map[string]*economicsCurrencyAcc),
+	}
+}
+
+func (acc *economicsDayAcc) addRevenue(currency string, decimals int, revenue, usd decimal.Decimal) {
+	cur, ok := acc.currencies[currency]
+	if !ok {
+		cur = &economicsCurrencyAcc{decimals: decimals}
+		acc.currencies[currency] = cur
+	}
+	cur.items++
+	cur.revenue = cur.revenue.Add(revenue)
+	cur.usd = cur.usd.Add(usd)
+	acc.revenueUsd = acc.revenueUsd.Add(usd)
+}
+
+func (acc *economicsDayAcc) result() schema.EconomicsDay {
+	day := acc.day
+	day.ArCost = acc.arCost.String()
+	day.CostUsd = acc.costUsd.Round(schema.EconomicsUsdDecimals).String()
+	day.RevenueUsd = acc.revenueUsd.Round(schema.EconomicsUsdDecimals).String()
+	day.MarginUsd = acc.revenueUsd.Sub(acc.costUsd).Round(schema.EconomicsUsdDecimals).String()
+	day.CostPerGbUsd = "0"
+	if day.Bytes > 0 {
+		day.CostPerGbUsd = acc.costUsd.Mul(bytesPerGb).Div(decimal.NewFromInt(day.Bytes)).Round(schema.EconomicsUsdDecimals).String()
+	}
+	day.Currencies = economicsCurrencies(acc.currencies)
+	return day
+}
+
+func economicsCurrencies(currencies map[string]*economicsCurrencyAcc) []schema.EconomicsCurrency {
+	res := make([]schema.EconomicsCurrency, 0, len(currencies))
+	for currency, cur := range currencies {
+		res = append(res, schema.EconomicsCurrency{
+			Currency:   currency,
+			Decimals:   cur.decimals,
+			Items:      cur.items,
+			Revenue:    cur.revenue.String(),
+			RevenueUsd: cur.usd.Round(schema.EconomicsUsdDecimals).String(),
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Currency < res[j].Currency
+	})
+	return res
+}
+
+// BuildEconomicsReport the bundles on chain in [start, end] are counted by the day they are posted,
+// the bundle cost is converted at the AR price of post time and the item fee at the token price of order time
+func (s *Arseeding) BuildEconomicsReport(start, end string) (*schema.EconomicsReport, error) {
+	startTime, err := time.ParseInLocation("20060102", start, time.UTC)
+	if err != nil {
+		return nil, fmt.Errorf("start format must be yyyyMMdd: %s", start)
+	}
+	endTime, err := time.ParseInLocation("20060102", end, time.UTC)
+	if err != nil {
+		return nil, fmt.Errorf("end format must be yyyyMMdd: %s", end)
+	}
+	r := schema.TimeRange{Start: startTime, End: endTime.AddDate(0, 0, 1)}
+	if !r.End.After(r.Start) || r.End.Sub(r.Start) > schema.MaxEconomicsReportDays*24*time.Hour {
+		return nil, fmt.Errorf("the report range must be in 1 to %d days", schema.MaxEconomicsReportDays)
+	}
+
+	snapshots, err := s.wdb.GetPriceSnapshots(r)
+	if err != nil {
+		return nil, err
+	}
+	tps, err := s.wdb.GetPrices()
+	if err != nil {
+		return nil, err
+	}
+	prices := newPriceHistory(snapshots, tps)
+	arTxs, err := s.wdb.GetArTxsByTime(schema.SuccOnChain, r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &schema.EconomicsReport{
+		Start:               start,
+		End:                 end,
+		Days:                make([]schema.EconomicsDay, 0),
+		UnprofitableBundles: make([]schema.EconomicsBundle, 0),
+	}
+	total := newEconomicsDayAcc("")
+	days := make(map[string]*economicsDayAcc)
+	dates := make([]string, 0)
+	for _, arTx := range arTxs {
+		date := arTx.CreatedAt.UTC().Format("20060102")
+		dayAcc, ok := days[date]
+		if !ok {
+			dayAcc = newEconomicsDayAcc(date)
+			days[date] = dayAcc
+			dates = append(dates, date)
+		}
+
+		bundle, err := s.bundleEconomics(arTx, prices)
+		if err != nil {
+			log.Error("s.bundleEconomics(arTx, prices)", "err", err, "arId", arTx.ArId)
+			return nil, err
+		}
+		bundle.result.Date = date
+		for _, acc := range []*economicsDayAcc{dayAcc, total} {
+			acc.day.Bundles++
+			acc.day.Items += bundle.result.Items
+			acc.day.Bytes += bundle.result.Bytes
+			acc.arCost = acc.arCost.Add(bundle.arCost)
+			acc.costUsd = acc.costUsd.Add(bundle.costUsd)
+			for _, rev := range bundle.revenues {
+				acc.addRevenue(rev.currency, rev.decimals, rev.revenue, rev.usd)
+			}
+			if bundle.revenueUsd.LessThan(bundle.costUsd) {
+				acc.day.UnprofitableBundles++
+			}
+		}
+		if bundle.revenueUsd.LessThan(bundle.costUsd) {
+			report.UnprofitableBundles = append(report.UnprofitableBundles, bundle.result)
+		}
+	}
+	for _, date := range dates {
+		report.Days = append(report.Days, days[date].result())
+	}
+	report.Total = total.result()
+	report.Currencies = report.Total.Currencies
+	return report, nil
+}
+
+type economicsRevenue struct {
+	currency string
+	decimals int
+	revenue  decimal.Decimal
+	usd      decimal.Decimal
+}
+
+type bundleEconomics struct {
+	result     schema.EconomicsBundle
+	arCost     decimal.Decimal
+	costUsd    decimal.Decimal
+	revenueUsd decimal.Decimal
+	revenues   []economicsRevenue
+}
+
+func (s *Arseeding) bundleEconomics(arTx schema.OnChainTx, prices *priceHistory) (*bundleEconomics, error) {
+	res := &bundleEconomics{revenues: make([]economicsRevenue, 0)}
+	res.arCost, _ = decimal.NewFromString(arTx.Reward)
+	if arPrice, ok := prices.priceAt("AR", arTx.CreatedAt); ok {
+		res.costUsd = res.arCost.Div(winstonPerAr).Mul(arPrice)
+	}
+	res.result.Bytes, _ = strconv.ParseInt(arTx.DataSize, 10, 64)
+
+	itemIds := make([]string, 0)
+	if len(arTx.ItemIds) > 0 {
+		if err := json.Unmarshal(arTx.ItemIds, &itemIds); err != nil {
+			return nil, err
+		}
+	}
+	res.result.Items = int64(len(itemIds))
+	paid := make(map[string]bool)
+	for i := 0; i < len(itemIds); i += statementOrderBatch {
+		end := i + statementOrderBatch
+		if end > len(itemIds) {
+			end = len(itemIds)
+		}
+		orders, err := s.wdb.GetPaidOrdersByItemIds(itemIds[i:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, ord := range orders {
+			fee, err := decimal.NewFromString(ord.Fee)
+			if err != nil || paid[ord.ItemId] {
+				continue
+			}
+			paid[ord.ItemId] = true
+			rev := economicsRevenue{currency: strings.ToUpper(ord.Currency), decimals: ord.Decimals, revenue: fee}
+			if price, ok := prices.priceAt(ord.Currency, ord.CreatedAt); ok {
+				rev.usd = fee.Shift(int32(-ord.Decimals)).Mul(price)
+			}
+			res.revenueUsd = res.revenueUsd.Add(rev.usd)
+			res.revenues = append(res.revenues, rev)
+		}
+	}
+
+	res.result.ArId = arTx.ArId
+	res.result.ArCost = res.arCost.String()
+	res.result.CostUsd = res.costUsd.Round(schema.EconomicsUsdDecimals).String()
+	res.result.RevenueUsd = res.revenueUsd.Round(schema.EconomicsUsdDecimals).String()
+	res.result.MarginUsd = res.revenueUsd.Sub(res.costUsd).Round(schema.EconomicsUsdDecimals).String()
+	return res, nil
+}
@@ -0,0 +1,72 @@
+package arseeding
+
+import (
+	"encoding/json"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestBuildEconomicsReport(t *testing.T) {
+	sqliteDir := "./data/economics"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	aa := &Arseeding{wdb: wdb}
+
+	at := func(d, h int) time.Time {
+		return time.Date(2024, 3, d, h, 0, 0, 0, time.UTC)
+	}
+	assert.NoError(t, wdb.InsertPrices([]schema.TokenPrice{{Symbol: "AR", Price: 100}, {Symbol: "USDC", Price: 1}}))
+	assert.NoError(t, wdb.InsertPriceSnapshots([]schema.TokenPriceSnapshot{
+		{CreatedAt: at(1, 0), Symbol: "AR", Price: 10},
+		{CreatedAt: at(2, 0), Symbol: "AR", Price: 20},
+	}))
+
+	itemIds := func(ids ...string) []byte {
+		js, _ := json.Marshal(ids)
+		return js
+	}
+	// 0.1 AR at 10 USD and 0.1 AR at 20 USD
+	assert.NoError(t, wdb.InsertArTx(schema.OnChainTx{Model: gorm.Model{CreatedAt: at(1, 10)}, ArId: "bundle-1", Reward: "100000000000", DataSize: "1073741824", Status: schema.SuccOnChain, ItemIds: itemIds("item-1", "item-2")}))
+	assert.NoError(t, wdb.InsertArTx(schema.OnChainTx{Model: gorm.Model{CreatedAt: at(2, 10)}, ArId: "bundle-2", Reward: "100000000000", DataSize: "1073741824", Status: schema.SuccOnChain, ItemIds: itemIds("item-3")}))
+	assert.NoError(t, wdb.InsertArTx(schema.OnChainTx{Model: gorm.Model{CreatedAt: at(2, 11)}, ArId: "bundle-3", Reward: "100000000000", Status: schema.PendingOnChain}))
+	assert.NoError(t, wdb.InsertOrder(schema.Order{CreatedAt: at(1, 9), ItemId: "item-1", Currency: "USDC", Decimals: 6, Fee: "1500000", PaymentStatus: schema.SuccPayment}))
+	assert.NoError(t, wdb.InsertOrder(schema.Order{CreatedAt: at(1, 9), ItemId: "item-2", Currency: "AR", Decimals: 12, Fee: "10000000000", PaymentStatus: schema.SuccPayment}))
+	assert.NoError(t, wdb.InsertOrder(schema.Order{CreatedAt: at(2, 9), ItemId: "item-3", Currency: "USDC", Decimals: 6, Fee: "1000000", PaymentStatus: schema.SuccPayment}))
+
+	_, err := aa.BuildEconomicsReport("20240301", "20240501")
+	assert.Error(t, err)
+	report, err := aa.BuildEconomicsReport("20240301", "20240302")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(report.Days))
+
+	// cost 1 USD, revenue 1.5 USDC + 0.01 AR at 10 USD
+	day1 := report.Days[0]
+	assert.Equal(t, "20240301", day1.Date)
+	assert.Equal(t, int64(2), day1.Items)
+	assert.Equal(t, "1", day1.CostUsd)
+	assert.Equal(t, "1.6", day1.RevenueUsd)
+	assert.Equal(t, "0.6", day1.MarginUsd)
+	assert.Equal(t, "1", day1.CostPerGbUsd)
+	assert.Equal(t, int64(0), day1.UnprofitableBundles)
+
+	// cost 2 USD, revenue 1 USDC
+	day2 := report.Days[1]
+	assert.Equal(t, "-1", day2.MarginUsd)
+	assert.Equal(t, int64(1), day2.UnprofitableBundles)
+	assert.Equal(t, 1, len(report.UnprofitableBundles))
+	assert.Equal(t, "bundle-2", report.UnprofitableBundles[0].ArId)
+
+	assert.Equal(t, int64(2), report.Total.Bundles)
+	assert.Equal(t, "200000000000", report.Total.ArCost)
+	assert.Equal(t, "-0.4", report.Total.MarginUsd)
+	assert.Equal(t, "1.5", report.Total.CostPerGbUsd)
+	assert.Equal(t, []schema.EconomicsCurrency{
+		{Currency: "AR", Decimals: 12, Items: 1, Revenue: "10000000000", RevenueUsd: "0.1"},
+		{Currency: "USDC", Decimals: 6, Items: 2, Revenue: "2500000", RevenueUsd: "2.5"},
+	}, report.Currencies)
+}
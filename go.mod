@@ -31,6 +31,7 @@ require (
 require (
 	github.com/Khan/genqlient v0.6.0
 	github.com/allegro/bigcache/v3 v3.1.0
+	github.com/btcsuite/btcd/btcutil v1.1.3
 	github.com/everFinance/go-everpay v0.1.1
 	github.com/everFinance/goarns v0.0.3
 	github.com/segmentio/kafka-go v0.4.40
@@ -41,7 +42,6 @@ require (
 	github.com/StackExchange/wmi v1.2.1 // indirect
 	github.com/beorn7/perks v1.0.1 // indirect
 	github.com/btcsuite/btcd/btcec/v2 v2.3.2 // indirect
-	github.com/btcsuite/btcd/btcutil v1.1.3 // indirect
 	github.com/cespare/xxhash/v2 v2.1.2 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -0,0 +1,55 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/schema"
+	"time"
+)
+
+// BeginIdempotentRequest record the request with the idempotency key, return the completed record if the request has been processed
+func (s *Arseeding) BeginIdempotentRequest(scope, idempotencyKey, request, bodyHash string) (*schema.IdempotencyRecord, error) {
+	now := time.Now().Unix()
+	record := schema.IdempotencyRecord{
+		Scope:          scope,
+		IdempotencyKey: idempotencyKey,
+		Request:        request,
+		BodyHash:       bodyHash,
+		Status:         schema.IdempotencyProcessing,
+		ExpiredTime:    now + schema.IdempotencyExpiredRange,
+	}
+	if err := s.wdb.InsertIdempotencyRecord(record); err == nil {
+		return nil, nil
+	}
+
+	// the key has been used
+	old, err := s.wdb.GetIdempotencyRecord(scope, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if old.ExpiredTime < now {
+		// out of the idempotency window, process as a new request
+		if err = s.wdb.DelIdempotencyRecord(scope, idempotencyKey); err != nil {
+			return nil, err
+		}
+		return nil, s.wdb.InsertIdempotencyRecord(record)
+	}
+	if old.Request != request || old.BodyHash != bodyHash {
+		return nil, schema.ErrIdempotencyKeyReused
+	}
+	if old.Status != schema.IdempotencyCompleted {
+		return nil, schema.ErrIdempotencyKeyInProcess
+	}
+	return &old, nil
+}
+
+// EndIdempotentRequest keep the response if the request succeed, otherwise the request can be retried with the same key
+func (s *Arseeding) EndIdempotentRequest(scope, idempotencyKey string, succeed bool, response []byte) {
+	var err error
+	if succeed {
+		err = s.wdb.CompleteIdempotencyRecord(scope, idempotencyKey, response)
+	} else {
+		err = s.wdb.DelIdempotencyRecord(scope, idempotencyKey)
+	}
+	if err != nil {
+		log.Error("s.EndIdempotentRequest", "err", err, "succeed", succeed)
+	}
+}
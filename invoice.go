@@ -0,0 +1,172 @@
+package arseeding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// CreateInvoice group the unpaid orders of items in currency, the invoice amount is the total fee of orders
+func (s *Arseeding) CreateInvoice(req schema.ReqCreateInvoice) (*schema.RespInvoice, error) {
+	if len(req.ItemIds) == 0 {
+		return nil, errors.New("itemIds is empty")
+	}
+	if len(req.ItemIds) > schema.MaxInvoiceItems {
+		return nil, fmt.Errorf("itemIds can not more than %d", schema.MaxInvoiceItems)
+	}
+	itemIds := make([]string, 0, len(req.ItemIds))
+	visited := make(map[string]bool)
+	for _, itemId := range req.ItemIds {
+		if visited[itemId] {
+			continue
+		}
+		visited[itemId] = true
+		itemIds = append(itemIds, itemId)
+	}
+
+	ordMap, err := getUnPaidOrderMap(s.wdb, itemIds)
+	if err != nil {
+		return nil, err
+	}
+	total := big.NewInt(0)
+	expiredTime := int64(0)
+	decimals := 0
+	for _, itemId := range itemIds {
+		ord, ok := ordMap[itemId]
+		if !ok {
+			return nil, fmt.Errorf("unpaid order not found: %s", itemId)
+		}
+		if !strings.EqualFold(ord.Currency, req.Currency) {
+			return nil, fmt.Errorf("order currency is %s: %s", ord.Currency, itemId)
+		}
+		fee, ok := new(big.Int).SetString(ord.Fee, 10)
+		if !ok {
+			return nil, fmt.Errorf("order fee incorrect: %s", itemId)
+		}
+		total = new(big.Int).Add(total, fee)
+		if expiredTime == 0 || ord.PaymentExpiredTime < expiredTime {
+			expiredTime = ord.PaymentExpiredTime
+		}
+		decimals = ord.Decimals
+	}
+
+	itemIdsJs, err := json.Marshal(itemIds)
+	if err != nil {
+		return nil, err
+	}
+	invoice := schema.Invoice{
+		InvoiceId:   uuid.NewString(),
+		Currency:    strings.ToUpper(req.Currency),
+		Decimals:    decimals,
+		Amount:      total.String(),
+		ItemIds:     itemIdsJs,
+		Status:      schema.InvoiceUnpaid,
+		ExpiredTime: expiredTime,
+	}
+	if err = s.wdb.InsertInvoice(invoice); err != nil {
+		return nil, err
+	}
+	return s.respInvoice(invoice, len(itemIds)), nil
+}
+
+func (s *Arseeding) GetInvoice(invoiceId string) (*schema.RespInvoice, error) {
+	invoice, err := s.wdb.GetInvoice(invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	itemIds := make([]string, 0)
+	if err = json.Unmarshal(invoice.ItemIds, &itemIds); err != nil {
+		return nil, err
+	}
+	return s.respInvoice(invoice, len(itemIds)), nil
+}
+
+func (s *Arseeding) respInvoice(invoice schema.Invoice, itemCount int) *schema.RespInvoice {
+	return &schema.RespInvoice{
+		InvoiceId:   invoice.InvoiceId,
+		Bundler:     s.bundler.Signer.Address,
+		Currency:    invoice.Currency,
+		Decimals:    invoice.Decimals,
+		Amount:      invoice.Amount,
+		ItemCount:   itemCount,
+		Status:      invoice.Status,
+		PaymentId:   invoice.PaymentId,
+		ExpiredTime: invoice.ExpiredTime,
+	}
+}
+
+// processPayInvoice pay all the orders of invoice in one db transaction, the receipt is refunded if the invoice can not be paid.
+// The receipt is kept unspent and retried if the db is failed
+func processPayInvoice(wdb *Wdb, invoiceId string, urtx schema.ReceiptEverTx) error {
+	needRefund, err := payInvoice(wdb, invoiceId, urtx)
+	if err != nil {
+		log.Error("payInvoice(wdb, invoiceId, urtx)", "err", err, "invoiceId", invoiceId, "id", urtx.RawId, "needRefund", needRefund)
+		if !needRefund {
+			return err
+		}
+		if err := wdb.UpdateReceiptStatus(urtx.RawId, schema.UnRefund, nil); err != nil {
+			log.Error("s.wdb.UpdateReceiptStatus9", "err", err, "id", urtx.RawId)
+		}
+		return err
+	}
+	return nil
+}
+
+func payInvoice(wdb *Wdb, invoiceId string, urtx schema.ReceiptEverTx) (needRefund bool, err error) {
+	invoice, err := wdb.GetInvoice(invoiceId)
+	if err != nil {
+		return err == gorm.ErrRecordNotFound, err
+	}
+	if invoice.ExpiredTime < time.Now().Unix() {
+		return true, errors.New("invoice expired")
+	}
+	if !strings.EqualFold(invoice.Currency, urtx.Symbol) {
+		return true, errors.New("currency incorrect")
+	}
+	itemIds := make([]string, 0)
+	if err = json.Unmarshal(invoice.ItemIds, &itemIds); err != nil {
+		return true, err
+	}
+	ordArr, err := getUnPaidOrdersByItemIds(wdb, itemIds)
+	if err != nil {
+		return err == gorm.ErrRecordNotFound, err
+	}
+	if err = checkOrdersCurrency(ordArr, urtx.Symbol); err != nil {
+		return true, err
+	}
+	if err = checkOrdersAmount(ordArr, urtx.Amount); err != nil {
+		return true, err
+	}
+
+	dbTx := wdb.Db.Begin()
+	ok, err := wdb.LockInvoicePaid(invoiceId, urtx.EverHash, dbTx)
+	if err != nil {
+		dbTx.Rollback()
+		return false, err
+	}
+	if !ok {
+		dbTx.Rollback()
+		return true, errors.New("invoice is not unpaid")
+	}
+	for _, ord := range ordArr {
+		if err = wdb.UpdateOrderPay(ord.ID, urtx.EverHash, schema.SuccPayment, dbTx); err != nil {
+			dbTx.Rollback()
+			return err == schema.ErrOrderNotUnpaid, err
+		}
+	}
+	if err = addPaidUsage(wdb, ordArr, dbTx); err != nil {
+		dbTx.Rollback()
+		return false, err
+	}
+	if err = wdb.UpdateReceiptStatus(urtx.RawId, schema.Spent, dbTx); err != nil {
+		dbTx.Rollback()
+		return false, err
+	}
+	return false, dbTx.Commit().Error
+}
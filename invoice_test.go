@@ -0,0 +1,77 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar"
+	"github.com/everFinance/goar/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestInvoicePayment(t *testing.T) {
+	sqliteDir := "./data/invoice"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	prvKey, err := utils.GenerateRsaKey(2048)
+	assert.NoError(t, err)
+	signer := goar.NewSignerByPrivateKey(prvKey)
+	aa := &Arseeding{wdb: wdb, bundler: &goar.Wallet{Signer: signer}}
+
+	expired := time.Now().Unix() + 3600
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-1", Currency: "USDC", Decimals: 6, Fee: "100", PaymentStatus: schema.UnPayment, PaymentExpiredTime: expired}))
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-2", Currency: "USDC", Decimals: 6, Fee: "200", PaymentStatus: schema.UnPayment, PaymentExpiredTime: expired - 10}))
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-3", Currency: "AR", Fee: "10", PaymentStatus: schema.UnPayment, PaymentExpiredTime: expired}))
+
+	_, err = aa.CreateInvoice(schema.ReqCreateInvoice{Currency: "USDC", ItemIds: []string{"item-1", "item-3"}})
+	assert.Error(t, err) // currency mismatch
+	_, err = aa.CreateInvoice(schema.ReqCreateInvoice{Currency: "USDC", ItemIds: []string{"item-1", "item-4"}})
+	assert.Error(t, err) // not found
+	invoice, err := aa.CreateInvoice(schema.ReqCreateInvoice{Currency: "usdc", ItemIds: []string{"item-1", "item-2", "item-1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "300", invoice.Amount)
+	assert.Equal(t, 2, invoice.ItemCount)
+	assert.Equal(t, expired-10, invoice.ExpiredTime)
+
+	action, ids, err := parseTxData(`{"appName":"arseeding","action":"invoicePayment","invoiceId":"` + invoice.InvoiceId + `"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, InvoicePaymentAction, action)
+	assert.Equal(t, []string{invoice.InvoiceId}, ids)
+
+	// amount not enough, the receipt is refunded and no order is paid
+	urtx := schema.ReceiptEverTx{RawId: 1, EverHash: "everHash-1", Symbol: "USDC", Amount: "299", Status: schema.UnSpent}
+	assert.NoError(t, wdb.InsertReceiptTx(urtx))
+	assert.Error(t, processPayInvoice(wdb, invoice.InvoiceId, urtx))
+	assert.False(t, wdb.ExistPaidOrd("item-1"))
+	rpt, err := wdb.GetReceiptByEverHash("everHash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.UnRefund, rpt.Status)
+
+	urtx = schema.ReceiptEverTx{RawId: 2, EverHash: "everHash-2", Symbol: "USDC", Amount: "300", Status: schema.UnSpent}
+	assert.NoError(t, wdb.InsertReceiptTx(urtx))
+	assert.NoError(t, processPayInvoice(wdb, invoice.InvoiceId, urtx))
+	assert.True(t, wdb.ExistPaidOrd("item-1"))
+	assert.True(t, wdb.ExistPaidOrd("item-2"))
+	res, err := aa.GetInvoice(invoice.InvoiceId)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.InvoicePaid, res.Status)
+	assert.Equal(t, "everHash-2", res.PaymentId)
+
+	// the paid invoice can not be paid again
+	urtx = schema.ReceiptEverTx{RawId: 3, EverHash: "everHash-3", Symbol: "USDC", Amount: "300", Status: schema.UnSpent}
+	assert.NoError(t, wdb.InsertReceiptTx(urtx))
+	assert.Error(t, processPayInvoice(wdb, invoice.InvoiceId, urtx))
+	rpt, err = wdb.GetReceiptByEverHash("everHash-3")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.UnRefund, rpt.Status)
+
+	// the unknown invoice is refunded
+	urtx = schema.ReceiptEverTx{RawId: 4, EverHash: "everHash-4", Symbol: "USDC", Amount: "300", Status: schema.UnSpent}
+	assert.NoError(t, wdb.InsertReceiptTx(urtx))
+	assert.Error(t, processPayInvoice(wdb, "unknown", urtx))
+	rpt, err = wdb.GetReceiptByEverHash("everHash-4")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.UnRefund, rpt.Status)
+}
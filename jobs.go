@@ -2,25 +2,17 @@ package arseeding
 
 import (
 	"context"
-	"crypto/rand"
 	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/crypto/ecies"
 	"github.com/everFinance/arseeding/rawdb"
 	"github.com/everFinance/arseeding/schema"
-	"github.com/everFinance/go-everpay/account"
-	"github.com/everFinance/go-everpay/config"
-	sdkSchema "github.com/everFinance/go-everpay/sdk/schema"
-	paySchema "github.com/everFinance/go-everpay/token/schema"
-	tokUtils "github.com/everFinance/go-everpay/token/utils"
 	"github.com/everFinance/goar"
 	"github.com/everFinance/goar/types"
 	"github.com/everFinance/goar/utils"
-	"github.com/google/uuid"
 	"github.com/panjf2000/ants/v2"
 	"github.com/shopspring/decimal"
 	"github.com/tidwall/gjson"
@@ -33,8 +25,9 @@ import (
 )
 
 const (
-	ItemPaymentAction   = "payment"
-	ApikeyPaymentAction = "apikeyPayment"
+	ItemPaymentAction    = "payment"
+	ApikeyPaymentAction  = "apikeyPayment"
+	InvoicePaymentAction = "invoicePayment"
 )
 
 func (s *Arseeding) runJobs(bundleInterval int) {
@@ -47,17 +40,23 @@ func (s *Arseeding) runJobs(bundleInterval int) {
 	s.scheduler.Every(1).Minute().SingletonMode().Do(s.updateBundlePerFee)
 	// about bundle
 	if !s.NoFee {
-		go s.watchEverReceiptTxs()
+		for _, provider := range s.paymentProviders {
+			go s.watchReceipts(provider)
+		}
 		s.scheduler.Every(5).Seconds().SingletonMode().Do(s.mergeReceiptEverTxs)
 		s.scheduler.Every(2).Minute().SingletonMode().Do(s.refundReceipt)
 		s.scheduler.Every(1).Minute().SingletonMode().Do(s.processExpiredOrd)
 		// collection fee
-		s.scheduler.Every(1).Day().At("00:00").SingletonMode().Do(s.collectFee)
+		s.scheduler.Every(1).Hour().SingletonMode().Do(s.collectFee) // the sweep interval of token is controlled by policy
+		// price history for the economics report
+		s.scheduler.Every(1).Hour().SingletonMode().Do(s.snapshotTokenPrices)
 	}
 
 	s.scheduler.Every(bundleInterval).Seconds().SingletonMode().Do(s.onChainBundleItems) // can set a longer time, if the items are less. such as 2m
 	// onChainBundleItems by upload order
 	s.scheduler.Every(bundleInterval).Seconds().SingletonMode().Do(s.onChainItemsBySeq)
+	// express items are bundled at once
+	s.scheduler.Every(5).Seconds().SingletonMode().Do(s.onChainExpressItems)
 	s.scheduler.Every(3).Minute().SingletonMode().Do(s.watchArTx)
 	s.scheduler.Every(2).Minute().SingletonMode().Do(s.retryOnChainArTx)
 
@@ -67,14 +66,20 @@ func (s *Arseeding) runJobs(bundleInterval int) {
 	s.scheduler.Every(5).Seconds().SingletonMode().Do(s.watcherAndCloseTasks)
 
 	s.scheduler.Every(1).Minute().SingletonMode().Do(s.updateBundler)
+	s.scheduler.Every(10).Seconds().SingletonMode().Do(s.updateBundleTags)
 
 	// delete tmp file, one may be repeat request same data,tmp file can be reserve with short time
 	s.scheduler.Every(2).Minute().SingletonMode().Do(s.deleteTmpFile)
+	s.scheduler.Every(10).Minute().SingletonMode().Do(s.processExpiredUploadSession)
+	s.scheduler.Every(1).Hour().SingletonMode().Do(s.delExpiredIdempotencyRecords)
+	s.scheduler.Every(1).Hour().SingletonMode().Do(s.delExpiredAuth)
+	s.scheduler.Every(1).Hour().SingletonMode().Do(s.delExpiredFeeQuotes)
 
 	//statistic
 	s.scheduler.Every(1).Minute().SingletonMode().Do(s.UpdateRealTime)
 	go s.ProduceDailyStatistic()
 	s.scheduler.Every(1).Day().At("00:01").SingletonMode().Do(s.ProduceDailyStatistic)
+	s.scheduler.Every(1).Day().At("00:10").SingletonMode().Do(s.ProduceBillingStatements)
 
 	// kafka
 	if len(s.KWriters) > 0 {
@@ -150,17 +155,20 @@ func (s *Arseeding) updateTokenPrice() {
 		log.Error("s.wdb.GetPrices()", "err", err)
 		return
 	}
+	oracle, err := NewPriceOracle(s.config.Param.PriceSources, s.config.Param.PriceMaxChange, s.config.Param.PriceMinAgree)
+	if err != nil {
+		log.Error("NewPriceOracle", "err", err, "sources", s.config.Param.PriceSources)
+		return
+	}
 	for _, tp := range tps {
 		if tp.ManualSet {
 			continue
 		}
-		price, err := config.GetTokenPriceByRedstone(tp.Symbol, "USDC", "")
+		// the move is checked against the last accepted price even if it is stale,
+		// a big move needs the agreement of several sources or the price set by admin
+		price, err := oracle.Query(tp.Symbol, tp.Price)
 		if err != nil {
-			log.Error("config.GetTokenPriceByRedstone(tp.Symbol,\"USDC\")", "err", err, "symbol", tp.Symbol)
-			continue
-		}
-		if price <= 0.0 {
-			// log.Error("GetTokenPriceByRedstone return price less than 0.0", "token", tp.Symbol)
+			log.Error("oracle.Query(tp.Symbol, tp.Price)", "err", err, "symbol", tp.Symbol, "stale", isPriceStale(tp, s.priceTTL()))
 			continue
 		}
 		// update tokenPrice
@@ -196,46 +204,14 @@ func (s *Arseeding) watcherAndCloseTasks() {
 	}
 }
 
-func (s *Arseeding) watchEverReceiptTxs() {
-	startCursor, err := s.wdb.GetLastEverRawId()
+func (s *Arseeding) watchReceipts(provider PaymentProvider) {
+	startCursor, err := s.wdb.GetLastReceiptRawId(provider.Name())
 	if err != nil {
 		panic(err)
 	}
-	subTx := s.everpaySdk.Cli.SubscribeTxs(sdkSchema.FilterQuery{
-		StartCursor: int64(startCursor),
-		Address:     s.bundler.Signer.Address,
-		Action:      paySchema.TxActionTransfer,
-	})
-	defer subTx.Unsubscribe()
-
-	for {
-		select {
-		case tt := <-subTx.Subscribe():
-			if tt.To != s.bundler.Signer.Address {
-				continue
-			}
-			_, from, err := account.IDCheck(tt.From)
-			if err != nil {
-				log.Error("account.IDCheck(tt.From)", "err", err, "from", tt.From)
-				continue
-			}
-
-			res := schema.ReceiptEverTx{
-				RawId:    uint64(tt.RawId),
-				EverHash: tt.EverHash,
-				Nonce:    tt.Nonce,
-				Symbol:   tt.TokenSymbol,
-				TokenTag: tokUtils.Tag(tt.ChainType, tt.TokenSymbol, tt.TokenID),
-				From:     from,
-				Amount:   tt.Amount,
-				Data:     tt.Data,
-				Sig:      tt.Sig,
-				Status:   schema.UnSpent,
-			}
-
-			if err = s.wdb.InsertReceiptTx(res); err != nil {
-				log.Error("s.wdb.InsertReceiptTx(res)", "err", err)
-			}
+	for res := range provider.Subscribe(startCursor) {
+		if err = s.wdb.InsertReceiptTx(res); err != nil {
+			log.Error("s.wdb.InsertReceiptTx(res)", "err", err, "provider", provider.Name())
 		}
 	}
 }
@@ -277,9 +253,20 @@ func processPayItems(wdb *Wdb, itemIds []string, urtx schema.ReceiptEverTx) erro
 		if err = wdb.UpdateOrderPay(ord.ID, urtx.EverHash, schema.SuccPayment, dbTx); err != nil {
 			log.Error("s.wdb.UpdateOrderPay(ord.ID,schema.SuccPayment,dbTx)", "err", err)
 			dbTx.Rollback()
-			break
+			// the order is expired or cancelled after it is queried, the other error is retried
+			if err == schema.ErrOrderNotUnpaid {
+				if err := wdb.UpdateReceiptStatus(urtx.RawId, schema.UnRefund, nil); err != nil {
+					log.Error("s.wdb.UpdateReceiptStatus10", "err", err, "id", urtx.RawId)
+				}
+			}
+			return err
 		}
 	}
+	if err = addPaidUsage(wdb, ordArr, dbTx); err != nil {
+		log.Error("addPaidUsage(wdb, ordArr, dbTx)", "err", err, "id", urtx.RawId)
+		dbTx.Rollback()
+		return err
+	}
 
 	if err = wdb.UpdateReceiptStatus(urtx.RawId, schema.Spent, dbTx); err != nil {
 		log.Error("s.wdb.UpdateReceiptStatus(urtx.ID,schema.Spent,dbTx)", "err", err)
@@ -299,74 +286,62 @@ func processPayApikey(wdb *Wdb, urtx schema.ReceiptEverTx) error {
 		return errors.New("amount can not be 0")
 	}
 
+	from, err := getOrCreatePayerApikey(wdb, urtx)
+	if err != nil {
+		return err
+	}
+
+	// add token balance and update spent status in one db transaction
+	amountDe, err := decimal.NewFromString(urtx.Amount)
+	if err != nil {
+		log.Error("decimal.NewFromString(urtx.Amount)", "err", err, "id", urtx.RawId)
+		return err
+	}
+	dbTx := wdb.Db.Begin()
+	if _, err = postLedgerEntry(wdb, from, urtx.Symbol, schema.LedgerCredit, amountDe, urtx.EverHash, "", nil, dbTx); err != nil {
+		log.Error("postLedgerEntry(credit)", "err", err, "id", urtx.RawId)
+		dbTx.Rollback()
+		return err
+	}
+	if err = wdb.UpdateReceiptStatus(urtx.RawId, schema.Spent, dbTx); err != nil {
+		log.Error("s.wdb.UpdateReceiptStatus8(urtx.ID,schema.Spent,dbTx)", "err", err, "id", urtx.RawId)
+		dbTx.Rollback()
+		return err
+	}
+	return dbTx.Commit().Error
+}
+
+// getOrCreatePayerApikey return the apikey address of the receipt payer, the apikey is created if not exist
+func getOrCreatePayerApikey(wdb *Wdb, urtx schema.ReceiptEverTx) (string, error) {
 	from := common.HexToAddress(urtx.From).String()
-	exist, apikey := wdb.ExistApikey(from)
+	exist, _ := wdb.ExistApikey(from)
 	if !exist {
 		// create new record
-		newKey, err := uuid.NewUUID()
-		if err != nil {
-			log.Error("uuid.NewUUID()", "err", err)
-			return err
-		}
-		newKeyStr := newKey.String()
 		// ecrcover public
 		public, err := ecrecoverPubkey(urtx.EverHash, urtx.Sig)
 		if err != nil {
 			log.Error("EcrecoverPubkey(urtx.EverHash,urtx.Sig)", "everHash", urtx.EverHash, "sig", urtx.Sig)
-			return err
+			return "", err
 		}
-
-		publicKey, err := crypto.UnmarshalPubkey(common.Hex2Bytes(public))
+		newKeyStr, encKey, err := newEncryptedApiKey(public)
 		if err != nil {
-			log.Error("crypto.UnmarshalPubkey(common.Hex2Bytes(public))", "err", err)
-			return err
-		}
-		encPub, err := ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(publicKey), []byte(newKeyStr), nil, nil)
-		if err != nil {
-			log.Error("ecies.Encrypt", "err", err)
-			return err
+			log.Error("newEncryptedApiKey(public)", "err", err)
+			return "", err
 		}
 
 		err = wdb.InsertApiKey(schema.AutoApiKey{
 			ApiKey:       newKeyStr,
 			PubKey:       public,
 			Address:      from,
-			EncryptedKey: common.Bytes2Hex(encPub),
-			TokenBalance: map[string]interface{}{
-				strings.ToUpper(urtx.Symbol): urtx.Amount,
-			},
+			EncryptedKey: encKey,
+			TokenBalance: map[string]interface{}{},
 		})
 		if err != nil {
 			log.Error("s.wdb.InsertApiKey", "err", err)
-			return err
-		}
-	} else {
-		// add token balance
-		tokBalMap := make(map[string]interface{})
-		for k, v := range apikey.TokenBalance {
-			tokBalMap[k] = v
-		}
-		amountDe, _ := decimal.NewFromString(urtx.Amount)
-		if oldBal, ok := tokBalMap[strings.ToUpper(urtx.Symbol)]; ok {
-			oldBalDe, _ := decimal.NewFromString(oldBal.(string))
-			newBalDe := amountDe.Add(oldBalDe)
-			tokBalMap[strings.ToUpper(urtx.Symbol)] = newBalDe.String()
-		} else {
-			tokBalMap[strings.ToUpper(urtx.Symbol)] = urtx.Amount
-		}
-
-		// update db
-		if err := wdb.UpdateApikeyTokenBal(from, tokBalMap); err != nil {
-			log.Error("s.wdb.UpdateApikeyTokenBal(from,tokBalMap)", "err", err)
-			return err
+			return "", err
 		}
 	}
-	//  更新 spent 状态
-	if err := wdb.UpdateReceiptStatus(urtx.RawId, schema.Spent, nil); err != nil {
-		log.Error("s.wdb.UpdateReceiptStatus8(urtx.ID,schema.Spent,nil)", "err", err, "id", urtx.RawId)
-		return err
-	}
-	return nil
+	return from, nil
 }
 
 func (s *Arseeding) mergeReceiptEverTxs() {
@@ -387,12 +362,32 @@ func (s *Arseeding) mergeReceiptEverTxs() {
 
 		switch action {
 		case ItemPaymentAction:
+			if s.creditSettlement(urtx) {
+				if err := settlePayItems(s.wdb, itemIds, urtx); err != nil {
+					log.Error("settlePayItems", "err", err)
+				}
+				continue
+			}
 			if err := processPayItems(s.wdb, itemIds, urtx); err != nil {
 				log.Error("processPayItemOrder", "err", err)
 				continue
 			}
 
+		case InvoicePaymentAction:
+			if err := processPayInvoice(s.wdb, itemIds[0], urtx); err != nil {
+				log.Error("processPayInvoice", "err", err)
+				continue
+			}
+
 		case ApikeyPaymentAction:
+			// apikey owner is recovered from everTx signature, only everPay is supported
+			if urtx.Provider != schema.PaymentProviderEverPay {
+				log.Error("apikey payment not support the provider", "provider", urtx.Provider, "id", urtx.RawId)
+				if err = s.wdb.UpdateReceiptStatus(urtx.RawId, schema.UnRefund, nil); err != nil {
+					log.Error("s.wdb.UpdateReceiptStatus6", "err", err, "id", urtx.RawId)
+				}
+				continue
+			}
 			if s.GetPerFee(urtx.Symbol) == nil {
 				log.Error("s.bundlePerFeeMap[strings.ToUpper(urtx.Symbol)]", "symbol", urtx.Symbol)
 				if err = s.wdb.UpdateReceiptStatus(urtx.RawId, schema.UnRefund, nil); err != nil {
@@ -469,6 +464,7 @@ func checkOrdersCurrency(ordArr []schema.Order, txSymbol string) error {
 	return nil
 }
 
+// parseTxData return the paid itemIds for item payment, or the invoiceId as the only element for invoice payment
 func parseTxData(txData string) (action string, itemIds []string, err error) {
 	res := gjson.Parse(txData)
 	// appName must be arseeding
@@ -490,58 +486,55 @@ func parseTxData(txData string) (action string, itemIds []string, err error) {
 		return ItemPaymentAction, itemIds, nil
 	case ApikeyPaymentAction:
 		return ApikeyPaymentAction, nil, nil
+	case InvoicePaymentAction:
+		invoiceId := res.Get("invoiceId").String()
+		if invoiceId == "" {
+			return "", nil, errors.New("invoiceId is empty")
+		}
+		return InvoicePaymentAction, []string{invoiceId}, nil
 	default:
 		return "", nil, errors.New(fmt.Sprintf("not support action: %s", act))
 	}
 }
 
-func getUnPaidOrdersByItemIds(wdb *Wdb, itemIds []string) ([]schema.Order, error) {
-	ordArr := make([]schema.Order, 0, len(itemIds))
-	for _, itemId := range itemIds {
-		ord, err := wdb.GetUnPaidOrder(itemId)
+const unPaidOrderBatch = 500
+
+// getUnPaidOrderMap return the last unpaid order of items, the orders are queried in batches
+func getUnPaidOrderMap(wdb *Wdb, itemIds []string) (map[string]schema.Order, error) {
+	ordMap := make(map[string]schema.Order, len(itemIds))
+	for i := 0; i < len(itemIds); i += unPaidOrderBatch {
+		end := i + unPaidOrderBatch
+		if end > len(itemIds) {
+			end = len(itemIds)
+		}
+		ords, err := wdb.GetUnPaidOrdersByItemIds(itemIds[i:end])
 		if err != nil {
-			log.Error("s.wdb.GetUnPaidOrder(itemId)", "err", err, "itemId", itemId)
 			return nil, err
 		}
-		ordArr = append(ordArr, ord)
+		for _, ord := range ords {
+			ordMap[ord.ItemId] = ord
+		}
 	}
-	return ordArr, nil
+	return ordMap, nil
 }
 
-func (s *Arseeding) collectFee() {
-	collectAddr := s.config.FeeCollectAddress()
-	if collectAddr == "" {
-		log.Warn("s.config.FeeCollectAddress()", "collectAddr", "null")
-		return
-	}
-
-	// check bundler address token balance
-	tokBals, err := s.everpaySdk.Cli.Balances(s.bundler.Signer.Address)
+// getUnPaidOrdersByItemIds return gorm.ErrRecordNotFound if any item has no unpaid order
+func getUnPaidOrdersByItemIds(wdb *Wdb, itemIds []string) ([]schema.Order, error) {
+	ordMap, err := getUnPaidOrderMap(wdb, itemIds)
 	if err != nil {
-		log.Error("s.everpaySdk.Cli.Balances(s.bundler.Signer.Address)", "err", err, "bundler", s.bundler.Signer.Address)
-		return
+		log.Error("getUnPaidOrderMap(wdb, itemIds)", "err", err)
+		return nil, err
 	}
-
-	for _, tokBal := range tokBals.Balances {
-		amt, ok := new(big.Int).SetString(tokBal.Amount, 10)
+	ordArr := make([]schema.Order, 0, len(itemIds))
+	for _, itemId := range itemIds {
+		ord, ok := ordMap[itemId]
 		if !ok {
-			continue
+			log.Error("unpaid order not found", "itemId", itemId)
+			return nil, gorm.ErrRecordNotFound
 		}
-		if amt.Cmp(big.NewInt(0)) <= 0 {
-			continue
-		}
-		mmap := map[string]string{
-			"appName": "arseeding",
-			"action":  "feeCollection",
-			"bundler": s.bundler.Signer.Address,
-		}
-		data, _ := json.Marshal(mmap)
-		_, err = s.everpaySdk.Transfer(tokBal.Tag, amt, collectAddr, string(data))
-		if err != nil {
-			log.Error("s.everpaySdk.Transfer(tokBal.Tag,amt,collectAddr,\"\")", "err", err)
-		}
-		time.Sleep(5 * time.Second)
+		ordArr = append(ordArr, ord)
 	}
+	return ordArr, nil
 }
 
 func (s *Arseeding) refundReceipt() {
@@ -552,54 +545,51 @@ func (s *Arseeding) refundReceipt() {
 	}
 
 	for _, rpt := range recpts {
-		// update rpt status is refund
-		if err := s.wdb.UpdateReceiptStatus(rpt.RawId, schema.Refund, nil); err != nil {
-			log.Error("s.wdb.UpdateReceiptStatus(rpt.ID,schema.Refund,nil)", "err", err, "id", rpt.RawId)
+		// update rpt status is refund, the receipt may be handled by admin at the same time
+		ok, err := s.wdb.LockReceiptStatus(rpt.RawId, []string{schema.UnRefund}, schema.Refund, nil)
+		if err != nil || !ok {
+			log.Error("s.wdb.LockReceiptStatus(rpt.RawId,schema.Refund)", "err", err, "id", rpt.RawId)
 			continue
 		}
-		// send everTx transfer for refund
-		amount, ok := new(big.Int).SetString(rpt.Amount, 10)
-		if !ok {
-			log.Error("new(big.Int).SetString(rpt.Amount,10) failed", "amt", rpt.Amount)
-			continue
-		}
-		// everTx data
-		mmap := map[string]string{
-			"appName":        "arseeding",
-			"action":         "refund",
-			"refundEverHash": rpt.EverHash,
-		}
-		data, _ := json.Marshal(mmap)
-		everTx, err := s.everpaySdk.Transfer(rpt.TokenTag, amount, rpt.From, string(data))
-		if err != nil { // notice: if refund failed, then need manual check and refund
-			log.Error("s.everpaySdk.Transfer", "err", err)
-			// update receipt status is unrefund
-			if err := s.wdb.UpdateRefundErr(rpt.RawId, err.Error()); err != nil {
-				log.Error("s.wdb.UpdateRefundErr(rpt.RawId, err.Error())", "err", err, "id", rpt.RawId)
+		provider, err := s.getPaymentProvider(rpt.Provider)
+		if err == nil {
+			var refundHash string
+			refundHash, err = provider.Refund(rpt)
+			if err == nil {
+				log.Info("refund receipt success...", "provider", rpt.Provider, "receipt everHash", rpt.EverHash, "refund hash", refundHash)
+				if err = s.wdb.UpdateRefundHash(rpt.RawId, refundHash); err != nil {
+					log.Error("s.wdb.UpdateRefundHash(rpt.RawId, refundHash)", "err", err, "id", rpt.RawId)
+				}
+				continue
 			}
-			continue
 		}
-		log.Info("refund receipt success...", "receipt everHash", rpt.EverHash, "refund everHash", everTx.HexHash())
+		// notice: if refund failed, then need manual check and refund
+		log.Error("provider.Refund(rpt)", "err", err, "provider", rpt.Provider, "id", rpt.RawId)
+		if err := s.wdb.UpdateRefundErr(rpt.RawId, err.Error()); err != nil {
+			log.Error("s.wdb.UpdateRefundErr(rpt.RawId, err.Error())", "err", err, "id", rpt.RawId)
+		}
 	}
 }
 
 func (s *Arseeding) onChainBundleItems() {
-	ords, err := s.wdb.GetNeedOnChainOrders()
+	policy := s.bundlePackingPolicy()
+	ords, err := s.wdb.GetNeedOnChainOrders(policy.OrderBy, time.Now().Add(-policy.MaxWait))
 	if err != nil {
-		log.Error("s.wdb.GetNeedOnChainOrders()", "err", err)
+		log.Error("s.wdb.GetNeedOnChainOrders(policy.OrderBy, overdueTime)", "err", err)
 		return
 	}
+	ords = s.packOrders(ords, policy)
 	if len(ords) == 0 {
 		return
 	}
 	// send arTx to arweave
-	arTx, onChainItemIds, err := s.onChainOrds(ords)
+	arTx, onChainItemIds, err := s.onChainOrds(ords, schema.LaneStandard)
 	if err != nil {
-		log.Error("s.onChainOrds()", "err", err)
+		log.Error("s.onChainOrds(ords, schema.LaneStandard)", "err", err)
 		return
 	}
 
-	s.updateOnChainInfo(onChainItemIds, arTx, schema.PendingOnChain)
+	s.updateOnChainInfo(onChainItemIds, arTx, schema.PendingOnChain, schema.LaneStandard)
 
 }
 
@@ -613,7 +603,7 @@ func (s *Arseeding) onChainItemsBySeq() {
 	if len(ords) == 0 {
 		return
 	}
-	arTx, onChainItemIds, err := s.onChainOrds(ords)
+	arTx, onChainItemIds, err := s.onChainOrds(ords, schema.LaneStandard)
 	if err != nil {
 		return
 	}
@@ -625,10 +615,10 @@ func (s *Arseeding) onChainItemsBySeq() {
 		return
 	}
 
-	s.updateOnChainInfo(onChainItemIds, arTx, schema.SuccOnChain)
+	s.updateOnChainInfo(onChainItemIds, arTx, schema.SuccOnChain, schema.LaneStandard)
 }
 
-func (s *Arseeding) onChainOrds(ords []schema.Order) (arTx types.Transaction, onChainItemIds []string, err error) {
+func (s *Arseeding) onChainOrds(ords []schema.Order, lane string) (arTx types.Transaction, onChainItemIds []string, err error) {
 	// once total size limit 2 GB
 	itemIds := make([]string, 0, len(ords))
 	totalSize := int64(0)
@@ -644,21 +634,21 @@ func (s *Arseeding) onChainOrds(ords []schema.Order) (arTx types.Transaction, on
 			continue
 		}
 		itemIds = append(itemIds, ord.ItemId)
-		totalSize += od.Size
+		totalSize += ord.Size
 	}
 
 	// send arTx to arweave
-	return s.onChainBundleTx(itemIds)
+	return s.onChainBundleTx(itemIds, lane)
 }
 
-func (s *Arseeding) updateOnChainInfo(onChainItemIds []string, arTx types.Transaction, onChainStatus string) {
-	// insert arTx record
+func (s *Arseeding) updateOnChainInfo(onChainItemIds []string, arTx types.Transaction, onChainStatus, lane string) {
 	onChainItemIdsJs, err := json.Marshal(onChainItemIds)
 	if err != nil {
 		log.Error("json.Marshal(itemIds)", "err", err, "onChainItemIds", onChainItemIds)
 		return
 	}
-	if err = s.wdb.InsertArTx(schema.OnChainTx{
+	// insert arTx record and update order onChainStatus
+	if err = s.wdb.InsertBundleArTx(schema.OnChainTx{
 		ArId:      arTx.ID,
 		CurHeight: s.cache.GetInfo().Height,
 		DataSize:  arTx.DataSize,
@@ -666,16 +656,10 @@ func (s *Arseeding) updateOnChainInfo(onChainItemIds []string, arTx types.Transa
 		Status:    schema.PendingOnChain,
 		ItemIds:   onChainItemIdsJs,
 		ItemNum:   len(onChainItemIds),
-	}); err != nil {
-		log.Error("s.wdb.InsertArTx", "err", err)
-		return
-	}
-
-	// update order onChainStatus to pending
-	for _, itemId := range onChainItemIds {
-		if err = s.wdb.UpdateOrdOnChainStatus(itemId, onChainStatus, nil); err != nil {
-			log.Error("s.wdb.UpdateOrdOnChainStatus(item.Id,schema.PendingOnChain)", "err", err, "itemId", itemId)
-		}
+		Bundler:   arTxBundler(arTx),
+		Lane:      lane,
+	}, onChainItemIds, onChainStatus); err != nil {
+		log.Error("s.wdb.InsertBundleArTx", "err", err, "arId", arTx.ID)
 	}
 }
 
@@ -742,18 +726,22 @@ func (s *Arseeding) retryOnChainArTx() {
 		if err = json.Unmarshal(tx.ItemIds, &itemIds); err != nil {
 			return
 		}
-		arTx, _, err := s.onChainBundleTx(itemIds)
+		lane := tx.Lane
+		if lane == "" {
+			lane = schema.LaneStandard
+		}
+		arTx, _, err := s.onChainBundleTx(itemIds, lane)
 		if err != nil {
 			return
 		}
 		// update onChain
-		if err = s.wdb.UpdateArTx(tx.ID, arTx.ID, s.cache.GetInfo().Height, arTx.DataSize, arTx.Reward, schema.PendingOnChain); err != nil {
+		if err = s.wdb.UpdateArTx(tx.ID, arTx.ID, s.cache.GetInfo().Height, arTx.DataSize, arTx.Reward, schema.PendingOnChain, arTxBundler(arTx)); err != nil {
 			log.Error("s.wdb.UpdateArTx", "err", err, "id", tx.ID, "arId", arTx.ID)
 		}
 	}
 }
 
-func (s *Arseeding) onChainBundleTx(itemIds []string) (arTx types.Transaction, onChainItemIds []string, err error) {
+func (s *Arseeding) onChainBundleTx(itemIds []string, lane string) (arTx types.Transaction, onChainItemIds []string, err error) {
 	onChainItems := make([]types.BundleItem, 0)
 	bundle := &types.Bundle{}
 	verifyBundle := &types.Bundle{}
@@ -822,31 +810,9 @@ func (s *Arseeding) onChainBundleTx(itemIds []string) (arTx types.Transaction, o
 		onChainItemIds = append(onChainItemIds, item.Id)
 	}
 
-	arTxtags := []types.Tag{
-		{Name: "App-Name", Value: "arseeding"},
-		{Name: "App-Version", Value: "1.0.0"},
-		{Name: "Action", Value: "Bundle"},
-		{Name: "Protocol-Name", Value: "U"},
-		{Name: "Action", Value: "Burn"},
-		{Name: "App-Name", Value: "SmartWeaveAction"},
-		{Name: "App-Version", Value: "0.3.0"},
-		{Name: "Input", Value: `{"function":"mint"}`},
-		{Name: "Contract", Value: "KTzTXT_ANmF84fWEKHzWURD1LWd9QaFR9yfYUwH2Lxw"},
-	}
-
-	if len(s.customTags) > 0 {
-		arTxtags = append(s.customTags, arTxtags...)
-	}
-
-	// speed arTx Fee
-
-	concurrentNum := s.config.Param.ChunkConcurrentNum
-	if len(bundle.BundleBinary) > 0 {
-		log.Debug("use binary submit bundle arTx", "binary length:", len(bundle.BundleBinary))
-		price := calculatePrice(s.cache.GetFee(), int64(len(bundle.BundleBinary)))
-		speedFactor := calculateFactor(price, s.config.GetSpeedFee())
-		arTx, err = s.bundler.SendBundleTxSpeedUp(context.TODO(), concurrentNum, bundle.BundleBinary, arTxtags, speedFactor)
-	} else {
+	// bundle size
+	bundleSize := int64(len(bundle.BundleBinary))
+	if bundleSize == 0 {
 		fileInfo, err1 := bundle.BundleDataReader.Stat()
 		if err1 != nil {
 			err = err1
@@ -856,14 +822,42 @@ func (s *Arseeding) onChainBundleTx(itemIds []string) (arTx types.Transaction, o
 			err = errors.New("bundle.BundleDataReader is null")
 			return
 		}
-		price := calculatePrice(s.cache.GetFee(), fileInfo.Size())
-		speedFactor := calculateFactor(price, s.config.GetSpeedFee())
-		arTx, err = s.bundler.SendBundleTxSpeedUp(context.TODO(), concurrentNum, bundle.BundleDataReader, arTxtags, speedFactor)
+		bundleSize = fileInfo.Size()
+	}
+
+	// speed arTx Fee
+	concurrentNum := s.config.Param.ChunkConcurrentNum
+	price := calculatePrice(s.cache.GetFee(), bundleSize)
+	speedFactor := s.bundleSpeedFactor(price, lane)
+
+	// select the bundler wallet of pool
+	reward := new(big.Int).Div(big.NewInt(price*(100+speedFactor)), big.NewInt(100))
+	pendings, err := s.wdb.GetPendingArTxNums()
+	if err != nil {
+		log.Error("s.wdb.GetPendingArTxNums()", "err", err)
+		return
+	}
+	bundler, err := s.bundlerPool.acquire(reward, pendings)
+	if err != nil {
+		log.Error("s.bundlerPool.acquire(reward, pendings)", "err", err, "reward", reward)
+		return
+	}
+
+	// the {bundler} tag is the wallet which signs the arTx
+	arTxtags := s.bundleTags(len(onChainItemIds), bundleSize, lane, bundler.Signer.Address)
+	if len(bundle.BundleBinary) > 0 {
+		log.Debug("use binary submit bundle arTx", "binary length:", len(bundle.BundleBinary))
+		arTx, err = bundler.SendBundleTxSpeedUp(context.TODO(), concurrentNum, bundle.BundleBinary, arTxtags, speedFactor)
+	} else {
+		arTx, err = bundler.SendBundleTxSpeedUp(context.TODO(), concurrentNum, bundle.BundleDataReader, arTxtags, speedFactor)
 	}
 	if err != nil {
-		log.Error("s.bundler.SendBundleTxSpeedUp(bundle.BundleBinary,arTxtags)", "err", err)
+		s.bundlerPool.release(bundler.Signer.Address, nil)
+		log.Error("bundler.SendBundleTxSpeedUp(bundle.BundleBinary,arTxtags)", "err", err, "bundler", bundler.Signer.Address)
 		return
 	}
+	spent, _ := new(big.Int).SetString(arTx.Reward, 10)
+	s.bundlerPool.release(bundler.Signer.Address, spent)
 	log.Info("Send bundle arTx", "arTx", arTx.ID)
 
 	// arseeding broadcast tx data
@@ -962,16 +956,8 @@ func (s *Arseeding) processExpiredOrd() {
 		if !s.wdb.IsLatestUnpaidOrd(ord.ItemId, ord.PaymentExpiredTime) {
 			continue
 		}
-		// delete bundle item from store
-		if err = s.DelItem(ord.ItemId); err != nil {
-			log.Error("DelItem", "err", err, "itemId", ord.ItemId)
-			continue
-		}
-		// delete manifest table
-		if err = s.wdb.DelManifest(ord.ItemId); err != nil {
-			log.Error("s.wdb.DelManifest", "err", err, "itemId", ord.ItemId)
-			continue
-		}
+		// delete bundle item from store and manifest table
+		s.delOrderItem(ord.ItemId)
 	}
 }
 
@@ -1007,13 +993,17 @@ func (s *Arseeding) parseAndSaveBundleTx() {
 }
 
 func (s *Arseeding) updateBundler() {
-	// update bundler balance
-	addr := s.bundler.Signer.Address
-	bal, err := s.arCli.GetWalletBalance(addr)
-	if err != nil {
-		return
+	// update the balance of bundler wallets
+	for _, w := range s.bundlerPool.getWallets() {
+		addr := w.Signer.Address
+		bal, err := s.arCli.GetWalletBalance(addr)
+		if err != nil {
+			log.Error("s.arCli.GetWalletBalance(addr)", "err", err, "addr", addr)
+			continue
+		}
+		metricBundlerBalance(bal, addr)
+		s.bundlerPool.setBalance(addr, utils.ARToWinston(bal))
 	}
-	metricBundlerBalance(bal, addr)
 }
 
 func (s *Arseeding) deleteTmpFile() {
@@ -1027,6 +1017,54 @@ func (s *Arseeding) deleteTmpFile() {
 	}
 }
 
+func (s *Arseeding) processExpiredUploadSession() {
+	sessions, err := s.wdb.GetExpiredUploadSessions()
+	if err != nil {
+		log.Error("s.wdb.GetExpiredUploadSessions()", "err", err)
+		return
+	}
+	for _, session := range sessions {
+		// the finalizing session whose item has been submitted is finalized
+		status, itemId := schema.SessionExpired, ""
+		if session.Status == schema.SessionFinalizing && len(session.ItemId) > 0 {
+			ords, err := s.wdb.GetOrdersByItemIds([]string{session.ItemId})
+			if err != nil {
+				log.Error("s.wdb.GetOrdersByItemIds([]string{session.ItemId})", "err", err, "sessionId", session.SessionId)
+				continue
+			}
+			if len(ords) > 0 {
+				status, itemId = schema.SessionFinalized, session.ItemId
+			}
+		}
+		if err = s.wdb.UpdateUploadSession(session.SessionId, status, itemId); err != nil {
+			log.Error("s.wdb.UpdateUploadSession(session.SessionId, status, itemId)", "err", err, "sessionId", session.SessionId, "status", status)
+			continue
+		}
+		// delete upload parts
+		if err = os.RemoveAll(uploadSessionDir(session.SessionId)); err != nil {
+			log.Error("os.RemoveAll(uploadSessionDir(session.SessionId))", "err", err, "sessionId", session.SessionId)
+		}
+	}
+}
+
+func (s *Arseeding) delExpiredIdempotencyRecords() {
+	if err := s.wdb.DelExpiredIdempotencyRecords(); err != nil {
+		log.Error("s.wdb.DelExpiredIdempotencyRecords()", "err", err)
+	}
+}
+
+func (s *Arseeding) delExpiredFeeQuotes() {
+	if err := s.wdb.DelExpiredFeeQuotes(); err != nil {
+		log.Error("s.wdb.DelExpiredFeeQuotes()", "err", err)
+	}
+}
+
+func (s *Arseeding) delExpiredAuth() {
+	if err := s.wdb.DelExpiredAuth(); err != nil {
+		log.Error("s.wdb.DelExpiredAuth()", "err", err)
+	}
+}
+
 func filterPeers(peers []string, constTx *types.Transaction) map[string]bool {
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -1178,6 +1216,31 @@ func (s *Arseeding) ProduceDailyStatistic() {
 	}
 }
 
+// ProduceBillingStatements produce the statements of last month for all the apikeys with ledger entries
+func (s *Arseeding) ProduceBillingStatements() {
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	month := usageMonth(thisMonth.AddDate(0, -1, 0))
+	addrs, err := s.wdb.GetLedgerAddresses(thisMonth)
+	if err != nil {
+		log.Error("s.wdb.GetLedgerAddresses(thisMonth)", "err", err)
+		return
+	}
+	for _, addr := range addrs {
+		if _, err := s.wdb.GetBillingStatement(addr, month); err == nil {
+			continue
+		}
+		st, err := s.BuildBillingStatement(addr, month)
+		if err != nil {
+			log.Error("s.BuildBillingStatement(addr, month)", "err", err, "address", addr, "month", month)
+			continue
+		}
+		if err = s.wdb.InsertBillingStatement(*st); err != nil {
+			log.Error("s.wdb.InsertBillingStatement(*st)", "err", err, "address", addr, "month", month)
+		}
+	}
+}
+
 func (s *Arseeding) broadcastItemToKafka() {
 	kafkaOrdInfos, err := s.wdb.GetKafkaOrderInfos()
 	if err != nil {
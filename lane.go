@@ -0,0 +1,91 @@
+package arseeding
+
+import (
+	"fmt"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/shopspring/decimal"
+	"strings"
+)
+
+func (s *Arseeding) expressSurcharge() int64 {
+	if s.config.Param.ExpressSurcharge > 0 {
+		return s.config.Param.ExpressSurcharge
+	}
+	return schema.DefaultExpressSurcharge
+}
+
+func (s *Arseeding) expressSpeedFactor() int64 {
+	if s.config.Param.ExpressSpeedFactor > 0 {
+		return s.config.Param.ExpressSpeedFactor
+	}
+	return schema.DefaultExpressSpeedFactor
+}
+
+// parseLane empty lane means the standard lane
+func parseLane(lane string) (string, error) {
+	switch strings.ToLower(lane) {
+	case "", schema.LaneStandard:
+		return schema.LaneStandard, nil
+	case schema.LaneExpress:
+		return schema.LaneExpress, nil
+	default:
+		return "", fmt.Errorf("lane must be %s or %s", schema.LaneStandard, schema.LaneExpress)
+	}
+}
+
+// calcExpressFee add the surcharge to fee, round up to integer
+func calcExpressFee(fee decimal.Decimal, surcharge int64) decimal.Decimal {
+	return fee.Mul(decimal.NewFromInt(100 + surcharge)).Div(decimal.NewFromInt(100)).Ceil()
+}
+
+// quoteExpressFee set the express fee of respFee, so both lanes are quoted
+func (s *Arseeding) quoteExpressFee(respFee *schema.RespFee) error {
+	fee, err := decimal.NewFromString(respFee.FinalFee)
+	if err != nil {
+		return err
+	}
+	expressFee := calcExpressFee(fee, s.expressSurcharge())
+	respFee.ExpressFee = expressFee.String()
+	respFee.ExpressUsdFee = calcUsdFee(expressFee, respFee.Decimals, respFee.UsdPrice)
+	return nil
+}
+
+// applyLane the express order is charged the express fee
+func (s *Arseeding) applyLane(respFee *schema.RespFee, lane string) (*schema.RespFee, error) {
+	if lane != schema.LaneExpress {
+		return respFee, nil
+	}
+	if err := s.quoteExpressFee(respFee); err != nil {
+		return nil, err
+	}
+	res := *respFee
+	res.FinalFee = respFee.ExpressFee
+	res.UsdFee = respFee.ExpressUsdFee
+	return &res, nil
+}
+
+// bundleSpeedFactor the express bundle is sent with the speed factor not less than the express speed factor
+func (s *Arseeding) bundleSpeedFactor(price int64, lane string) int64 {
+	speedFactor := calculateFactor(price, s.config.GetSpeedFee())
+	if lane == schema.LaneExpress && speedFactor < s.expressSpeedFactor() {
+		speedFactor = s.expressSpeedFactor()
+	}
+	return speedFactor
+}
+
+func (s *Arseeding) onChainExpressItems() {
+	ords, err := s.wdb.GetNeedOnChainExpressOrders()
+	if err != nil {
+		log.Error("s.wdb.GetNeedOnChainExpressOrders()", "err", err)
+		return
+	}
+	if len(ords) == 0 {
+		return
+	}
+	arTx, onChainItemIds, err := s.onChainOrds(ords, schema.LaneExpress)
+	if err != nil {
+		log.Error("s.onChainOrds(ords, schema.LaneExpress)", "err", err)
+		return
+	}
+	s.updateOnChainInfo(onChainItemIds, arTx, schema.PendingOnChain, schema.LaneExpress)
+}
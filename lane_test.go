@@ -0,0 +1,70 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/config"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar/types"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestExpressLane(t *testing.T) {
+	sqliteDir := "./data/lane"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	aa := &Arseeding{
+		wdb:    wdb,
+		config: &config.Config{},
+		bundlePerFeeMap: map[string]schema.Fee{
+			"AR": {Currency: "AR", Base: decimal.New(5, 0), PerChunk: decimal.New(1, 0)},
+		},
+	}
+
+	lane, err := parseLane("")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.LaneStandard, lane)
+	lane, err = parseLane("Express")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.LaneExpress, lane)
+	_, err = parseLane("fast")
+	assert.Error(t, err)
+
+	// default surcharge 50%, round up
+	fee, err := aa.calcOrderFee("AR", types.MAX_CHUNK_SIZE, "", "", "", schema.LaneExpress)
+	assert.NoError(t, err)
+	assert.Equal(t, "9", fee.FinalFee)
+	fee, err = aa.calcOrderFee("AR", types.MAX_CHUNK_SIZE, "", "", "", schema.LaneStandard)
+	assert.NoError(t, err)
+	assert.Equal(t, "6", fee.FinalFee)
+
+	// both lanes are quoted
+	aa.config.Param.ExpressSurcharge = 100
+	quote, err := aa.CreateFeeQuote("AR", 2*types.MAX_CHUNK_SIZE, "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223")
+	assert.NoError(t, err)
+	assert.Equal(t, "7", quote.FinalFee)
+	assert.Equal(t, "14", quote.ExpressFee)
+	fee, err = aa.calcOrderFee("AR", types.MAX_CHUNK_SIZE, "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", quote.QuoteId, "item-0", schema.LaneExpress)
+	assert.NoError(t, err)
+	assert.Equal(t, "12", fee.FinalFee)
+
+	// speed factor of express bundle
+	assert.Equal(t, int64(0), aa.bundleSpeedFactor(1000, schema.LaneStandard))
+	assert.Equal(t, schema.DefaultExpressSpeedFactor, aa.bundleSpeedFactor(1000, schema.LaneExpress))
+
+	// the express orders are bundled by the express lane only
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-1", PaymentStatus: schema.SuccPayment, OnChainStatus: schema.WaitOnChain}))
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-2", PaymentStatus: schema.SuccPayment, OnChainStatus: schema.WaitOnChain, Lane: schema.LaneExpress}))
+	ords, err := wdb.GetNeedOnChainOrders(schema.BundleOrderByAge, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ords))
+	assert.Equal(t, "item-1", ords[0].ItemId)
+	assert.Equal(t, schema.LaneStandard, ords[0].Lane)
+	ords, err = wdb.GetNeedOnChainExpressOrders()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ords))
+	assert.Equal(t, "item-2", ords[0].ItemId)
+}
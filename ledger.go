@@ -0,0 +1,227 @@
+package arseeding
+
+import (
+	"errors"
+	"fmt"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// postLedgerEntry must be called in a db transaction, the apikey row is locked until the transaction end.
+// the apikey TokenBalance is the balance derived from all the ledger entries.
+// fee is the fee charged by a debit and records the conversion rates, it is nil for the other entries
+func postLedgerEntry(wdb *Wdb, address, currency, entryType string, amount decimal.Decimal, reference, subKey string, fee *schema.RespFee, dbTx *gorm.DB) (*schema.LedgerEntry, error) {
+	if amount.IsNegative() {
+		return nil, errors.New("ledger amount can not be negative")
+	}
+	apikey, err := wdb.GetApikeyForUpdate(address, dbTx)
+	if err != nil {
+		return nil, err
+	}
+	currency = strings.ToUpper(currency)
+	apikeyAccount := schema.LedgerAccountApikey + address
+
+	balDe := decimal.Zero
+	bal, ok := apikey.TokenBalance[currency]
+	if ok {
+		if balDe, err = decimal.NewFromString(bal.(string)); err != nil {
+			return nil, err
+		}
+	}
+
+	entry := &schema.LedgerEntry{
+		Address:   address,
+		Currency:  currency,
+		Type:      entryType,
+		Amount:    amount.String(),
+		Reference: reference,
+		SubKey:    subKey,
+	}
+	if fee != nil {
+		entry.UsdPrice = decimal.NewFromFloat(fee.UsdPrice).String()
+		entry.ArPrice = decimal.NewFromFloat(fee.ArPrice).String()
+	}
+	switch entryType {
+	case schema.LedgerCredit:
+		entry.DebitAccount, entry.CreditAccount = schema.LedgerAccountDeposit, apikeyAccount
+		balDe = balDe.Add(amount)
+	case schema.LedgerRefund:
+		entry.DebitAccount, entry.CreditAccount = schema.LedgerAccountRevenue, apikeyAccount
+		balDe = balDe.Add(amount)
+	case schema.LedgerDebit:
+		if !ok {
+			return nil, errors.New("apiKey not the currency balance")
+		}
+		entry.DebitAccount, entry.CreditAccount = apikeyAccount, schema.LedgerAccountRevenue
+		balDe = balDe.Sub(amount)
+		if balDe.IsNegative() {
+			// balance is insufficient
+			return nil, errors.New("balance is insufficient")
+		}
+	default:
+		return nil, fmt.Errorf("not support ledger entry type: %s", entryType)
+	}
+	entry.Balance = balDe.String()
+
+	tokBalMap := make(map[string]interface{})
+	for k, v := range apikey.TokenBalance {
+		tokBalMap[k] = v
+	}
+	tokBalMap[currency] = entry.Balance
+	if err = wdb.UpdateApikeyTokenBal(address, tokBalMap, dbTx); err != nil {
+		return nil, err
+	}
+	if err = wdb.InsertLedgerEntry(entry, dbTx); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// postOpeningBalances post a credit entry for the apikey balance which was deposited before the ledger existed,
+// the currency already has ledger entries is skipped, so the entries are only posted once
+func postOpeningBalances(wdb *Wdb) error {
+	cursorId := uint(0)
+	for {
+		apikeys, err := wdb.GetApiKeys(cursorId, 500)
+		if err != nil {
+			return err
+		}
+		if len(apikeys) == 0 {
+			return nil
+		}
+		cursorId = apikeys[len(apikeys)-1].ID
+
+		for _, apikey := range apikeys {
+			if len(apikey.TokenBalance) == 0 {
+				continue
+			}
+			lastEntries, err := wdb.GetLastLedgerEntries(apikey.Address, time.Now())
+			if err != nil {
+				return err
+			}
+			posted := make(map[string]bool)
+			for _, entry := range lastEntries {
+				posted[strings.ToUpper(entry.Currency)] = true
+			}
+			for currency, bal := range apikey.TokenBalance {
+				currency = strings.ToUpper(currency)
+				balStr, ok := bal.(string)
+				if !ok || posted[currency] {
+					continue
+				}
+				balDe, err := decimal.NewFromString(balStr)
+				if err != nil {
+					return err
+				}
+				if !balDe.IsPositive() {
+					continue
+				}
+				entry := &schema.LedgerEntry{
+					CreatedAt:     apikey.UpdatedAt, // the balance is not changed since the apikey updated
+					Address:       apikey.Address,
+					Currency:      currency,
+					Type:          schema.LedgerCredit,
+					DebitAccount:  schema.LedgerAccountOpening,
+					CreditAccount: schema.LedgerAccountApikey + apikey.Address,
+					Amount:        balDe.String(),
+					Balance:       balDe.String(),
+					Reference:     schema.LedgerOpeningReference,
+				}
+				if err = wdb.InsertLedgerEntry(entry, nil); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// postLedgerEntryTx post the ledger entry in a new db transaction
+func postLedgerEntryTx(wdb *Wdb, address, currency, entryType string, amount decimal.Decimal, reference string, fee *schema.RespFee) (*schema.LedgerEntry, error) {
+	dbTx := wdb.Db.Begin()
+	entry, err := postLedgerEntry(wdb, address, currency, entryType, amount, reference, "", fee, dbTx)
+	if err != nil {
+		dbTx.Rollback()
+		return nil, err
+	}
+	return entry, dbTx.Commit().Error
+}
+
+// postSubKeyDebit debit the parent apikey balance and accumulate the sub-key spent in one db transaction
+func postSubKeyDebit(wdb *Wdb, subKey, currency string, amount decimal.Decimal, reference string, fee *schema.RespFee) (*schema.LedgerEntry, error) {
+	dbTx := wdb.Db.Begin()
+	entry, err := updateSubKeySpent(wdb, subKey, currency, amount, reference, schema.LedgerDebit, fee, dbTx)
+	if err != nil {
+		dbTx.Rollback()
+		return nil, err
+	}
+	return entry, dbTx.Commit().Error
+}
+
+func updateSubKeySpent(wdb *Wdb, subKey, currency string, amount decimal.Decimal, reference, entryType string, fee *schema.RespFee, dbTx *gorm.DB) (*schema.LedgerEntry, error) {
+	sk, err := wdb.GetApiSubKeyForUpdate(subKey, dbTx)
+	if err != nil {
+		return nil, err
+	}
+	currency = strings.ToUpper(currency)
+	spentDe := decimal.Zero
+	if spent, ok := sk.Spent[currency]; ok {
+		if spentDe, err = decimal.NewFromString(spent.(string)); err != nil {
+			return nil, err
+		}
+	}
+
+	if entryType == schema.LedgerDebit {
+		spentDe = spentDe.Add(amount)
+		if spendCap, ok := sk.SpendCap[currency]; ok {
+			capDe, err := decimal.NewFromString(spendCap.(string))
+			if err != nil {
+				return nil, err
+			}
+			if spentDe.GreaterThan(capDe) {
+				return nil, fmt.Errorf("sub-key %s exceeds the spend cap: %s", sk.Name, capDe.String())
+			}
+		}
+	} else {
+		spentDe = decimal.Max(spentDe.Sub(amount), decimal.Zero)
+	}
+
+	entry, err := postLedgerEntry(wdb, sk.Address, currency, entryType, amount, reference, sk.Name, fee, dbTx)
+	if err != nil {
+		return nil, err
+	}
+	spentMap := make(map[string]interface{})
+	for k, v := range sk.Spent {
+		spentMap[k] = v
+	}
+	spentMap[currency] = spentDe.String()
+	if err = wdb.UpdateApiSubKeySpent(subKey, spentMap, dbTx); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// refundLedgerEntry return the debit amount to the apikey, and reduce the sub-key spent if the debit is spent by sub-key
+func refundLedgerEntry(wdb *Wdb, debit *schema.LedgerEntry) error {
+	amount, err := decimal.NewFromString(debit.Amount)
+	if err != nil {
+		return err
+	}
+	if debit.SubKey == "" {
+		_, err = postLedgerEntryTx(wdb, debit.Address, debit.Currency, schema.LedgerRefund, amount, debit.Reference, nil)
+		return err
+	}
+
+	sk, err := wdb.GetApiSubKeyByName(debit.Address, debit.SubKey)
+	if err != nil {
+		return err
+	}
+	dbTx := wdb.Db.Begin()
+	if _, err = updateSubKeySpent(wdb, sk.SubKey, debit.Currency, amount, debit.Reference, schema.LedgerRefund, nil, dbTx); err != nil {
+		dbTx.Rollback()
+		return err
+	}
+	return dbTx.Commit().Error
+}
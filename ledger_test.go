@@ -0,0 +1,75 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/schema"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestPostLedgerEntry(t *testing.T) {
+	sqliteDir := "./data/ledger"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+
+	addr := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	assert.NoError(t, wdb.InsertApiKey(schema.AutoApiKey{ApiKey: "apikey-test", Address: addr, TokenBalance: map[string]interface{}{}}))
+
+	_, err := postLedgerEntryTx(wdb, addr, "usdc", schema.LedgerDebit, decimal.NewFromInt(10), "item-1", nil)
+	assert.Error(t, err) // no currency balance
+
+	_, err = postLedgerEntryTx(wdb, addr, "usdc", schema.LedgerCredit, decimal.NewFromInt(100), "everHash-1", nil)
+	assert.NoError(t, err)
+	debit, err := postLedgerEntryTx(wdb, addr, "usdc", schema.LedgerDebit, decimal.NewFromInt(60), "item-1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "40", debit.Balance)
+	assert.Equal(t, schema.LedgerAccountApikey+addr, debit.DebitAccount)
+	assert.Equal(t, schema.LedgerAccountRevenue, debit.CreditAccount)
+
+	_, err = postLedgerEntryTx(wdb, addr, "usdc", schema.LedgerDebit, decimal.NewFromInt(60), "item-2", nil)
+	assert.Error(t, err) // balance is insufficient
+
+	assert.NoError(t, refundLedgerEntry(wdb, debit))
+	apikey, err := wdb.GetApiKeyDetail("apikey-test")
+	assert.NoError(t, err)
+	assert.Equal(t, "100", apikey.TokenBalance["USDC"])
+
+	entries, err := wdb.GetLedgerEntries(addr, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, schema.LedgerRefund, entries[0].Type)
+	assert.Equal(t, "item-1", entries[0].Reference)
+	assert.Equal(t, schema.LedgerCredit, entries[2].Type)
+
+	entries, err = wdb.GetLedgerEntries(addr, int64(entries[1].ID), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(entries))
+}
+
+func TestPostOpeningBalances(t *testing.T) {
+	sqliteDir := "./data/ledger-opening"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+
+	// the apikey is funded before the ledger existed
+	addr := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	assert.NoError(t, wdb.InsertApiKey(schema.AutoApiKey{ApiKey: "apikey-test", Address: addr, TokenBalance: map[string]interface{}{"USDC": "100", "AR": "0"}}))
+	assert.NoError(t, postOpeningBalances(wdb))
+	entries, err := wdb.GetLedgerEntries(addr, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, schema.LedgerOpeningReference, entries[0].Reference)
+	assert.Equal(t, schema.LedgerAccountOpening, entries[0].DebitAccount)
+	assert.Equal(t, "100", entries[0].Balance)
+
+	// only posted once
+	_, err = postLedgerEntryTx(wdb, addr, "usdc", schema.LedgerDebit, decimal.NewFromInt(60), "item-1", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, postOpeningBalances(wdb))
+	entries, err = wdb.GetLedgerEntries(addr, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(entries))
+}
@@ -1,7 +1,10 @@
 package arseeding
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/base32"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/everFinance/arseeding/schema"
@@ -61,7 +64,7 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Sort, sort, X-API-KEY, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Sort, sort, X-API-KEY, X-UPLOAD-TOKEN, X-FEE-QUOTE, Idempotency-Key, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, HEAD")
 
 		if c.Request.Method == "OPTIONS" {
@@ -73,6 +76,201 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
+// SessionAuthMiddleware check the session token in Authorization header, the request without the header is passed
+func SessionAuthMiddleware(s *Arseeding) gin.HandlerFunc {
+	return sessionAuth(s, false)
+}
+
+// RequireSessionMiddleware same as SessionAuthMiddleware, but the request without the header is rejected
+func RequireSessionMiddleware(s *Arseeding) gin.HandlerFunc {
+	return sessionAuth(s, true)
+}
+
+func sessionAuth(s *Arseeding, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := c.GetHeader("Authorization")
+		if len(auth) == 0 {
+			if required {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, schema.RespErr{Err: "Authorization can not be null"})
+				return
+			}
+			c.Next()
+			return
+		}
+		session, err := s.CheckAuthSession(strings.TrimPrefix(auth, "Bearer "))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, schema.RespErr{Err: err.Error()})
+			return
+		}
+		c.Set("authAddress", session.Address)
+		c.Next()
+	}
+}
+
+// AdminMiddleware must be used after RequireSessionMiddleware, the session address must be an admin
+func AdminMiddleware(s *Arseeding) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authAddr := c.GetString("authAddress")
+		if len(authAddr) == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, schema.RespErr{Err: "Authorization can not be null"})
+			return
+		}
+		if !s.config.IsAdmin(authAddr) {
+			c.AbortWithStatusJSON(http.StatusForbidden, schema.RespErr{Err: "not admin"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkAuthAddress the session address must be the queried address
+func checkAuthAddress(c *gin.Context, addr string) bool {
+	authAddr := c.GetString("authAddress")
+	if len(authAddr) == 0 {
+		c.JSON(http.StatusUnauthorized, schema.RespErr{Err: "Authorization can not be null"})
+		return false
+	}
+	if strings.EqualFold(authAddr, addr) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, schema.RespErr{Err: "session not match the address"})
+	return false
+}
+
+// IdempotencyMiddleware the retries of a succeed request with the same Idempotency-Key and body return the original response,
+// the key is scoped by the upload token if the request is paid by it, otherwise by the apikey
+func IdempotencyMiddleware(s *Arseeding) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader("Idempotency-Key")
+		if len(idempotencyKey) == 0 {
+			c.Next()
+			return
+		}
+		// the invalid apikey or upload token is rejected by the handler, no record is kept
+		scope := ""
+		if uploadToken := c.GetHeader("X-UPLOAD-TOKEN"); len(uploadToken) > 0 {
+			if _, err := s.wdb.GetUploadToken(uploadToken); err == nil {
+				scope = uploadToken
+			}
+		} else if apiKey := c.GetHeader("X-API-KEY"); len(apiKey) > 0 {
+			if _, _, err := s.checkApiKey(apiKey); err == nil {
+				scope = apiKey
+			}
+		}
+		if len(scope) == 0 {
+			c.Next()
+			return
+		}
+
+		// the multipart form body is a little bigger than the data
+		bodyHash, clean, err := hashRequestBody(c, schema.SubmitMaxSize+schema.FormDataMaxOverhead)
+		if err == schema.ErrDataTooBig {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, schema.RespErr{Err: err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, schema.RespErr{Err: err.Error()})
+			return
+		}
+		defer clean()
+
+		record, err := s.BeginIdempotentRequest(scope, idempotencyKey, c.Request.Method+" "+c.Request.URL.Path, bodyHash)
+		switch err {
+		case nil:
+		case schema.ErrIdempotencyKeyReused:
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, schema.RespErr{Err: err.Error()})
+			return
+		case schema.ErrIdempotencyKeyInProcess:
+			c.AbortWithStatusJSON(http.StatusConflict, schema.RespErr{Err: err.Error()})
+			return
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, schema.RespErr{Err: err.Error()})
+			return
+		}
+		if record != nil {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(http.StatusOK, "application/json; charset=utf-8", record.Response)
+			c.Abort()
+			return
+		}
+
+		writer := &bodyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+		c.Next()
+		s.EndIdempotentRequest(scope, idempotencyKey, writer.Status() == http.StatusOK, writer.body.Bytes())
+	}
+}
+
+// hashRequestBody return the sha256 of the request body, the body is replaced by a copy which is removed by clean.
+// schema.ErrDataTooBig is returned if the body is larger than maxSize
+func hashRequestBody(c *gin.Context, maxSize int64) (bodyHash string, clean func(), err error) {
+	hash := sha256.New()
+	clean = func() {}
+	if c.Request.Body == nil {
+		return hex.EncodeToString(hash.Sum(nil)), clean, nil
+	}
+	if c.Request.ContentLength > maxSize {
+		return "", nil, schema.ErrDataTooBig
+	}
+	reqBody := c.Request.Body
+	defer reqBody.Close()
+	body := io.TeeReader(http.MaxBytesReader(c.Writer, reqBody, maxSize), hash)
+
+	// keep up to schema.AllowStreamMinItemSize in memory
+	var buf bytes.Buffer
+	size, err := io.CopyN(&buf, body, schema.AllowStreamMinItemSize+1)
+	if err != nil && err != io.EOF {
+		return "", nil, bodyReadErr(err)
+	}
+	if size <= schema.AllowStreamMinItemSize {
+		c.Request.Body = io.NopCloser(&buf)
+		return hex.EncodeToString(hash.Sum(nil)), clean, nil
+	}
+
+	tmpFile, err := os.CreateTemp(schema.TmpFileDir, "arseed-idempotency-")
+	if err != nil {
+		return "", nil, err
+	}
+	clean = func() {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+	}
+	if _, err = io.Copy(tmpFile, io.MultiReader(&buf, body)); err != nil {
+		clean()
+		return "", nil, bodyReadErr(err)
+	}
+	if _, err = tmpFile.Seek(0, 0); err != nil {
+		clean()
+		return "", nil, err
+	}
+	c.Request.Body = io.NopCloser(tmpFile)
+	return hex.EncodeToString(hash.Sum(nil)), clean, nil
+}
+
+// bodyReadErr the error of http.MaxBytesReader has no type in go1.17
+func bodyReadErr(err error) error {
+	if err != nil && err.Error() == "http: request body too large" {
+		return schema.ErrDataTooBig
+	}
+	return err
+}
+
+// bodyWriter keep a copy of the response body
+type bodyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
 func ManifestMiddleware(s *Arseeding) gin.HandlerFunc {
 
 	wdb := s.wdb
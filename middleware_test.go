@@ -1,6 +1,17 @@
 package arseeding
 
-import "testing"
+import (
+	"github.com/everFinance/arseeding/config"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
 
 func TestSandboxMiddleware(t *testing.T) {
 	host := "p6qmubetdqoqlsoktncg3hiec2nbyjmgqgmhboopftn67xfk.arseed.web3infura.io"
@@ -15,3 +26,150 @@ func TestGetSubDomain(t *testing.T) {
 	res := getSubDomain(host)
 	t.Log(res)
 }
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	sqliteDir := "./data/idempotency"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	aa := &Arseeding{wdb: wdb}
+	assert.NoError(t, wdb.InsertApiKey(schema.AutoApiKey{ApiKey: "apikey-test", Address: "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"}))
+	assert.NoError(t, wdb.InsertUploadToken(schema.UploadToken{Token: "token-1", ApiKey: "apikey-test"}))
+	assert.NoError(t, wdb.InsertUploadToken(schema.UploadToken{Token: "token-2", ApiKey: "apikey-test"}))
+
+	calls := 0
+	r := gin.New()
+	r.POST("/bundle/data/:currency", IdempotencyMiddleware(aa), func(c *gin.Context) {
+		calls++
+		if c.Query("fail") == "true" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed"})
+			return
+		}
+		body, err := ioutil.ReadAll(c.Request.Body)
+		assert.NoError(t, err)
+		c.JSON(http.StatusOK, gin.H{"calls": calls, "body": string(body)})
+	})
+	sendWith := func(url, idempotencyKey, header, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		req.Header.Set(header, key)
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	send := func(url, idempotencyKey string) *httptest.ResponseRecorder {
+		return sendWith(url, idempotencyKey, "X-API-KEY", "apikey-test", "data")
+	}
+
+	// failed request can be retried
+	assert.Equal(t, http.StatusBadRequest, send("/bundle/data/usdc?fail=true", "key-1").Code)
+	w := send("/bundle/data/usdc", "key-1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"body":"data","calls":2}`, w.Body.String())
+
+	// retry return the original response
+	w = send("/bundle/data/usdc", "key-1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"body":"data","calls":2}`, w.Body.String())
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 2, calls)
+
+	// the key can not be used by other request
+	assert.Equal(t, http.StatusUnprocessableEntity, send("/bundle/data/ar", "key-1").Code)
+
+	w = send("/bundle/data/usdc", "key-2")
+	assert.Equal(t, `{"body":"data","calls":3}`, w.Body.String())
+
+	// the key can not be used by other body
+	assert.Equal(t, http.StatusUnprocessableEntity, sendWith("/bundle/data/usdc", "key-2", "X-API-KEY", "apikey-test", "other").Code)
+
+	// invalid apikey is not recorded
+	assert.Equal(t, http.StatusOK, sendWith("/bundle/data/usdc", "key-3", "X-API-KEY", "invalid", "data").Code)
+	_, err := wdb.GetIdempotencyRecord("invalid", "key-3")
+	assert.Error(t, err)
+
+	// the key is scoped by the upload token
+	w = sendWith("/bundle/data/usdc", "key-4", "X-UPLOAD-TOKEN", "token-1", "data")
+	assert.Equal(t, `{"body":"data","calls":5}`, w.Body.String())
+	w = sendWith("/bundle/data/usdc", "key-4", "X-UPLOAD-TOKEN", "token-2", "data")
+	assert.Equal(t, `{"body":"data","calls":6}`, w.Body.String())
+	w = sendWith("/bundle/data/usdc", "key-4", "X-UPLOAD-TOKEN", "token-1", "data")
+	assert.Equal(t, `{"body":"data","calls":5}`, w.Body.String())
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+
+	// the body is not read over the max size
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/bundle/data/usdc", strings.NewReader("data"))
+	_, _, err = hashRequestBody(c, 3)
+	assert.Equal(t, schema.ErrDataTooBig, err)
+	c.Request = httptest.NewRequest(http.MethodPost, "/bundle/data/usdc", ioutil.NopCloser(strings.NewReader("data")))
+	_, _, err = hashRequestBody(c, 3)
+	assert.Equal(t, schema.ErrDataTooBig, err)
+	c.Request = httptest.NewRequest(http.MethodPost, "/bundle/data/usdc", strings.NewReader("data"))
+	_, clean, err := hashRequestBody(c, 4)
+	assert.NoError(t, err)
+	clean()
+}
+
+func TestRequireSessionMiddleware(t *testing.T) {
+	sqliteDir := "./data/session"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	aa := &Arseeding{wdb: wdb}
+
+	r := gin.New()
+	handler := func(c *gin.Context) {
+		if !checkAuthAddress(c, c.Param("address")) {
+			return
+		}
+		c.JSON(http.StatusOK, "ok")
+	}
+	r.GET("/optional/:address", SessionAuthMiddleware(aa), handler)
+	r.GET("/required/:address", RequireSessionMiddleware(aa), handler)
+	send := func(url, auth string) int {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if len(auth) > 0 {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	addr := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	assert.Equal(t, http.StatusUnauthorized, send("/required/"+addr, ""))
+	assert.Equal(t, http.StatusUnauthorized, send("/required/"+addr, "Bearer invalid"))
+	// the handler must not serve the address data without session
+	assert.Equal(t, http.StatusUnauthorized, send("/optional/"+addr, ""))
+	assert.Equal(t, http.StatusUnauthorized, send("/optional/"+addr, "Bearer invalid"))
+}
+
+func TestLegacyAuth(t *testing.T) {
+	sqliteDir := "./data/legacy-auth"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	aa := &Arseeding{wdb: wdb, config: &config.Config{}}
+
+	r := gin.New()
+	r.GET("/bundle/orders/:signer", SessionAuthMiddleware(aa), aa.getOrders)
+	r.GET("/apikey/:timestamp/:signature", aa.getApiKey)
+	send := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	addr := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	// the deprecated access is kept by default
+	w := send("/bundle/orders/" + addr)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Deprecation"))
+	w = send("/apikey/0/0x00")
+	assert.Equal(t, http.StatusInternalServerError, w.Code) // timestamp expired
+
+	aa.config.Param.DisableLegacyAuth = true
+	assert.Equal(t, http.StatusUnauthorized, send("/bundle/orders/"+addr).Code)
+	assert.Equal(t, http.StatusGone, send("/apikey/0/0x00").Code)
+}
@@ -0,0 +1,201 @@
+package arseeding
+
+import (
+	"errors"
+	"fmt"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/go-everpay/config"
+	"github.com/tidwall/gjson"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPriceSources   = "redstone"
+	DefaultPriceMaxChange = 20.0 // 20%
+	DefaultPriceMinAgree  = 2
+	DefaultPriceTTL       = 30 * 60
+)
+
+// PriceSource return the token price in USD
+type PriceSource interface {
+	Name() string
+	GetPrice(symbol string) (float64, error)
+}
+
+type redstoneSource struct{}
+
+func (r redstoneSource) Name() string {
+	return "redstone"
+}
+
+func (r redstoneSource) GetPrice(symbol string) (float64, error) {
+	return config.GetTokenPriceByRedstone(symbol, "USDC", "")
+}
+
+// fileSource read prices from a local json file, e.g. {"AR": 8.5, "USDC": 1}. It is used by tests and private deployments
+type fileSource struct {
+	path string
+}
+
+func (f fileSource) Name() string {
+	return "file:" + f.path
+}
+
+func (f fileSource) GetPrice(symbol string) (float64, error) {
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return 0, err
+	}
+	res := gjson.GetBytes(data, strings.ToUpper(symbol))
+	if !res.Exists() {
+		return 0, fmt.Errorf("price of %s not found", symbol)
+	}
+	return res.Float(), nil
+}
+
+// jsonSource query price from http api, {symbol} in url is replaced by the token symbol and path is the gjson path of price
+type jsonSource struct {
+	url  string
+	path string
+	cli  *http.Client
+}
+
+func (j jsonSource) Name() string {
+	return "json:" + j.url
+}
+
+func (j jsonSource) GetPrice(symbol string) (float64, error) {
+	resp, err := j.cli.Get(strings.ReplaceAll(j.url, "{symbol}", symbol))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("price api status code: %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	res := gjson.GetBytes(data, j.path)
+	if !res.Exists() {
+		return 0, fmt.Errorf("price of %s not found", symbol)
+	}
+	return res.Float(), nil
+}
+
+func newPriceSource(conf string) (PriceSource, error) {
+	kv := strings.SplitN(strings.TrimSpace(conf), ":", 2)
+	switch kv[0] {
+	case "redstone":
+		return redstoneSource{}, nil
+	case "file":
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, errors.New("file price source need path")
+		}
+		return fileSource{path: kv[1]}, nil
+	case "json":
+		if len(kv) != 2 {
+			return nil, errors.New("json price source need url and path")
+		}
+		idx := strings.LastIndex(kv[1], ";")
+		if idx <= 0 || idx == len(kv[1])-1 {
+			return nil, errors.New("json price source format: json:<url>;<path>")
+		}
+		return jsonSource{url: kv[1][:idx], path: kv[1][idx+1:], cli: &http.Client{Timeout: 10 * time.Second}}, nil
+	default:
+		return nil, fmt.Errorf("not support price source: %s", conf)
+	}
+}
+
+// PriceOracle aggregate the prices of several sources
+type PriceOracle struct {
+	sources   []PriceSource
+	maxChange float64 // percentage
+	minAgree  int     // the number of sources which must agree on the price move larger than maxChange
+}
+
+func NewPriceOracle(sourcesConf string, maxChange float64, minAgree int) (*PriceOracle, error) {
+	if strings.TrimSpace(sourcesConf) == "" {
+		sourcesConf = DefaultPriceSources
+	}
+	if maxChange <= 0 {
+		maxChange = DefaultPriceMaxChange
+	}
+	if minAgree <= 0 {
+		minAgree = DefaultPriceMinAgree
+	}
+	sources := make([]PriceSource, 0)
+	for _, conf := range strings.Split(sourcesConf, ",") {
+		source, err := newPriceSource(conf)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return &PriceOracle{sources: sources, maxChange: maxChange, minAgree: minAgree}, nil
+}
+
+// Query return the median price of all sources.
+// lastPrice is the last accepted price; 0 means no check. The move larger than maxChange is rejected
+// unless at least minAgree sources are within maxChange of the new price
+func (o *PriceOracle) Query(symbol string, lastPrice float64) (float64, error) {
+	prices := make([]float64, 0, len(o.sources))
+	for _, source := range o.sources {
+		price, err := source.GetPrice(symbol)
+		if err != nil || price <= 0.0 {
+			continue
+		}
+		prices = append(prices, price)
+	}
+	if len(prices) == 0 {
+		return 0, fmt.Errorf("no price source available for %s", symbol)
+	}
+	price := median(prices)
+	if lastPrice > 0 {
+		change := math.Abs(price-lastPrice) / lastPrice * 100
+		if change > o.maxChange {
+			agree := 0
+			for _, p := range prices {
+				if math.Abs(p-price)/price*100 <= o.maxChange {
+					agree++
+				}
+			}
+			if agree < o.minAgree {
+				return 0, fmt.Errorf("price of %s change %.2f%% over the threshold %.2f%% and agreed by %d sources, need %d, last: %v, new: %v", symbol, change, o.maxChange, agree, o.minAgree, lastPrice, price)
+			}
+		}
+	}
+	return price, nil
+}
+
+func median(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// isPriceStale manual set price never be stale
+func isPriceStale(tp schema.TokenPrice, ttl int64) bool {
+	if tp.ManualSet {
+		return false
+	}
+	return time.Since(tp.UpdatedAt) > time.Duration(ttl)*time.Second
+}
+
+func (s *Arseeding) priceTTL() int64 {
+	if s.config.Param.PriceTTL > 0 {
+		return s.config.Param.PriceTTL
+	}
+	return DefaultPriceTTL
+}
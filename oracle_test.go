@@ -0,0 +1,56 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/schema"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestPriceOracle(t *testing.T) {
+	dir := "./data/oracle"
+	assert.NoError(t, os.MkdirAll(dir, os.ModePerm))
+	defer os.RemoveAll(dir)
+	files := []string{path.Join(dir, "p1.json"), path.Join(dir, "p2.json"), path.Join(dir, "p3.json")}
+	assert.NoError(t, ioutil.WriteFile(files[0], []byte(`{"AR": 10, "USDC": 1}`), 0644))
+	assert.NoError(t, ioutil.WriteFile(files[1], []byte(`{"AR": 11}`), 0644))
+	assert.NoError(t, ioutil.WriteFile(files[2], []byte(`{"AR": 100}`), 0644)) // bad tick
+
+	oracle, err := NewPriceOracle("file:"+files[0]+",file:"+files[1]+",file:"+files[2], 0, 3)
+	assert.NoError(t, err)
+	price, err := oracle.Query("ar", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 11.0, price)
+	price, err = oracle.Query("USDC", 1.01)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, price)
+	_, err = oracle.Query("ETH", 0)
+	assert.Error(t, err)
+
+	// the move over 20% is rejected
+	_, err = oracle.Query("AR", 8)
+	assert.Error(t, err)
+
+	// the move over 20% is accepted if enough sources agree on it
+	oracle, err = NewPriceOracle("file:"+files[0]+",file:"+files[1]+",file:"+files[2], 0, 0)
+	assert.NoError(t, err)
+	price, err = oracle.Query("AR", 8)
+	assert.NoError(t, err)
+	assert.Equal(t, 11.0, price)
+	oracle, err = NewPriceOracle("file:"+files[2], 0, 0)
+	assert.NoError(t, err)
+	_, err = oracle.Query("AR", 11)
+	assert.Error(t, err)
+
+	_, err = NewPriceOracle("unknown", 0, 0)
+	assert.Error(t, err)
+	_, err = NewPriceOracle("json:https://host/price", 0, 0)
+	assert.Error(t, err)
+
+	assert.True(t, isPriceStale(schema.TokenPrice{UpdatedAt: time.Now().Add(-time.Hour)}, 1800))
+	assert.False(t, isPriceStale(schema.TokenPrice{UpdatedAt: time.Now().Add(-time.Hour), ManualSet: true}, 1800))
+	assert.False(t, isPriceStale(schema.TokenPrice{UpdatedAt: time.Now()}, 1800))
+}
@@ -0,0 +1,60 @@
+package arseeding
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// paymentExpiration return the unpaid order expiration of currency, unit s
+func (s *Arseeding) paymentExpiration(currency string) int64 {
+	for symbol, val := range s.config.Param.CurrencyPaymentExpiredRange {
+		if !strings.EqualFold(symbol, currency) {
+			continue
+		}
+		var expiration int64
+		if _, err := fmt.Sscan(fmt.Sprint(val), &expiration); err == nil && expiration > 0 {
+			return expiration
+		}
+	}
+	if s.config.Param.PaymentExpiredRange > 0 {
+		return s.config.Param.PaymentExpiredRange
+	}
+	return s.paymentExpiredRange
+}
+
+// CancelOrder cancel the unpaid orders of the item and delete the item immediately, signer must be the item signer
+func (s *Arseeding) CancelOrder(signer, itemId string) error {
+	ord, err := s.wdb.GetUnPaidOrder(itemId)
+	if err != nil {
+		return errors.New("unpaid order not found")
+	}
+	if !strings.EqualFold(ord.Signer, signer) {
+		return errors.New("only the item signer can cancel the order")
+	}
+	num, err := s.wdb.CancelUnpaidOrders(itemId, ord.Signer)
+	if err != nil {
+		return err
+	}
+	if num == 0 {
+		return errors.New("unpaid order not found")
+	}
+	// the item is kept if it has been paid by another order
+	if s.wdb.ExistPaidOrd(itemId) {
+		return nil
+	}
+	return s.delOrderItem(itemId)
+}
+
+// delOrderItem delete the bundle item of the unpaid order from store and manifest table
+func (s *Arseeding) delOrderItem(itemId string) error {
+	if err := s.DelItem(itemId); err != nil {
+		log.Error("DelItem", "err", err, "itemId", itemId)
+		return err
+	}
+	if err := s.wdb.DelManifest(itemId); err != nil {
+		log.Error("s.wdb.DelManifest", "err", err, "itemId", itemId)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/config"
+	cfgSchema "github.com/everFinance/arseeding/config/schema"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+	"os"
+	"testing"
+)
+
+func TestPaymentExpiration(t *testing.T) {
+	aa := &Arseeding{
+		config:              &config.Config{},
+		paymentExpiredRange: schema.DefaultPaymentExpiredRange,
+	}
+	assert.Equal(t, schema.DefaultPaymentExpiredRange, aa.paymentExpiration("AR"))
+
+	aa.config.Param = cfgSchema.Param{
+		PaymentExpiredRange:         3600,
+		CurrencyPaymentExpiredRange: datatypes.JSONMap{"AR": float64(600), "usdc": "1200"},
+	}
+	assert.Equal(t, int64(600), aa.paymentExpiration("ar"))
+	assert.Equal(t, int64(1200), aa.paymentExpiration("USDC"))
+	assert.Equal(t, int64(3600), aa.paymentExpiration("ETH"))
+}
+
+func TestCancelOrder(t *testing.T) {
+	sqliteDir := "./data/order"
+	boltPath := "./data/order.db"
+	defer os.RemoveAll(sqliteDir)
+	defer os.RemoveAll(boltPath)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, true))
+	store, err := NewBoltStore(boltPath)
+	assert.NoError(t, err)
+	aa := &Arseeding{wdb: wdb, store: store}
+
+	signer := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-1", Signer: signer, PaymentStatus: schema.UnPayment, PaymentExpiredTime: 100}))
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-1", Signer: signer, PaymentStatus: schema.UnPayment, PaymentExpiredTime: 200}))
+
+	assert.Error(t, aa.CancelOrder("0xa06b79E655Db7D7C3B3E7B2ccEEb068c3259d0C9", "item-1"))
+	assert.NoError(t, aa.CancelOrder("0x4002ed1a1410af1b4930cf6c479ae373debd6223", "item-1"))
+	_, err = wdb.GetUnPaidOrder("item-1")
+	assert.Error(t, err)
+	assert.Error(t, aa.CancelOrder(signer, "item-1"))
+
+	// the cancelled order can not be paid
+	ords := make([]schema.Order, 0)
+	assert.NoError(t, wdb.Db.Where("item_id = ?", "item-1").Find(&ords).Error)
+	assert.Equal(t, 2, len(ords))
+	assert.Equal(t, schema.CancelPayment, ords[0].PaymentStatus)
+	assert.Equal(t, schema.ErrOrderNotUnpaid, wdb.UpdateOrderPay(ords[0].ID, "everHash", schema.SuccPayment, nil))
+}
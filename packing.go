@@ -0,0 +1,124 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/schema"
+	"github.com/shopspring/decimal"
+	"sort"
+	"strings"
+	"time"
+)
+
+type bundlePackingPolicy struct {
+	MinSize  int64
+	MaxSize  int64
+	MaxItems int
+	MaxWait  time.Duration
+	OrderBy  string
+}
+
+func (s *Arseeding) bundlePackingPolicy() bundlePackingPolicy {
+	param := s.config.Param
+	policy := bundlePackingPolicy{
+		MinSize:  param.BundleMinSize,
+		MaxSize:  param.BundleMaxSize,
+		MaxItems: param.BundleMaxItems,
+		MaxWait:  time.Duration(param.BundleMaxWait) * time.Second,
+		OrderBy:  strings.ToLower(param.BundleOrderBy),
+	}
+	if policy.MaxSize <= 0 || policy.MaxSize > schema.MaxPerOnChainSize {
+		policy.MaxSize = schema.MaxPerOnChainSize
+	}
+	if policy.MaxWait <= 0 {
+		policy.MaxWait = time.Duration(schema.DefaultBundleMaxWait) * time.Second
+	}
+	if policy.OrderBy != schema.BundleOrderByFee {
+		policy.OrderBy = schema.BundleOrderByAge
+	}
+	return policy
+}
+
+// packOrders select the orders of next bundle by policy, nil is returned if the bundle should wait for more items
+func (s *Arseeding) packOrders(ords []schema.Order, policy bundlePackingPolicy) []schema.Order {
+	feeValues := make(map[string]decimal.Decimal)
+	if policy.OrderBy == schema.BundleOrderByFee {
+		tps, err := s.wdb.GetPrices()
+		if err != nil {
+			log.Error("s.wdb.GetPrices()", "err", err)
+		}
+		feeValues = orderFeeValues(ords, tps)
+	}
+	return packBundleOrders(ords, policy, feeValues, time.Now())
+}
+
+// orderFeeValues convert the order fee to USD, so the fee paid in different currencies can be compared
+func orderFeeValues(ords []schema.Order, tps []schema.TokenPrice) map[string]decimal.Decimal {
+	prices := make(map[string]decimal.Decimal, len(tps)+1)
+	for _, tp := range tps {
+		prices[strings.ToUpper(tp.Symbol)] = decimal.NewFromFloat(tp.Price)
+	}
+	prices[schema.UsdCurrency] = decimal.NewFromInt(1)
+
+	values := make(map[string]decimal.Decimal, len(ords))
+	for _, ord := range ords {
+		fee, err := decimal.NewFromString(ord.Fee)
+		if err != nil {
+			continue
+		}
+		values[ord.ItemId] = fee.Shift(int32(-ord.Decimals)).Mul(prices[strings.ToUpper(ord.Currency)])
+	}
+	return values
+}
+
+// packBundleOrders the orders are packed by age or fee value until the max size or max items of policy.
+// The orders waited longer than max wait are packed first by age, so the low fee orders are bundled before the expected block.
+// The bundle smaller than min size is returned only if the oldest order waited longer than max wait
+func packBundleOrders(ords []schema.Order, policy bundlePackingPolicy, feeValues map[string]decimal.Decimal, now time.Time) []schema.Order {
+	if len(ords) == 0 {
+		return nil
+	}
+	overdue := func(ord schema.Order) bool {
+		return now.Sub(ord.CreatedAt) >= policy.MaxWait
+	}
+	sorted := make([]schema.Order, len(ords))
+	copy(sorted, ords)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if policy.OrderBy == schema.BundleOrderByFee && overdue(sorted[i]) != overdue(sorted[j]) {
+			return overdue(sorted[i])
+		}
+		if policy.OrderBy == schema.BundleOrderByFee && !overdue(sorted[i]) {
+			vi, vj := feeValues[sorted[i].ItemId], feeValues[sorted[j].ItemId]
+			if !vi.Equal(vj) {
+				return vi.GreaterThan(vj)
+			}
+		}
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	packed := make([]schema.Order, 0)
+	totalSize := int64(0)
+	oldest := time.Time{}
+	for _, ord := range sorted {
+		if oldest.IsZero() || ord.CreatedAt.Before(oldest) {
+			oldest = ord.CreatedAt
+		}
+		if policy.MaxItems > 0 && len(packed) >= policy.MaxItems {
+			continue
+		}
+		// the item larger than max size is bundled alone
+		if totalSize+ord.Size > policy.MaxSize && (len(packed) > 0 || ord.Size > schema.MaxPerOnChainSize) {
+			continue
+		}
+		packed = append(packed, ord)
+		totalSize += ord.Size
+	}
+	if len(packed) == 0 {
+		return nil
+	}
+	if totalSize < policy.MinSize && now.Sub(oldest) < policy.MaxWait {
+		return nil
+	}
+	return packed
+}
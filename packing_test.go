@@ -0,0 +1,114 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/schema"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPackBundleOrders(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	ords := []schema.Order{
+		{ID: 1, CreatedAt: now.Add(-30 * time.Minute), ItemId: "item-1", Size: 100, Currency: "USDC", Decimals: 6, Fee: "1000000"},
+		{ID: 2, CreatedAt: now.Add(-20 * time.Minute), ItemId: "item-2", Size: 200, Currency: "AR", Decimals: 12, Fee: "1000000000000"},
+		{ID: 3, CreatedAt: now.Add(-10 * time.Minute), ItemId: "item-3", Size: 300, Currency: "USDC", Decimals: 6, Fee: "3000000"},
+	}
+	policy := bundlePackingPolicy{MaxSize: schema.MaxPerOnChainSize, MaxWait: time.Hour, OrderBy: schema.BundleOrderByAge}
+	itemIds := func(ords []schema.Order) []string {
+		res := make([]string, 0, len(ords))
+		for _, ord := range ords {
+			res = append(res, ord.ItemId)
+		}
+		return res
+	}
+
+	// default policy bundles all
+	assert.Equal(t, []string{"item-1", "item-2", "item-3"}, itemIds(packBundleOrders(ords, policy, nil, now)))
+
+	// max size and max items
+	policy.MaxSize = 400
+	assert.Equal(t, []string{"item-1", "item-2"}, itemIds(packBundleOrders(ords, policy, nil, now)))
+	policy.MaxItems = 1
+	assert.Equal(t, []string{"item-1"}, itemIds(packBundleOrders(ords, policy, nil, now)))
+	policy.MaxItems = 0
+
+	// the item larger than max size is bundled alone
+	policy.MaxSize = 250
+	assert.Equal(t, []string{"item-1"}, itemIds(packBundleOrders(ords[:1], policy, nil, now)))
+	assert.Equal(t, []string{"item-3"}, itemIds(packBundleOrders(ords[2:], policy, nil, now)))
+	policy.MaxSize = schema.MaxPerOnChainSize
+
+	// order by fee value
+	policy.OrderBy = schema.BundleOrderByFee
+	feeValues := orderFeeValues(ords, []schema.TokenPrice{{Symbol: "AR", Price: 10}, {Symbol: "USDC", Price: 1}})
+	assert.True(t, decimal.NewFromInt(10).Equal(feeValues["item-2"]))
+	policy.MaxSize = 500
+	assert.Equal(t, []string{"item-2", "item-3"}, itemIds(packBundleOrders(ords, policy, feeValues, now)))
+	policy.MaxSize = schema.MaxPerOnChainSize
+
+	// the orders waited longer than max wait are packed first by age
+	policy.MaxItems = 1
+	assert.Equal(t, []string{"item-2"}, itemIds(packBundleOrders(ords, policy, feeValues, now)))
+	policy.MaxWait = 25 * time.Minute
+	assert.Equal(t, []string{"item-1"}, itemIds(packBundleOrders(ords, policy, feeValues, now)))
+	policy.MaxWait = 15 * time.Minute
+	policy.MaxItems = 2
+	assert.Equal(t, []string{"item-1", "item-2"}, itemIds(packBundleOrders(ords, policy, feeValues, now)))
+	policy.MaxItems = 0
+	policy.MaxWait = time.Hour
+
+	// min size waits until the oldest order waited max wait
+	policy.MinSize = 1000
+	assert.Nil(t, packBundleOrders(ords, policy, feeValues, now))
+	assert.Equal(t, 3, len(packBundleOrders(ords, policy, feeValues, now.Add(30*time.Minute))))
+	policy.MinSize = 600
+	assert.Equal(t, 3, len(packBundleOrders(ords, policy, feeValues, now)))
+}
+
+func TestGetNeedOnChainOrdersByFee(t *testing.T) {
+	sqliteDir := "./data/packing"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	for _, ord := range []schema.Order{
+		{ItemId: "item-1", Currency: "USDC", Fee: "900000"},
+		{ItemId: "item-2", Currency: "USDC", Fee: "10000000"},
+		{ItemId: "item-3", Currency: "AR", Fee: "2000000000000"},
+		{ItemId: "item-4", Currency: "USDC", Fee: "3000000"},
+	} {
+		ord.PaymentStatus = schema.SuccPayment
+		ord.OnChainStatus = schema.WaitOnChain
+		assert.NoError(t, wdb.InsertOrder(ord))
+	}
+	itemIds := func(ords []schema.Order) []string {
+		res := make([]string, 0, len(ords))
+		for _, ord := range ords {
+			res = append(res, ord.ItemId)
+		}
+		return res
+	}
+
+	ords, err := wdb.GetNeedOnChainOrders(schema.BundleOrderByAge, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"item-1", "item-2", "item-3", "item-4"}, itemIds(ords))
+
+	// the fee strings are compared as numbers in every currency
+	ords, err = wdb.GetNeedOnChainOrders(schema.BundleOrderByFee, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"item-1", "item-2", "item-3", "item-4"}, itemIds(ords))
+	usdc := make([]string, 0)
+	for _, ord := range ords {
+		if ord.Currency == "USDC" {
+			usdc = append(usdc, ord.ItemId)
+		}
+	}
+	assert.Equal(t, []string{"item-2", "item-4", "item-1"}, usdc)
+
+	// the overdue orders are returned once
+	ords, err = wdb.GetNeedOnChainOrders(schema.BundleOrderByFee, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"item-1", "item-2", "item-3", "item-4"}, itemIds(ords))
+}
@@ -0,0 +1,348 @@
+package arseeding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/go-everpay/account"
+	paySdk "github.com/everFinance/go-everpay/sdk"
+	sdkSchema "github.com/everFinance/go-everpay/sdk/schema"
+	paySchema "github.com/everFinance/go-everpay/token/schema"
+	tokUtils "github.com/everFinance/go-everpay/token/utils"
+	"github.com/everFinance/goar"
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"github.com/tidwall/gjson"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// PaymentProvider is a channel through which the bundler receives fee payments
+type PaymentProvider interface {
+	Name() string
+	// Subscribe push incoming payments to the bundler, which are after the cursor(the last receipt rawId of this provider)
+	Subscribe(cursor uint64) <-chan schema.ReceiptEverTx
+	// Refund return the receipt amount to the payer
+	Refund(rpt schema.ReceiptEverTx) (txHash string, err error)
+	// FeeBalances return the bundler token balances which hold the collected fee
+	FeeBalances() ([]schema.FeeBalance, error)
+	// TransferFee transfer amount of the token to the address, return the tx hash
+	TransferFee(bal schema.FeeBalance, amount *big.Int, to string) (string, error)
+}
+
+func (s *Arseeding) initPaymentProviders(enableArPayment bool) {
+	s.paymentProviders = map[string]PaymentProvider{
+		schema.PaymentProviderEverPay: newEverPayProvider(s.everpaySdk, s.bundler.Signer.Address),
+	}
+	if enableArPayment {
+		s.paymentProviders[schema.PaymentProviderArweave] = newArPayProvider(s.arCli, s.bundler)
+	}
+}
+
+func (s *Arseeding) getPaymentProvider(name string) (PaymentProvider, error) {
+	if name == "" {
+		name = schema.PaymentProviderEverPay
+	}
+	provider, ok := s.paymentProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("payment provider not found: %s", name)
+	}
+	return provider, nil
+}
+
+type everPayProvider struct {
+	sdk     *paySdk.SDK
+	bundler string
+}
+
+func newEverPayProvider(sdk *paySdk.SDK, bundler string) *everPayProvider {
+	return &everPayProvider{sdk: sdk, bundler: bundler}
+}
+
+func (p *everPayProvider) Name() string {
+	return schema.PaymentProviderEverPay
+}
+
+func (p *everPayProvider) Subscribe(cursor uint64) <-chan schema.ReceiptEverTx {
+	receipts := make(chan schema.ReceiptEverTx)
+	go func() {
+		subTx := p.sdk.Cli.SubscribeTxs(sdkSchema.FilterQuery{
+			StartCursor: int64(cursor),
+			Address:     p.bundler,
+			Action:      paySchema.TxActionTransfer,
+		})
+		defer subTx.Unsubscribe()
+
+		for tt := range subTx.Subscribe() {
+			if tt.To != p.bundler {
+				continue
+			}
+			_, from, err := account.IDCheck(tt.From)
+			if err != nil {
+				log.Error("account.IDCheck(tt.From)", "err", err, "from", tt.From)
+				continue
+			}
+			receipts <- schema.ReceiptEverTx{
+				RawId:    uint64(tt.RawId),
+				EverHash: tt.EverHash,
+				Nonce:    tt.Nonce,
+				Symbol:   tt.TokenSymbol,
+				TokenTag: tokUtils.Tag(tt.ChainType, tt.TokenSymbol, tt.TokenID),
+				From:     from,
+				Amount:   tt.Amount,
+				Data:     tt.Data,
+				Sig:      tt.Sig,
+				Status:   schema.UnSpent,
+				Provider: schema.PaymentProviderEverPay,
+			}
+		}
+	}()
+	return receipts
+}
+
+func (p *everPayProvider) Refund(rpt schema.ReceiptEverTx) (string, error) {
+	amount, ok := new(big.Int).SetString(rpt.Amount, 10)
+	if !ok {
+		return "", fmt.Errorf("receipt amount incorrect: %s", rpt.Amount)
+	}
+	// everTx data
+	mmap := map[string]string{
+		"appName":        "arseeding",
+		"action":         "refund",
+		"refundEverHash": rpt.EverHash,
+	}
+	data, _ := json.Marshal(mmap)
+	everTx, err := p.sdk.Transfer(rpt.TokenTag, amount, rpt.From, string(data))
+	if err != nil {
+		return "", err
+	}
+	return everTx.HexHash(), nil
+}
+
+func (p *everPayProvider) FeeBalances() ([]schema.FeeBalance, error) {
+	tokBals, err := p.sdk.Cli.Balances(p.bundler)
+	if err != nil {
+		return nil, err
+	}
+	tokens := p.sdk.GetTokens()
+	res := make([]schema.FeeBalance, 0, len(tokBals.Balances))
+	for _, tokBal := range tokBals.Balances {
+		tok, ok := tokens[tokBal.Tag]
+		if !ok {
+			continue
+		}
+		res = append(res, schema.FeeBalance{
+			Symbol:   strings.ToUpper(tok.Symbol),
+			Tag:      tokBal.Tag,
+			Decimals: tokBal.Decimals,
+			Amount:   tokBal.Amount,
+		})
+	}
+	return res, nil
+}
+
+func (p *everPayProvider) TransferFee(bal schema.FeeBalance, amount *big.Int, to string) (string, error) {
+	mmap := map[string]string{
+		"appName": "arseeding",
+		"action":  "feeCollection",
+		"bundler": p.bundler,
+	}
+	data, _ := json.Marshal(mmap)
+	everTx, err := p.sdk.Transfer(bal.Tag, amount, to, string(data))
+	if err != nil {
+		return "", err
+	}
+	return everTx.HexHash(), nil
+}
+
+// arPayProvider watch native AR transfers to the bundler wallet.
+// The payment tx must carry the tags: "App-Name: arseeding" and "Arseeding-Item-Ids: itemId1,itemId2"
+type arPayProvider struct {
+	arCli  *goar.Client
+	wallet *goar.Wallet
+}
+
+func newArPayProvider(arCli *goar.Client, wallet *goar.Wallet) *arPayProvider {
+	return &arPayProvider{arCli: arCli, wallet: wallet}
+}
+
+func (p *arPayProvider) Name() string {
+	return schema.PaymentProviderArweave
+}
+
+// arReceiptRawId the rawId of ar receipt is made up of block height and the tx index in block
+func arReceiptRawId(height int64, idx int) uint64 {
+	return uint64(height)<<20 | uint64(idx)
+}
+
+func arReceiptHeight(rawId uint64) int64 {
+	return int64(rawId >> 20)
+}
+
+func (p *arPayProvider) Subscribe(cursor uint64) <-chan schema.ReceiptEverTx {
+	receipts := make(chan schema.ReceiptEverTx)
+	go func() {
+		// blocks before lastHeight have been scanned
+		lastHeight := arReceiptHeight(cursor)
+		for {
+			info, err := p.arCli.GetInfo()
+			if err != nil {
+				log.Error("p.arCli.GetInfo()", "err", err)
+				time.Sleep(1 * time.Minute)
+				continue
+			}
+			confirmedHeight := info.Height - schema.ArPaymentConfirmations
+			if lastHeight == 0 { // first run, not scan the history
+				lastHeight = confirmedHeight
+			}
+			if confirmedHeight > lastHeight {
+				rpts, err := p.fetchReceipts(lastHeight+1, confirmedHeight)
+				if err != nil {
+					log.Error("p.fetchReceipts", "err", err, "from", lastHeight+1, "to", confirmedHeight)
+					time.Sleep(1 * time.Minute)
+					continue
+				}
+				for _, rpt := range rpts {
+					receipts <- rpt
+				}
+				lastHeight = confirmedHeight
+			}
+			time.Sleep(2 * time.Minute)
+		}
+	}()
+	return receipts
+}
+
+const arPaymentQuery = `{
+  transactions(recipients: ["%s"], tags: [{name: "%s", values: ["arseeding"]}], block: {min: %d, max: %d}, sort: HEIGHT_ASC, first: %d, after: "%s") {
+    pageInfo { hasNextPage }
+    edges {
+      cursor
+      node {
+        id
+        owner { address }
+        quantity { winston }
+        tags { name value }
+        block { height timestamp }
+      }
+    }
+  }
+}`
+
+// fetchReceipts return all arseeding payment txs in blocks [minHeight, maxHeight]
+func (p *arPayProvider) fetchReceipts(minHeight, maxHeight int64) ([]schema.ReceiptEverTx, error) {
+	rpts := make([]schema.ReceiptEverTx, 0)
+	after := ""
+	lastHeight, idx := int64(0), 0
+	for {
+		query := fmt.Sprintf(arPaymentQuery, p.wallet.Signer.Address, schema.ArPaymentAppNameTag, minHeight, maxHeight, schema.ArPaymentPageSize, after)
+		data, err := p.arCli.GraphQL(query)
+		if err != nil {
+			return nil, err
+		}
+		res := gjson.ParseBytes(data).Get("transactions")
+		for _, edge := range res.Get("edges").Array() {
+			after = edge.Get("cursor").String()
+			node := edge.Get("node")
+			height := node.Get("block.height").Int()
+			if height != lastHeight {
+				lastHeight, idx = height, 0
+			}
+			idx++
+			rpts = append(rpts, schema.ReceiptEverTx{
+				RawId:    arReceiptRawId(height, idx),
+				EverHash: node.Get("id").String(),
+				Nonce:    node.Get("block.timestamp").Int() * 1000,
+				Symbol:   "AR",
+				TokenTag: "AR",
+				From:     node.Get("owner.address").String(),
+				Amount:   node.Get("quantity.winston").String(),
+				Data:     arPaymentData(node.Get("tags").Array()),
+				Status:   schema.UnSpent,
+				Provider: schema.PaymentProviderArweave,
+			})
+		}
+		if !res.Get("pageInfo.hasNextPage").Bool() {
+			return rpts, nil
+		}
+	}
+}
+
+// arPaymentData convert the payment tx tags to the same data format as everTx
+func arPaymentData(tags []gjson.Result) string {
+	itemIds := make([]string, 0)
+	for _, tag := range tags {
+		if tag.Get("name").String() == schema.ArPaymentInvoiceTag {
+			data, _ := json.Marshal(map[string]interface{}{
+				"appName":   "arseeding",
+				"action":    InvoicePaymentAction,
+				"invoiceId": tag.Get("value").String(),
+			})
+			return string(data)
+		}
+		if tag.Get("name").String() != schema.ArPaymentItemIdsTag {
+			continue
+		}
+		for _, id := range strings.Split(tag.Get("value").String(), ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				itemIds = append(itemIds, id)
+			}
+		}
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"appName": "arseeding",
+		"action":  ItemPaymentAction,
+		"itemIds": itemIds,
+	})
+	return string(data)
+}
+
+func (p *arPayProvider) Refund(rpt schema.ReceiptEverTx) (string, error) {
+	amount, ok := new(big.Int).SetString(rpt.Amount, 10)
+	if !ok {
+		return "", fmt.Errorf("receipt amount incorrect: %s", rpt.Amount)
+	}
+	// the refund tx reward is paid by payer
+	reward, err := p.arCli.GetTransactionPrice(0, &rpt.From)
+	if err != nil {
+		return "", err
+	}
+	amount = new(big.Int).Sub(amount, big.NewInt(reward))
+	if amount.Sign() <= 0 {
+		return "", errors.New("receipt amount not enough to pay refund tx reward")
+	}
+	tx, err := p.wallet.SendWinston(amount, rpt.From, []types.Tag{
+		{Name: "App-Name", Value: "arseeding-refund"},
+		{Name: "Refund-Tx", Value: rpt.EverHash},
+	})
+	if err != nil {
+		return "", err
+	}
+	return tx.ID, nil
+}
+
+// FeeBalances the AR received is kept in bundler wallet, it is also used to pay for the bundle txs
+func (p *arPayProvider) FeeBalances() ([]schema.FeeBalance, error) {
+	bal, err := p.arCli.GetWalletBalance(p.wallet.Signer.Address)
+	if err != nil {
+		return nil, err
+	}
+	return []schema.FeeBalance{{
+		Symbol:   "AR",
+		Tag:      "AR",
+		Decimals: 12,
+		Amount:   utils.ARToWinston(bal).String(),
+	}}, nil
+}
+
+func (p *arPayProvider) TransferFee(bal schema.FeeBalance, amount *big.Int, to string) (string, error) {
+	tx, err := p.wallet.SendWinston(amount, to, []types.Tag{
+		{Name: "App-Name", Value: "arseeding-fee-collection"},
+	})
+	if err != nil {
+		return "", err
+	}
+	return tx.ID, nil
+}
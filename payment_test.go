@@ -0,0 +1,38 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+	"os"
+	"testing"
+)
+
+func TestArPaymentReceipt(t *testing.T) {
+	tags := gjson.Parse(`[{"name":"App-Name","value":"arseeding"},{"name":"Arseeding-Item-Ids","value":"item1, item2,"}]`).Array()
+	action, itemIds, err := parseTxData(arPaymentData(tags))
+	assert.NoError(t, err)
+	assert.Equal(t, ItemPaymentAction, action)
+	assert.Equal(t, []string{"item1", "item2"}, itemIds)
+
+	// no itemIds tag, the payment will be refunded
+	_, _, err = parseTxData(arPaymentData(tags[:1]))
+	assert.Error(t, err)
+
+	rawId := arReceiptRawId(1200000, 3)
+	assert.Equal(t, int64(1200000), arReceiptHeight(rawId))
+
+	sqliteDir := "./data/payment"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	assert.NoError(t, wdb.InsertReceiptTx(schema.ReceiptEverTx{RawId: 10, EverHash: "everHash", Status: schema.UnSpent}))
+	assert.NoError(t, wdb.InsertReceiptTx(schema.ReceiptEverTx{RawId: rawId, EverHash: "arTxId", Status: schema.UnSpent, Provider: schema.PaymentProviderArweave}))
+
+	cursor, err := wdb.GetLastReceiptRawId(schema.PaymentProviderEverPay)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), cursor)
+	cursor, err = wdb.GetLastReceiptRawId(schema.PaymentProviderArweave)
+	assert.NoError(t, err)
+	assert.Equal(t, rawId, cursor)
+}
@@ -0,0 +1,100 @@
+package arseeding
+
+import (
+	"fmt"
+	cfgSchema "github.com/everFinance/arseeding/config/schema"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+func usageMonth(t time.Time) string {
+	return t.UTC().Format("200601")
+}
+
+// CalcPlanItemFee return the item fee with the pricing plan assigned to owner(apikey owner or item signer)
+func (s *Arseeding) CalcPlanItemFee(currency string, itemSize int64, owner string) (*schema.RespFee, error) {
+	respFee, err := s.CalcItemFee(currency, itemSize)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyOwnerPlan(respFee, owner)
+}
+
+func (s *Arseeding) applyOwnerPlan(respFee *schema.RespFee, owner string) (*schema.RespFee, error) {
+	if owner == "" {
+		return respFee, nil
+	}
+	plan := s.config.GetPricingPlan(owner)
+	if plan == nil {
+		return respFee, nil
+	}
+	monthlyBytes, err := s.wdb.GetMonthlyBytes(owner, usageMonth(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	discount, err := plan.TierDiscount(monthlyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return applyPricingPlan(respFee, *plan, discount)
+}
+
+func applyPricingPlan(respFee *schema.RespFee, plan cfgSchema.PricingPlan, discount float64) (*schema.RespFee, error) {
+	fee, err := decimal.NewFromString(respFee.FinalFee)
+	if err != nil {
+		return nil, err
+	}
+	if discount > 0 && discount <= 100 {
+		fee = fee.Mul(decimal.NewFromFloat(100 - discount)).Div(decimal.NewFromInt(100)).Round(0)
+	}
+	for symbol, val := range plan.MinFee {
+		if !strings.EqualFold(symbol, respFee.Currency) {
+			continue
+		}
+		minFee, err := decimal.NewFromString(fmt.Sprint(val)) // string or number
+		if err != nil {
+			return nil, err
+		}
+		if fee.LessThan(minFee) {
+			fee = minFee
+		}
+	}
+	return &schema.RespFee{
+		Currency: respFee.Currency,
+		Decimals: respFee.Decimals,
+		FinalFee: fee.String(),
+		Plan:     plan.Name,
+		UsdFee:   calcUsdFee(fee, respFee.Decimals, respFee.UsdPrice),
+		UsdPrice: respFee.UsdPrice,
+		ArPrice:  respFee.ArPrice,
+	}, nil
+}
+
+// addMonthlyUsage count the paid bytes for the volume tier pricing
+func (s *Arseeding) addMonthlyUsage(owner string, size int64) {
+	if err := s.wdb.AddMonthlyUsage(owner, usageMonth(time.Now()), size, nil); err != nil {
+		log.Error("s.wdb.AddMonthlyUsage(owner, month, size)", "err", err, "owner", owner)
+	}
+}
+
+// addPaidUsage count the bytes of the orders paid by a receipt in the payment db transaction, the owner is the item signer
+func addPaidUsage(wdb *Wdb, ords []schema.Order, dbTx *gorm.DB) error {
+	month := usageMonth(time.Now())
+	owners := make([]string, 0)
+	usage := make(map[string]int64)
+	for _, ord := range ords {
+		if _, ok := usage[ord.Signer]; !ok {
+			owners = append(owners, ord.Signer)
+		}
+		usage[ord.Signer] += ord.Size
+	}
+	for _, owner := range owners {
+		if err := wdb.AddMonthlyUsage(owner, month, usage[owner], dbTx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package arseeding
+
+import (
+	cfgSchema "github.com/everFinance/arseeding/config/schema"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestApplyPricingPlan(t *testing.T) {
+	plan := cfgSchema.PricingPlan{
+		Name:     "partner",
+		Discount: 10,
+		Tiers:    []byte(`[{"minMonthlyBytes":1000,"discount":20},{"minMonthlyBytes":100000,"discount":50}]`),
+		MinFee:   map[string]interface{}{"USDC": "500"},
+	}
+	discount, err := plan.TierDiscount(10)
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, discount)
+	discount, err = plan.TierDiscount(100000)
+	assert.NoError(t, err)
+	assert.Equal(t, 50.0, discount)
+
+	fee, err := applyPricingPlan(&schema.RespFee{Currency: "AR", FinalFee: "1000"}, plan, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, "800", fee.FinalFee)
+	assert.Equal(t, "partner", fee.Plan)
+
+	// minimum fee
+	fee, err = applyPricingPlan(&schema.RespFee{Currency: "usdc", FinalFee: "800"}, plan, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, "500", fee.FinalFee)
+
+	sqliteDir := "./data/pricing"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	owner := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	assert.NoError(t, wdb.AddMonthlyUsage(owner, "202601", 100, nil))
+	assert.NoError(t, wdb.AddMonthlyUsage(owner, "202601", 200, nil))
+	bytes, err := wdb.GetMonthlyBytes(owner, "202601")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(300), bytes)
+	bytes, err = wdb.GetMonthlyBytes(owner, "202602")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), bytes)
+}
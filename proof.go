@@ -0,0 +1,191 @@
+package arseeding
+
+import (
+	"errors"
+	"fmt"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"strconv"
+)
+
+func (s *Arseeding) GetItemProof(itemId string) (*schema.RespItemProof, error) {
+	onChainTx, err := s.wdb.GetOnChainTxByItemId(itemId)
+	if err != nil {
+		return nil, err
+	}
+	txMeta, err := s.store.LoadTxMeta(onChainTx.ArId)
+	if err != nil || !s.store.IsExistTxDataEndOffset(txMeta.DataRoot, txMeta.DataSize) {
+		// bundle tx not in local, it is synced by task instead of the request
+		if s.taskMg.GetTask(onChainTx.ArId, schema.TaskTypeSync) == nil {
+			if err = s.registerTask(onChainTx.ArId, schema.TaskTypeSync); err != nil {
+				return nil, err
+			}
+		}
+		return nil, schema.ErrBundleSyncing
+	}
+
+	proof, err := generateItemProof(itemId, txMeta, s.store)
+	if err != nil {
+		return nil, err
+	}
+	proof.BlockId = onChainTx.BlockId
+	proof.BlockHeight = onChainTx.BlockHeight
+	return proof, nil
+}
+
+func generateItemProof(itemId string, txMeta *types.Transaction, db *Store) (*schema.RespItemProof, error) {
+	dataSize, err := strconv.ParseUint(txMeta.DataSize, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if dataSize < 32 {
+		return nil, errors.New("bundle data length must more than 32")
+	}
+
+	// 1. parse items num from the first chunk
+	firstChunks, err := loadTxChunks(txMeta.DataRoot, txMeta.DataSize, db, 0, 32)
+	if err != nil {
+		return nil, err
+	}
+	numBy, err := utils.Base64Decode(firstChunks[0].Chunk)
+	if err != nil {
+		return nil, err
+	}
+	if len(numBy) < 32 {
+		return nil, errors.New("first chunk length must more than 32")
+	}
+	itemsNum := utils.ByteArrayToLong(numBy[:32])
+	headerEnd := uint64(32 + itemsNum*64)
+	if headerEnd > dataSize {
+		return nil, errors.New("bundle header length incorrect")
+	}
+
+	// 2. find item in bundle header
+	headerChunks, err := loadTxChunks(txMeta.DataRoot, txMeta.DataSize, db, 0, headerEnd)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, headerEnd)
+	for _, chunk := range headerChunks {
+		chunkBy, err := utils.Base64Decode(chunk.Chunk)
+		if err != nil {
+			return nil, err
+		}
+		header = append(header, chunkBy...)
+	}
+	itemIdx := -1
+	start := headerEnd
+	itemSize := uint64(0)
+	for i := 0; i < itemsNum; i++ {
+		entry := header[32+i*64 : 32+(i+1)*64]
+		size := uint64(utils.ByteArrayToLong(entry[:32]))
+		if utils.Base64Encode(entry[32:]) == itemId {
+			itemIdx = i
+			itemSize = size
+			break
+		}
+		start += size
+	}
+	if itemIdx == -1 {
+		return nil, fmt.Errorf("item not in bundle; itemId: %s, arId: %s", itemId, txMeta.ID)
+	}
+	end := start + itemSize
+	if end > dataSize {
+		return nil, errors.New("item range out of bundle data")
+	}
+
+	// 3. only need header chunks which cover the item entry
+	entryEnd := uint64(32 + (itemIdx+1)*64)
+	headerProofs := make([]schema.ChunkProof, 0)
+	for _, chunk := range headerChunks {
+		headerProofs = append(headerProofs, schema.ChunkProof{
+			Offset:   chunk.Offset,
+			DataPath: chunk.DataPath,
+			Chunk:    chunk.Chunk,
+		})
+		offset, err := strconv.ParseUint(chunk.Offset, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if offset+1 >= entryEnd {
+			break
+		}
+	}
+
+	// 4. item range chunks, only the first one with data
+	itemChunks, err := loadTxChunks(txMeta.DataRoot, txMeta.DataSize, db, start, end)
+	if err != nil {
+		return nil, err
+	}
+	itemProofs := make([]schema.ChunkProof, 0, len(itemChunks))
+	for i, chunk := range itemChunks {
+		cp := schema.ChunkProof{
+			Offset:   chunk.Offset,
+			DataPath: chunk.DataPath,
+		}
+		// the first chunk is verified with data
+		if i == 0 {
+			cp.Chunk = chunk.Chunk
+		}
+		itemProofs = append(itemProofs, cp)
+	}
+
+	return &schema.RespItemProof{
+		ItemId:       itemId,
+		BundleId:     txMeta.ID,
+		DataRoot:     txMeta.DataRoot,
+		DataSize:     txMeta.DataSize,
+		ItemIndex:    itemIdx,
+		ItemCount:    itemsNum,
+		StartOffset:  int64(start),
+		EndOffset:    int64(end),
+		HeaderChunks: headerProofs,
+		ItemChunks:   itemProofs,
+	}, nil
+}
+
+// loadTxChunks returns the chunks which overlap the tx data range [start, end)
+func loadTxChunks(dataRoot, dataSize string, db *Store, start, end uint64) ([]types.GetChunk, error) {
+	size, err := strconv.ParseUint(dataSize, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	txDataEndOffset, err := db.LoadTxDataEndOffSet(dataRoot, dataSize)
+	if err != nil {
+		return nil, err
+	}
+	txDataStartOffset := txDataEndOffset - size + 1
+
+	// the chunks are MAX_CHUNK_SIZE except the last two, so the chunk before the one contains start begins at
+	// a multiple of MAX_CHUNK_SIZE. Read from the first chunk if the data is not chunked in this way
+	i := uint64(0)
+	if start >= 2*types.MAX_CHUNK_SIZE {
+		i = (start/types.MAX_CHUNK_SIZE - 1) * types.MAX_CHUNK_SIZE
+		if !db.IsExistChunk(txDataStartOffset + i) {
+			i = 0
+		}
+	}
+	chunks := make([]types.GetChunk, 0)
+	for i < size && i < end {
+		chunk, err := db.LoadChunk(txDataStartOffset + i)
+		if err != nil {
+			return nil, err
+		}
+		chunkData, err := utils.Base64Decode(chunk.Chunk)
+		if err != nil {
+			return nil, err
+		}
+		if len(chunkData) == 0 {
+			return nil, errors.New("chunk data is null")
+		}
+		if i+uint64(len(chunkData)) > start {
+			chunks = append(chunks, *chunk)
+		}
+		i += uint64(len(chunkData))
+	}
+	if len(chunks) == 0 {
+		return nil, schema.ErrNotExist
+	}
+	return chunks, nil
+}
@@ -0,0 +1,99 @@
+package arseeding
+
+import (
+	"crypto/rand"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/arseeding/sdk"
+	"github.com/everFinance/goar"
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"github.com/everFinance/goether"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestGenerateAndVerifyItemProof(t *testing.T) {
+	dbPath := "./data/proof.db"
+	defer os.RemoveAll(dbPath)
+	s, err := NewBoltStore(dbPath)
+	assert.NoError(t, err)
+	defer s.Close()
+	aa := &Arseeding{store: s}
+
+	signer, err := goether.NewSigner("2b8258cde747e3820e56a40aec5cd473150c6078819b45afe61baaf1fa1c75e6") // for test
+	assert.NoError(t, err)
+	itemSigner, err := goar.NewItemSigner(signer)
+	assert.NoError(t, err)
+	items := make([]types.BundleItem, 0)
+	// the chunks of last item are found from the middle of bundle
+	for _, size := range []int{300 * 1024, 10, 500 * 1024, 700 * 1024} {
+		data := make([]byte, size)
+		_, err = rand.Read(data)
+		assert.NoError(t, err)
+		item, err := itemSigner.CreateAndSignItem(data, "", "", nil)
+		assert.NoError(t, err)
+		items = append(items, item)
+	}
+	bundle, err := utils.NewBundle(items...)
+	assert.NoError(t, err)
+
+	arTx := types.Transaction{ID: "proof-test", DataSize: strconv.Itoa(len(bundle.BundleBinary))}
+	assert.NoError(t, utils.PrepareChunks(&arTx, bundle.BundleBinary, len(bundle.BundleBinary)))
+	assert.NoError(t, aa.syncAddTxDataEndOffset(arTx.DataRoot, arTx.DataSize))
+	assert.NoError(t, setTxDataChunks(arTx, bundle.BundleBinary, s))
+
+	for i, item := range items {
+		proof, err := generateItemProof(item.Id, &arTx, s)
+		assert.NoError(t, err)
+		assert.Equal(t, i, proof.ItemIndex)
+		assert.Equal(t, item.ItemBinary, bundle.BundleBinary[proof.StartOffset:proof.EndOffset])
+		assert.NoError(t, sdk.VerifyItemProof(*proof))
+
+		// the header chunk and the first item chunk must have data
+		chunk := proof.HeaderChunks[0].Chunk
+		proof.HeaderChunks[0].Chunk = ""
+		assert.Error(t, sdk.VerifyItemProof(*proof))
+		proof.HeaderChunks[0].Chunk = chunk
+		chunk = proof.ItemChunks[0].Chunk
+		proof.ItemChunks[0].Chunk = ""
+		assert.Error(t, sdk.VerifyItemProof(*proof))
+		proof.ItemChunks[0].Chunk = chunk
+
+		// tampered range must fail
+		proof.EndOffset += 1
+		assert.Error(t, sdk.VerifyItemProof(*proof))
+	}
+
+	_, err = generateItemProof("not-exist-item", &arTx, s)
+	assert.Error(t, err)
+}
+
+func TestGetOnChainTxByItemId(t *testing.T) {
+	sqliteDir := "./data/proof"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+
+	// the item bundled before the order is mapped to arTx
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-1", OnChainStatus: schema.SuccOnChain}))
+	assert.NoError(t, wdb.InsertArTx(schema.OnChainTx{ArId: "ar-1", Status: schema.SuccOnChain, ItemIds: []byte(`["item-1"]`)}))
+	tx, err := wdb.GetOnChainTxByItemId("item-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ar-1", tx.ArId)
+
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-2", OnChainStatus: schema.WaitOnChain}))
+	assert.NoError(t, wdb.InsertBundleArTx(schema.OnChainTx{ArId: "ar-2", Status: schema.PendingOnChain, ItemIds: []byte(`["item-2"]`)}, []string{"item-2"}, schema.PendingOnChain))
+	_, err = wdb.GetOnChainTxByItemId("item-2")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+	assert.NoError(t, wdb.UpdateArTxStatus("ar-2", schema.SuccOnChain, nil, nil))
+	assert.NoError(t, wdb.UpdateOrdOnChainStatus("item-2", schema.SuccOnChain, nil))
+	tx, err = wdb.GetOnChainTxByItemId("item-2")
+	assert.NoError(t, err)
+	assert.Equal(t, "ar-2", tx.ArId)
+
+	_, err = wdb.GetOnChainTxByItemId("item-3")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
@@ -0,0 +1,147 @@
+package arseeding
+
+import (
+	"errors"
+	"fmt"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"strings"
+	"time"
+)
+
+func (s *Arseeding) feeQuoteExpiration() int64 {
+	if s.config.Param.FeeQuoteExpiration > 0 {
+		return s.config.Param.FeeQuoteExpiration
+	}
+	return schema.DefaultFeeQuoteExpiration
+}
+
+// CreateFeeQuote lock the current fee of currency for an item not bigger than size, owner is the apikey owner or the item signer who pays the item
+func (s *Arseeding) CreateFeeQuote(currency string, size int64, owner string) (*schema.RespFeeQuote, error) {
+	if size <= 0 || size > schema.SubmitMaxSize {
+		return nil, errors.New("quote size incorrect")
+	}
+	if len(owner) == 0 {
+		return nil, errors.New("quote owner can not be null")
+	}
+	perFee := s.GetPerFee(currency)
+	if perFee == nil {
+		return nil, fmt.Errorf("not support currency: %s", currency)
+	}
+	if perFee.Stale {
+		return nil, fmt.Errorf("the price of currency is stale: %s", currency)
+	}
+	quote := schema.FeeQuote{
+		QuoteId:     uuid.NewString(),
+		Owner:       owner,
+		Currency:    strings.ToUpper(currency),
+		Decimals:    perFee.Decimals,
+		Size:        size,
+		Base:        perFee.Base.String(),
+		PerChunk:    perFee.PerChunk.String(),
+		ExpiredTime: time.Now().Unix() + s.feeQuoteExpiration(),
+		UsdPrice:    perFee.UsdPrice,
+		ArPrice:     perFee.ArPrice,
+	}
+	if err := s.wdb.InsertFeeQuote(quote); err != nil {
+		return nil, err
+	}
+	respFee, err := s.calcQuoteFee(quote, size, owner)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.quoteExpressFee(respFee); err != nil {
+		return nil, err
+	}
+	return &schema.RespFeeQuote{
+		RespFee:     *respFee,
+		QuoteId:     quote.QuoteId,
+		Size:        quote.Size,
+		ExpiredTime: quote.ExpiredTime,
+	}, nil
+}
+
+func (s *Arseeding) calcQuoteFee(quote schema.FeeQuote, itemSize int64, owner string) (*schema.RespFee, error) {
+	base, err := decimal.NewFromString(quote.Base)
+	if err != nil {
+		return nil, err
+	}
+	perChunk, err := decimal.NewFromString(quote.PerChunk)
+	if err != nil {
+		return nil, err
+	}
+	respFee := calcFeeByPerFee(schema.Fee{
+		Currency: quote.Currency,
+		Decimals: quote.Decimals,
+		Base:     base,
+		PerChunk: perChunk,
+		UsdPrice: quote.UsdPrice,
+		ArPrice:  quote.ArPrice,
+	}, itemSize)
+	return s.applyOwnerPlan(respFee, owner)
+}
+
+// releaseFeeQuote release the quote if the item is not accepted, the quote used by an accepted order is kept
+func (s *Arseeding) releaseFeeQuote(quoteId, itemId string) {
+	if quoteId == "" {
+		return
+	}
+	ords, err := s.wdb.GetOrdersByItemIds([]string{itemId})
+	if err != nil {
+		log.Error("s.wdb.GetOrdersByItemIds([]string{itemId})", "err", err, "itemId", itemId)
+		return
+	}
+	if len(ords) > 0 {
+		return
+	}
+	if err = s.wdb.ReleaseFeeQuote(quoteId, itemId); err != nil {
+		log.Error("s.wdb.ReleaseFeeQuote(quoteId, itemId)", "err", err, "quoteId", quoteId, "itemId", itemId)
+	}
+}
+
+// calcOrderFee use the locked fee if quoteId is not empty, otherwise the current fee. The express lane surcharge is added.
+// the quote is used by the item, it can not be used by other items. The quote is only checked if itemId is empty
+func (s *Arseeding) calcOrderFee(currency string, itemSize int64, owner, quoteId, itemId, lane string) (*schema.RespFee, error) {
+	if quoteId == "" {
+		respFee, err := s.CalcPlanItemFee(currency, itemSize, owner)
+		if err != nil {
+			return nil, err
+		}
+		return s.applyLane(respFee, lane)
+	}
+	quote, err := s.wdb.GetFeeQuote(quoteId)
+	if err != nil {
+		return nil, errors.New("fee quote not found")
+	}
+	if quote.ExpiredTime < time.Now().Unix() {
+		return nil, errors.New("fee quote expired")
+	}
+	if !strings.EqualFold(quote.Currency, currency) {
+		return nil, fmt.Errorf("fee quote currency is %s", quote.Currency)
+	}
+	if itemSize > quote.Size {
+		return nil, fmt.Errorf("item size %d bigger than the quoted size %d", itemSize, quote.Size)
+	}
+	if !strings.EqualFold(quote.Owner, owner) {
+		return nil, errors.New("fee quote is not created for the payer")
+	}
+	if itemId == "" {
+		if quote.ItemId != "" {
+			return nil, errors.New("fee quote has been used by other item")
+		}
+	} else {
+		ok, err := s.wdb.UseFeeQuote(quoteId, itemId)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("fee quote has been used by other item")
+		}
+	}
+	respFee, err := s.calcQuoteFee(quote, itemSize, owner)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyLane(respFee, lane)
+}
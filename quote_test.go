@@ -0,0 +1,72 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/config"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar/types"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestFeeQuote(t *testing.T) {
+	sqliteDir := "./data/quote"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	aa := &Arseeding{
+		wdb:    wdb,
+		config: &config.Config{},
+		bundlePerFeeMap: map[string]schema.Fee{
+			"AR": {Currency: "AR", Base: decimal.New(5, 0), PerChunk: decimal.New(1, 0)},
+		},
+	}
+
+	owner := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	_, err := aa.CreateFeeQuote("ar", 2*types.MAX_CHUNK_SIZE, "")
+	assert.Error(t, err) // the quote must be bound to the payer
+	quote, err := aa.CreateFeeQuote("ar", 2*types.MAX_CHUNK_SIZE, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, "7", quote.FinalFee)
+
+	// the price changed after quoting
+	aa.SetPerFee(map[string]schema.Fee{
+		"AR": {Currency: "AR", Base: decimal.New(10, 0), PerChunk: decimal.New(2, 0)},
+	})
+	_, err = aa.calcOrderFee("AR", 2*types.MAX_CHUNK_SIZE+1, owner, quote.QuoteId, "item-1", schema.LaneStandard)
+	assert.Error(t, err) // bigger than the quoted size
+	_, err = aa.calcOrderFee("USDC", 1, owner, quote.QuoteId, "item-1", schema.LaneStandard)
+	assert.Error(t, err)
+	_, err = aa.calcOrderFee("AR", 1, owner, "unknown", "item-1", schema.LaneStandard)
+	assert.Error(t, err)
+	_, err = aa.calcOrderFee("AR", 1, "0x0000000000000000000000000000000000000001", quote.QuoteId, "item-1", schema.LaneStandard)
+	assert.Error(t, err) // other payer
+
+	fee, err := aa.calcOrderFee("AR", types.MAX_CHUNK_SIZE, owner, quote.QuoteId, "item-1", schema.LaneStandard)
+	assert.NoError(t, err)
+	assert.Equal(t, "6", fee.FinalFee)
+	fee, err = aa.calcOrderFee("AR", types.MAX_CHUNK_SIZE, owner, "", "item-1", schema.LaneStandard)
+	assert.NoError(t, err)
+	assert.Equal(t, "12", fee.FinalFee)
+
+	// the quote is used by item-1 only
+	_, err = aa.calcOrderFee("AR", types.MAX_CHUNK_SIZE, owner, quote.QuoteId, "item-1", schema.LaneStandard)
+	assert.NoError(t, err)
+	_, err = aa.calcOrderFee("AR", types.MAX_CHUNK_SIZE, owner, quote.QuoteId, "item-2", schema.LaneStandard)
+	assert.Error(t, err)
+
+	// item-1 is not accepted, the quote is released
+	aa.releaseFeeQuote(quote.QuoteId, "item-1")
+	_, err = aa.calcOrderFee("AR", types.MAX_CHUNK_SIZE, owner, quote.QuoteId, "item-2", schema.LaneStandard)
+	assert.NoError(t, err)
+	// item-2 is accepted, the quote is kept
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-2"}))
+	aa.releaseFeeQuote(quote.QuoteId, "item-2")
+	_, err = aa.calcOrderFee("AR", types.MAX_CHUNK_SIZE, owner, quote.QuoteId, "item-3", schema.LaneStandard)
+	assert.Error(t, err)
+
+	aa.SetPerFee(map[string]schema.Fee{"AR": {Currency: "AR", Stale: true}})
+	_, err = aa.CreateFeeQuote("AR", 100, owner)
+	assert.Error(t, err)
+}
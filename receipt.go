@@ -0,0 +1,58 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar/utils"
+	"gorm.io/gorm"
+	"time"
+)
+
+func (s *Arseeding) SignUploadReceipt(ord schema.Order) (*schema.UploadReceipt, error) {
+	receipt := schema.UploadReceipt{
+		ItemId:         ord.ItemId,
+		Bundler:        s.bundler.Signer.Address,
+		Owner:          s.bundler.Signer.Owner(),
+		Currency:       ord.Currency,
+		Decimals:       ord.Decimals,
+		Fee:            ord.Fee,
+		DeadlineHeight: ord.ExpectedBlock,
+		Timestamp:      time.Now().Unix(),
+	}
+	msg, err := receipt.SignData()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := s.bundler.Signer.SignMsg(msg)
+	if err != nil {
+		return nil, err
+	}
+	receipt.Signature = utils.Base64Encode(sig)
+
+	if err = s.wdb.InsertUploadReceipt(receipt); err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// GetUploadReceipt the receipt failed to be signed at the upload is signed for the last live order of the item
+func (s *Arseeding) GetUploadReceipt(itemId string) (schema.UploadReceipt, error) {
+	receipt, err := s.wdb.GetUploadReceipt(itemId)
+	if err != gorm.ErrRecordNotFound {
+		return receipt, err
+	}
+	ords, err := s.wdb.GetOrdersByItemIds([]string{itemId})
+	if err != nil {
+		return schema.UploadReceipt{}, err
+	}
+	for i := len(ords) - 1; i >= 0; i-- {
+		if ords[i].PaymentStatus == schema.ExpiredPayment || ords[i].PaymentStatus == schema.CancelPayment {
+			continue
+		}
+		signed, err := s.SignUploadReceipt(ords[i])
+		if err != nil {
+			return schema.UploadReceipt{}, err
+		}
+		return *signed, nil
+	}
+	return schema.UploadReceipt{}, gorm.ErrRecordNotFound
+}
@@ -0,0 +1,52 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/arseeding/sdk"
+	"github.com/everFinance/goar"
+	"github.com/everFinance/goar/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"os"
+	"testing"
+)
+
+func TestSignUploadReceipt(t *testing.T) {
+	sqliteDir := "./data/receipt"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+
+	prvKey, err := utils.GenerateRsaKey(2048)
+	assert.NoError(t, err)
+	signer := goar.NewSignerByPrivateKey(prvKey)
+	aa := &Arseeding{wdb: wdb, bundler: &goar.Wallet{Signer: signer}}
+
+	receipt, err := aa.SignUploadReceipt(schema.Order{
+		ItemId:        "o2R8ZNyF4Ii5xB-uB3RQa3YSlyF0eX1PdI5dBL4v4Aw",
+		Currency:      "USDC",
+		Decimals:      6,
+		Fee:           "1200",
+		ExpectedBlock: 1200050,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, sdk.VerifyUploadReceipt(*receipt, signer.Address))
+
+	stored, err := wdb.GetUploadReceipt(receipt.ItemId)
+	assert.NoError(t, err)
+	assert.NoError(t, sdk.VerifyUploadReceipt(stored, signer.Address))
+
+	stored.DeadlineHeight += 100
+	assert.Error(t, sdk.VerifyUploadReceipt(stored, signer.Address))
+	assert.Error(t, sdk.VerifyUploadReceipt(*receipt, "other-bundler"))
+
+	// the receipt which failed to be signed at the upload is signed when it is queried
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-1", Currency: "USDC", Decimals: 6, Fee: "100", ExpectedBlock: 100, PaymentStatus: schema.SuccPayment}))
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-2", PaymentStatus: schema.ExpiredPayment}))
+	stored, err = aa.GetUploadReceipt("item-1")
+	assert.NoError(t, err)
+	assert.NoError(t, sdk.VerifyUploadReceipt(stored, signer.Address))
+	assert.Equal(t, "100", stored.Fee)
+	_, err = aa.GetUploadReceipt("item-2")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
@@ -0,0 +1,52 @@
+package schema
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+const (
+	// admin audit actions
+	AdminActionRetryRefund  = "retryRefund"
+	AdminActionManualRefund = "manualRefund"
+	AdminActionCredit       = "credit"
+	AdminActionUsdCredit    = "usdCredit"
+	AdminActionSetPrice     = "setPrice"
+
+	MaxAdminPageSize = 200
+)
+
+// AdminAudit record every admin operation
+type AdminAudit struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Operator string         `gorm:"index:idxAdminAudit0" json:"operator"` // admin session address
+	Action   string         `json:"action"`
+	Target   string         `gorm:"index:idxAdminAudit1" json:"target"` // receipt everHash
+	Request  datatypes.JSON `json:"request"`
+	Result   string         `json:"result"` // refund hash, credited address, etc
+	ErrMsg   string         `json:"errMsg"`
+}
+
+type ReqAdminManualRefund struct {
+	RefundHash string `json:"refundHash"` // the external refund tx hash
+	Remark     string `json:"remark"`
+}
+
+type ReqAdminCredit struct {
+	Address string `json:"address"` // apikey address, default is the receipt payer
+	Remark  string `json:"remark"`
+}
+
+type ReqAdminUsdCredit struct {
+	Amount    string `json:"amount"`    // USD, e.g. "100.50"
+	Reference string `json:"reference"` // invoice or contract number of the credit
+	Remark    string `json:"remark"`
+}
+
+type ReqAdminSetPrice struct {
+	Price     float64 `json:"price"`     // USD
+	ManualSet bool    `json:"manualSet"` // true: the price is not updated by oracle until it is set again with false
+	Remark    string  `json:"remark"`
+}
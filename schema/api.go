@@ -8,6 +8,7 @@ const (
 	AllowStreamMinItemSize = 5 * 1024 * 1024    // 5 MB
 	AllowMaxRespDataSize   = 50 * 1024 * 1024   // 50 MB
 	SubmitMaxSize          = 1024 * 1024 * 1024 // 1 GB
+	FormDataMaxOverhead    = 1024 * 1024        // 1 MB, boundaries, part headers and fields of multipart form
 )
 
 type RespReceiptEverTx struct {
@@ -28,6 +29,9 @@ type RespOrder struct {
 	Fee                string `json:"fee"`
 	PaymentExpiredTime int64  `json:"paymentExpiredTime"`
 	ExpectedBlock      int64  `json:"expectedBlock"`
+	Lane               string `json:"lane"`
+
+	Receipt *UploadReceipt `json:"receipt,omitempty"` // signed by bundler
 }
 
 type RespGetOrder struct {
@@ -44,17 +48,33 @@ type RespItemId struct {
 	Size   int64  `json:"size"`
 }
 
+type RespItemIds struct {
+	Items []RespItemId `json:"items"`
+}
+
 type Fee struct {
 	Currency string          `json:"currency"`
 	Decimals int             `json:"decimals"`
 	Base     decimal.Decimal `json:"base"`
 	PerChunk decimal.Decimal `json:"perChunk"`
+	Stale    bool            `json:"stale,omitempty"` // quoting is refused if the price is stale
+	UsdPrice float64         `json:"usdPrice"`        // USD price of the currency
+	ArPrice  float64         `json:"arPrice"`         // USD price of AR, the fee is converted from AR by ArPrice/UsdPrice
+
+	ExpressSurcharge int64 `json:"expressSurcharge"` // percentage of the fee added for the express lane
 }
 
 type RespFee struct {
-	Currency string `json:"currency"`
-	Decimals int    `json:"decimals"`
-	FinalFee string `json:"finalFee"` // uint
+	Currency string  `json:"currency"`
+	Decimals int     `json:"decimals"`
+	FinalFee string  `json:"finalFee"`           // uint
+	Plan     string  `json:"plan,omitempty"`     // the pricing plan applied
+	UsdFee   string  `json:"usdFee,omitempty"`   // FinalFee in USD
+	UsdPrice float64 `json:"usdPrice,omitempty"` // USD price of the currency used
+	ArPrice  float64 `json:"arPrice,omitempty"`  // USD price of AR used
+
+	ExpressFee    string `json:"expressFee,omitempty"`    // the fee of express lane
+	ExpressUsdFee string `json:"expressUsdFee,omitempty"` // ExpressFee in USD
 }
 
 type ResBundler struct {
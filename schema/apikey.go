@@ -1,10 +1,13 @@
 package schema
 
 import (
+	"encoding/json"
 	"gorm.io/datatypes"
 	"time"
 )
 
+const SubKeyPrefixLen = 8
+
 type AutoApiKey struct {
 	ID        uint      `gorm:"primarykey" json:"id"`
 	CreatedAt time.Time `json:"createdAt"`
@@ -16,4 +19,45 @@ type AutoApiKey struct {
 	Address      string `gorm:"index:apikey02,unique"`
 	PubKey       string
 	TokenBalance datatypes.JSONMap // key: symbol,val: balance
+	Revoked      bool              // revoked apikey can not be used until it is rotated
+}
+
+// ApiSubKey named key draw on the balance of parent apikey
+type ApiSubKey struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	SubKey      string            `gorm:"index:idxSubKey0,unique" json:"-"`                        // the secret is only returned when it is created
+	Prefix      string            `json:"prefix"`                                                  // the first SubKeyPrefixLen chars of sub-key
+	Address     string            `gorm:"index:idxSubKey1;index:idxSubKey2,unique" json:"address"` // parent apikey address
+	Name        string            `gorm:"index:idxSubKey2,unique" json:"name"`                     // unique per address, such as "ci", "staging"
+	Currencies  datatypes.JSON    `json:"currencies"`                                              // json.marshal([]string), null means all currencies are allowed
+	SpendCap    datatypes.JSONMap `json:"spendCap"`                                                // key: symbol, val: max spend amount; no cap if the currency not in map
+	Spent       datatypes.JSONMap `json:"spent"`                                                   // key: symbol, val: spent amount
+	ExpiredTime int64             `json:"expiredTime"`                                             // unit s, 0 means never expired
+	Revoked     bool              `json:"revoked"`
+}
+
+func (k ApiSubKey) AllowedCurrencies() ([]string, error) {
+	currencies := make([]string, 0)
+	if len(k.Currencies) == 0 {
+		return currencies, nil
+	}
+	err := json.Unmarshal(k.Currencies, &currencies)
+	return currencies, err
+}
+
+type ReqSubKey struct {
+	Name        string            `json:"name"`
+	Currencies  []string          `json:"currencies"`
+	SpendCap    map[string]string `json:"spendCap"`
+	ExpiredTime int64             `json:"expiredTime"`
+}
+
+type RespApiKeyAction struct {
+	Address string      `json:"address"`
+	ApiKey  string      `json:"apiKey,omitempty"`
+	SubKey  string      `json:"subKey,omitempty"` // the secret of created sub-key
+	SubKeys []ApiSubKey `json:"subKeys,omitempty"`
 }
@@ -0,0 +1,71 @@
+package schema
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"time"
+)
+
+const (
+	// wallet signature type
+	SigTypeEthereum = "ethereum" // EIP-712 typed data
+	SigTypeArweave  = "arweave"  // RSA-PSS, by arweave wallet
+	SigTypeSolana   = "solana"   // ed25519
+
+	AuthNonceExpiredRange   = int64(300)   // 5 mins
+	AuthSessionExpiredRange = int64(86400) // 1 day
+
+	AuthDomainName    = "arseeding"
+	AuthDomainVersion = "1"
+)
+
+// AuthNonce is the login challenge, it can only be used once
+type AuthNonce struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Nonce       string `gorm:"index:idxAuthNonce0,unique" json:"nonce"`
+	Address     string `json:"address"`
+	SigType     string `json:"sigType"`
+	ExpiredTime int64  `json:"expiredTime"` // unit s
+	Used        bool   `json:"used"`
+}
+
+type AuthSession struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Token       string `gorm:"index:idxAuthSession0,unique" json:"-"`
+	Address     string `gorm:"index:idxAuthSession1" json:"address"`
+	SigType     string `json:"sigType"`
+	ExpiredTime int64  `gorm:"index:idxAuthSession2" json:"expiredTime"` // unit s
+}
+
+// AuthMessage is the text signed by arweave and solana wallets
+func AuthMessage(address, bundler, nonce string, expiredTime int64) string {
+	return fmt.Sprintf("%s wants you to sign in with your account:\n%s\n\nBundler: %s\nNonce: %s\nExpiration Time: %d", AuthDomainName, address, bundler, nonce, expiredTime)
+}
+
+type RespAuthNonce struct {
+	Address     string              `json:"address"`
+	SigType     string              `json:"sigType"`
+	Nonce       string              `json:"nonce"`
+	ExpiredTime int64               `json:"expiredTime"`
+	Message     string              `json:"message,omitempty"`   // for arweave and solana
+	TypedData   *apitypes.TypedData `json:"typedData,omitempty"` // for ethereum
+}
+
+type ReqAuthLogin struct {
+	Address   string `json:"address"`
+	SigType   string `json:"sigType"`
+	Nonce     string `json:"nonce"`
+	PublicKey string `json:"publicKey"` // arweave wallet owner, base64url
+	Signature string `json:"signature"` // ethereum: hex, arweave: base64url, solana: base58
+}
+
+type RespAuthSession struct {
+	Token       string `json:"token"`
+	Address     string `json:"address"`
+	SigType     string `json:"sigType"`
+	ExpiredTime int64  `json:"expiredTime"`
+}
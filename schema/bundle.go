@@ -3,4 +3,9 @@ package schema
 const (
 	DefaultPaymentExpiredRange = int64(2592000) // 30 days
 	DefaultExpectedRange       = 50             // block height range
+	DefaultBundleMaxWait       = int64(3600)    // 1 hour
+
+	// bundle packing order
+	BundleOrderByAge = "age"
+	BundleOrderByFee = "fee"
 )
@@ -0,0 +1,15 @@
+package schema
+
+const (
+	// bundle arTx tags presets
+	BundleTagsPresetArseedingU = "arseeding-u" // arseeding tags and the U mint interaction
+	BundleTagsPresetMinimal    = "minimal"     // arseeding tags only
+	BundleTagsCustom           = "custom"      // the template of config is used
+
+	// placeholders of bundle tags template
+	BundleTagItemCount = "{itemCount}"
+	BundleTagTotalSize = "{totalSize}" // bundle size in bytes
+	BundleTagVersion   = "{version}"   // arseeding version
+	BundleTagBundler   = "{bundler}"   // bundler address
+	BundleTagLane      = "{lane}"
+)
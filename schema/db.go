@@ -16,6 +16,7 @@ const (
 	UnPayment      = "unpaid"
 	SuccPayment    = "paid"
 	ExpiredPayment = "expired"
+	CancelPayment  = "cancelled" // the unpaid order is cancelled by signer
 
 	// ReceiptEverTx Status
 	UnSpent   = "unspent"
@@ -50,8 +51,10 @@ type Order struct {
 
 	OnChainStatus string `gorm:"index:idx5" json:"onChainStatus"` // "waiting","pending","success","failed"
 	ApiKey        string `gorm:"index:idx2" json:"-"`
-	Sort          bool   `json:"sort"`                     // upload items to arweave by sequence
-	Kafka         bool   `gorm:"index:idx0"  json:"kafka"` // send to kafka
+	Sort          bool   `json:"sort"`                         // upload items to arweave by sequence
+	Lane          string `gorm:"default:standard" json:"lane"` // "standard" or "express"
+	Kafka         bool   `gorm:"index:idx0"  json:"kafka"`     // send to kafka
+	OnChainTxId   uint   `json:"-"`                            // id of the OnChainTx which bundles the item
 }
 
 type ReceiptEverTx struct {
@@ -65,8 +68,10 @@ type ReceiptEverTx struct {
 	Data     string
 	Sig      string
 
-	Status string //  "unspent","spent", "unrefund", "refund"
-	ErrMsg string
+	Status     string //  "unspent","spent", "unrefund", "refund"
+	ErrMsg     string
+	RefundHash string // refund tx hash, it is set by refund job or admin
+	Provider   string `gorm:"index:idxEverTx0;default:everpay"` // payment provider name, "everpay" or "arweave"
 }
 
 type TokenPrice struct {
@@ -89,4 +94,6 @@ type OnChainTx struct {
 	ItemIds     datatypes.JSON // json.marshal(itemIds)
 	ItemNum     int
 	Kafka       bool
+	Bundler     string `gorm:"index:idxOnChainTx0"` // the wallet address which signed the arTx
+	Lane        string `gorm:"default:standard"`    // the lane of bundled items, the failed arTx is retried in the same lane
 }
@@ -0,0 +1,60 @@
+package schema
+
+import "time"
+
+const (
+	MaxEconomicsReportDays = 31
+	EconomicsUsdDecimals   = 6 // the USD values in report are rounded to 6 decimals
+)
+
+// TokenPriceSnapshot is the hourly history of TokenPrice, it is used to convert the past cost and revenue to USD
+type TokenPriceSnapshot struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index:idxPriceSnapshot0" json:"createdAt"`
+
+	Symbol string  `gorm:"index:idxPriceSnapshot0" json:"symbol"`
+	Price  float64 `json:"price"` // unit is USD
+}
+
+// EconomicsReport compare the AR spent by the bundles with the fee of their items, the USD values are strings
+type EconomicsReport struct {
+	Start               string              `json:"start"` // yyyyMMdd, UTC
+	End                 string              `json:"end"`
+	Total               EconomicsDay        `json:"total"`
+	Days                []EconomicsDay      `json:"days"`
+	UnprofitableBundles []EconomicsBundle   `json:"unprofitableBundles"`
+	Currencies          []EconomicsCurrency `json:"currencies"` // revenue of the whole range by currency
+}
+
+type EconomicsDay struct {
+	Date                string              `json:"date"` // yyyyMMdd
+	Bundles             int64               `json:"bundles"`
+	Items               int64               `json:"items"`
+	Bytes               int64               `json:"bytes"`
+	ArCost              string              `json:"arCost"` // winston
+	CostUsd             string              `json:"costUsd"`
+	RevenueUsd          string              `json:"revenueUsd"`
+	MarginUsd           string              `json:"marginUsd"`
+	CostPerGbUsd        string              `json:"costPerGbUsd"`
+	UnprofitableBundles int64               `json:"unprofitableBundles"`
+	Currencies          []EconomicsCurrency `json:"currencies"`
+}
+
+type EconomicsCurrency struct {
+	Currency   string `json:"currency"`
+	Decimals   int    `json:"decimals"`
+	Items      int64  `json:"items"`
+	Revenue    string `json:"revenue"` // the smallest unit
+	RevenueUsd string `json:"revenueUsd"`
+}
+
+type EconomicsBundle struct {
+	ArId       string `json:"arId"`
+	Date       string `json:"date"`
+	Items      int64  `json:"items"`
+	Bytes      int64  `json:"bytes"`
+	ArCost     string `json:"arCost"`
+	CostUsd    string `json:"costUsd"`
+	RevenueUsd string `json:"revenueUsd"`
+	MarginUsd  string `json:"marginUsd"`
+}
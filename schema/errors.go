@@ -18,4 +18,10 @@ var (
 	ErrLocalNotExist = errors.New("not_exist_local") // need to get data from gateway
 	ErrPageNotFound  = errors.New("page_not_found")  // e.g manifest data not contain index path
 	ErrNotImplement  = errors.New("method not implement")
+
+	ErrOrderNotUnpaid = errors.New("order_not_unpaid") // the order is paid, expired or cancelled
+	ErrBundleSyncing  = errors.New("bundle_syncing")   // the bundle is syncing to local, retry later
+
+	ErrIdempotencyKeyReused    = errors.New("Idempotency-Key has been used by other request")
+	ErrIdempotencyKeyInProcess = errors.New("request with the Idempotency-Key is processing")
 )
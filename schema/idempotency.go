@@ -0,0 +1,29 @@
+package schema
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+const (
+	// idempotency record status
+	IdempotencyProcessing = "processing"
+	IdempotencyCompleted  = "completed"
+
+	IdempotencyExpiredRange = int64(86400) // 1 day
+)
+
+// IdempotencyRecord keep the response of the request with Idempotency-Key, retries of the request return the response directly
+type IdempotencyRecord struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Scope          string         `gorm:"index:idxIdempotency2,unique" json:"-"` // the apikey, or the upload token if the request is paid by it
+	IdempotencyKey string         `gorm:"index:idxIdempotency2,unique" json:"idempotencyKey"`
+	Request        string         `json:"request"`  // method + path, the key can not be reused by other request
+	BodyHash       string         `json:"bodyHash"` // sha256 of the request body, the key can not be reused by other body
+	Status         string         `json:"status"`
+	Response       datatypes.JSON `json:"response"`
+	ExpiredTime    int64          `gorm:"index:idxIdempotency1" json:"expiredTime"` // unit s
+}
@@ -0,0 +1,46 @@
+package schema
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+const (
+	// invoice status
+	InvoiceUnpaid = "unpaid"
+	InvoicePaid   = "paid"
+
+	MaxInvoiceItems = 10000
+)
+
+// Invoice group the unpaid orders of items, the payment data only reference the InvoiceId
+type Invoice struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	InvoiceId   string         `gorm:"index:idxInvoice0,unique" json:"invoiceId"`
+	Currency    string         `json:"currency"`
+	Decimals    int            `json:"decimals"`
+	Amount      string         `json:"amount"`  // total fee of orders
+	ItemIds     datatypes.JSON `json:"itemIds"` // json.marshal(itemIds)
+	Status      string         `json:"status"`
+	PaymentId   string         `json:"paymentId"`   // everHash
+	ExpiredTime int64          `json:"expiredTime"` // the earliest payment expired time of orders
+}
+
+type ReqCreateInvoice struct {
+	Currency string   `json:"currency"`
+	ItemIds  []string `json:"itemIds"`
+}
+
+type RespInvoice struct {
+	InvoiceId   string `json:"invoiceId"`
+	Bundler     string `json:"bundler"`
+	Currency    string `json:"currency"`
+	Decimals    int    `json:"decimals"`
+	Amount      string `json:"amount"`
+	ItemCount   int    `json:"itemCount"`
+	Status      string `json:"status"`
+	PaymentId   string `json:"paymentId"`
+	ExpiredTime int64  `json:"expiredTime"`
+}
@@ -0,0 +1,10 @@
+package schema
+
+const (
+	// the lane of order, the express items are bundled at once with a higher arTx reward
+	LaneStandard = "standard"
+	LaneExpress  = "express"
+
+	DefaultExpressSurcharge   = int64(50) // percentage of the standard fee
+	DefaultExpressSpeedFactor = int64(50) // percentage of the arTx reward
+)
@@ -0,0 +1,43 @@
+package schema
+
+import "time"
+
+const (
+	// ledger entry type
+	LedgerCredit = "credit" // deposit to apikey
+	LedgerDebit  = "debit"  // apikey pay for order
+	LedgerRefund = "refund" // return fee to apikey
+
+	// ledger accounts, apikey account is "apikey:" + address
+	LedgerAccountDeposit = "deposit" // funds from everPay
+	LedgerAccountRevenue = "revenue" // bundler fee income
+	LedgerAccountApikey  = "apikey:"
+	LedgerAccountOpening = "opening" // balance deposited before the ledger existed
+
+	LedgerOpeningReference = "opening_balance"
+)
+
+// LedgerEntry moves Amount from DebitAccount to CreditAccount, Balance is the apikey currency balance after the entry
+type LedgerEntry struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Address       string `gorm:"index:idxLedger0" json:"address"` // apikey owner
+	Currency      string `json:"currency"`
+	Type          string `json:"type"` // "credit", "debit", "refund"
+	DebitAccount  string `json:"debitAccount"`
+	CreditAccount string `json:"creditAccount"`
+	Amount        string `json:"amount"`
+	Balance       string `json:"balance"`
+	Reference     string `gorm:"index:idxLedger1" json:"reference"` // everHash for credit, itemId for debit and refund
+	SubKey        string `json:"subKey,omitempty"`                  // name of the sub-key which spent the balance
+
+	// conversion rates used by debit, the fee in AR is converted to Currency at ArPrice/UsdPrice
+	UsdPrice string `json:"usdPrice,omitempty"` // USD price of Currency
+	ArPrice  string `json:"arPrice,omitempty"`  // USD price of AR
+}
+
+type RespLedgerEntry struct {
+	LedgerEntry
+	Decimals int `json:"decimals"`
+}
@@ -0,0 +1,16 @@
+package schema
+
+const (
+	// payment providers
+	PaymentProviderEverPay = "everpay"
+	PaymentProviderArweave = "arweave"
+
+	// native AR payment tx tags, the Item-Ids value is itemIds joined by ","
+	ArPaymentAppNameTag = "App-Name"
+	ArPaymentActionTag  = "Arseeding-Action"
+	ArPaymentItemIdsTag = "Arseeding-Item-Ids"
+	ArPaymentInvoiceTag = "Arseeding-Invoice-Id" // pay the invoice instead of Item-Ids
+
+	ArPaymentConfirmations = int64(10) // only confirmed AR transfers are accepted
+	ArPaymentPageSize      = 100
+)
@@ -0,0 +1,9 @@
+package schema
+
+// MonthlyUsage the bytes submitted by owner in a month, it is used by volume tier pricing
+type MonthlyUsage struct {
+	ID    uint   `gorm:"primarykey" json:"id"`
+	Owner string `gorm:"index:idxMonthlyUsage0,unique" json:"owner"` // apikey owner address or item signer address
+	Month string `gorm:"index:idxMonthlyUsage0,unique" json:"month"` // e.g. "202601"
+	Bytes int64  `json:"bytes"`
+}
@@ -0,0 +1,25 @@
+package schema
+
+type ChunkProof struct {
+	Offset   string `json:"offset"`          // chunk end offset in bundle data
+	DataPath string `json:"dataPath"`        // merkle path under data_root
+	Chunk    string `json:"chunk,omitempty"` // chunk data, only for bundle header chunks
+}
+
+type RespItemProof struct {
+	ItemId    string `json:"itemId"`
+	BundleId  string `json:"bundleId"` // arTx id
+	DataRoot  string `json:"dataRoot"`
+	DataSize  string `json:"dataSize"`
+	ItemIndex int    `json:"itemIndex"` // index of item in bundle header
+	ItemCount int    `json:"itemCount"`
+
+	StartOffset int64 `json:"startOffset"` // item first byte in bundle data
+	EndOffset   int64 `json:"endOffset"`   // exclusive
+
+	HeaderChunks []ChunkProof `json:"headerChunks"` // prove item id and size in bundle header
+	ItemChunks   []ChunkProof `json:"itemChunks"`   // prove item byte range
+
+	BlockId     string `json:"blockId"`
+	BlockHeight int64  `json:"blockHeight"`
+}
@@ -0,0 +1,34 @@
+package schema
+
+import "time"
+
+const (
+	DefaultFeeQuoteExpiration = int64(10 * 60) // 10 min
+)
+
+// FeeQuote lock the base and per chunk fee of a currency until ExpiredTime,
+// the quote can be used by one item not bigger than Size and paid by Owner
+type FeeQuote struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	QuoteId     string `gorm:"index:idxQuote0,unique" json:"quoteId"`
+	Owner       string `json:"owner"`  // the apikey owner or the item signer
+	ItemId      string `json:"itemId"` // the item which used the quote
+	Currency    string `json:"currency"`
+	Decimals    int    `json:"decimals"`
+	Size        int64  `json:"size"`                               // max item size
+	Base        string `json:"base"`                               // locked base fee
+	PerChunk    string `json:"perChunk"`                           // locked per chunk fee
+	ExpiredTime int64  `gorm:"index:idxQuote1" json:"expiredTime"` // unit s
+
+	UsdPrice float64 `json:"usdPrice"` // locked conversion rates
+	ArPrice  float64 `json:"arPrice"`
+}
+
+type RespFeeQuote struct {
+	RespFee
+	QuoteId     string `json:"quoteId"`
+	Size        int64  `json:"size"`
+	ExpiredTime int64  `json:"expiredTime"`
+}
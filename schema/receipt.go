@@ -0,0 +1,37 @@
+package schema
+
+import "encoding/json"
+
+type UploadReceipt struct {
+	ID             uint   `gorm:"primarykey" json:"-"`
+	ItemId         string `gorm:"index:idxReceipt0" json:"itemId"`
+	Bundler        string `json:"bundler"` // bundler address
+	Owner          string `json:"owner"`   // bundler public key, base64url
+	Currency       string `json:"currency"`
+	Decimals       int    `json:"decimals"`
+	Fee            string `json:"fee"`
+	DeadlineHeight int64  `json:"deadlineHeight"` // bundler commit to post item on chain before this height
+	Timestamp      int64  `json:"timestamp"`      // unit s
+	Signature      string `json:"signature"`      // bundler signature of SignData, base64url
+}
+
+// SignData returns the message signed by bundler wallet
+func (r UploadReceipt) SignData() ([]byte, error) {
+	return json.Marshal(struct {
+		ItemId         string `json:"itemId"`
+		Bundler        string `json:"bundler"`
+		Currency       string `json:"currency"`
+		Decimals       int    `json:"decimals"`
+		Fee            string `json:"fee"`
+		DeadlineHeight int64  `json:"deadlineHeight"`
+		Timestamp      int64  `json:"timestamp"`
+	}{
+		ItemId:         r.ItemId,
+		Bundler:        r.Bundler,
+		Currency:       r.Currency,
+		Decimals:       r.Decimals,
+		Fee:            r.Fee,
+		DeadlineHeight: r.DeadlineHeight,
+		Timestamp:      r.Timestamp,
+	})
+}
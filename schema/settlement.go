@@ -0,0 +1,22 @@
+package schema
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+// PaymentSettlement record how an item payment receipt is split between orders and apikey balance
+type PaymentSettlement struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	EverHash      string         `gorm:"index:idxSettlement0,unique" json:"everHash"` // receipt everHash or ar tx id
+	Provider      string         `json:"provider"`
+	Address       string         `gorm:"index:idxSettlement1" json:"address"` // payer apikey address, empty if nothing credited
+	Currency      string         `json:"currency"`
+	Amount        string         `json:"amount"`        // receipt amount
+	PaidAmount    string         `json:"paidAmount"`    // sum of paid orders fee
+	CreditAmount  string         `json:"creditAmount"`  // credited to apikey balance
+	PaidItemIds   datatypes.JSON `json:"paidItemIds"`   // json.marshal([]string)
+	UnpaidItemIds datatypes.JSON `json:"unpaidItemIds"` // missing, currency mismatch or not enough amount
+}
@@ -0,0 +1,34 @@
+package schema
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+const (
+	StatementFormatJSON = "json"
+	StatementFormatCSV  = "csv"
+)
+
+// BillingStatement is the monthly statement of an apikey, Month is UTC "200601"
+type BillingStatement struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Address    string         `gorm:"index:idxStatement0,unique" json:"address"` // apikey owner
+	Month      string         `gorm:"index:idxStatement0,unique" json:"month"`
+	ItemCount  int64          `json:"itemCount"` // items paid by the apikey balance
+	Bytes      int64          `json:"bytes"`
+	Currencies datatypes.JSON `json:"currencies"` // []StatementCurrency
+}
+
+// StatementCurrency amounts are in the smallest unit of currency
+type StatementCurrency struct {
+	Currency       string `json:"currency"`
+	Decimals       int    `json:"decimals"`
+	OpeningBalance string `json:"openingBalance"`
+	Deposits       string `json:"deposits"` // ledger credits, e.g. everPay deposits, settlement and admin credits
+	Fees           string `json:"fees"`     // ledger debits
+	Refunds        string `json:"refunds"`  // fees returned
+	ClosingBalance string `json:"closingBalance"`
+}
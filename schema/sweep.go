@@ -0,0 +1,40 @@
+package schema
+
+import "time"
+
+const (
+	// fee sweep status
+	FeeSweepSuccess = "success"
+	FeeSweepFailed  = "failed"
+	FeeSweepSkipped = "skipped" // only returned by dry-run
+	FeeSweepDryRun  = "dryRun"  // the sweep would be executed
+
+	DefaultSweepInterval = int64(24 * 60 * 60)
+)
+
+// FeeBalance is a token balance of the bundler in payment provider, Amount is in the smallest unit
+type FeeBalance struct {
+	Symbol   string `json:"symbol"`
+	Tag      string `json:"tag"`
+	Decimals int    `json:"decimals"`
+	Amount   string `json:"amount"`
+}
+
+// FeeSweep record a sweep of the collected fee, Amount = Balance - PendingRefund - MinReserve
+type FeeSweep struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Provider      string `gorm:"index:idxFeeSweep0" json:"provider"`
+	Symbol        string `json:"symbol"`
+	Tag           string `gorm:"index:idxFeeSweep0" json:"tag"`
+	Decimals      int    `json:"decimals"`
+	Balance       string `json:"balance"`
+	PendingRefund string `json:"pendingRefund"` // the receipts which may be refunded
+	MinReserve    string `json:"minReserve"`
+	Amount        string `json:"amount"`
+	Destination   string `json:"destination"`
+	TxHash        string `json:"txHash"`
+	Status        string `json:"status"`
+	Reason        string `json:"reason"` // skipped reason or error message
+}
@@ -0,0 +1,57 @@
+package schema
+
+import (
+	"encoding/json"
+	"github.com/everFinance/goar/types"
+	"gorm.io/datatypes"
+	"time"
+)
+
+const (
+	// upload token status
+	UploadTokenUnused = "unused"
+	UploadTokenUsing  = "using" // the upload request is processing
+	UploadTokenUsed   = "used"
+
+	DefaultUploadTokenExpiration = int64(600)   // 10 mins
+	MaxUploadTokenExpiration     = int64(86400) // 1 day
+)
+
+// UploadToken minted by apikey owner, browser clients can submit data once with it instead of X-API-KEY
+type UploadToken struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Token       string         `gorm:"index:idxToken0,unique" json:"token"`
+	ApiKey      string         `gorm:"index:idxToken1" json:"-"`
+	Currency    string         `json:"currency"`
+	MaxSize     int64          `json:"maxSize"`
+	Tags        datatypes.JSON `json:"tags"`        // json.marshal(tags), forced tags of the uploaded items
+	ExpiredTime int64          `json:"expiredTime"` // unit s
+	Status      string         `json:"status"`
+}
+
+func (t UploadToken) ForcedTags() ([]types.Tag, error) {
+	tags := make([]types.Tag, 0)
+	if len(t.Tags) == 0 {
+		return tags, nil
+	}
+	err := json.Unmarshal(t.Tags, &tags)
+	return tags, err
+}
+
+type ReqUploadToken struct {
+	Currency   string      `json:"currency"`
+	MaxSize    int64       `json:"maxSize"`
+	Tags       []types.Tag `json:"tags"`
+	Expiration int64       `json:"expiration"` // unit s, default 600
+}
+
+type RespUploadToken struct {
+	Token       string      `json:"token"`
+	Currency    string      `json:"currency"`
+	MaxSize     int64       `json:"maxSize"`
+	Tags        []types.Tag `json:"tags"`
+	ExpiredTime int64       `json:"expiredTime"`
+}
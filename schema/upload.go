@@ -0,0 +1,54 @@
+package schema
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+const (
+	// upload session type
+	UploadSessionItem = "item" // parts are signed bundle item binary
+	UploadSessionData = "data" // parts are native data, signed by bundler with X-API-KEY
+
+	// upload session status
+	SessionUploading  = "uploading"
+	SessionFinalizing = "finalizing"
+	SessionFinalized  = "finalized"
+	SessionExpired    = "expired"
+
+	UploadMaxPartSize         = 100 * 1024 * 1024 // 100 MB
+	UploadSessionExpiredRange = int64(86400)      // 1 day
+	UploadSessionFinalizeTime = int64(3600)       // the session is left in finalizing by crash if it is not finalized in 1 hour
+)
+
+type UploadSession struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	SessionId   string         `gorm:"index:idxSession0,unique" json:"sessionId"`
+	Type        string         `json:"type"` // "item", "data"
+	Currency    string         `json:"currency"`
+	ApiKey      string         `json:"-"`
+	Sort        bool           `json:"sort"`
+	Tags        datatypes.JSON `json:"tags"` // json.marshal(tags), only for data session
+	Status      string         `gorm:"index:idxSession1" json:"status"`
+	ExpiredTime int64          `json:"expiredTime"` // unit s
+	ItemId      string         `json:"itemId"`      // set before the item is charged
+}
+
+type UploadPart struct {
+	PartNum int   `json:"partNum"` // start from 1
+	Size    int64 `json:"size"`
+}
+
+type RespUploadSession struct {
+	SessionId   string       `json:"sessionId"`
+	Type        string       `json:"type"`
+	Currency    string       `json:"currency"`
+	Status      string       `json:"status"`
+	ExpiredTime int64        `json:"expiredTime"`
+	MaxPartSize int64        `json:"maxPartSize"`
+	Parts       []UploadPart `json:"parts"`
+	ItemId      string       `json:"itemId,omitempty"`
+}
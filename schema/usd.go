@@ -0,0 +1,7 @@
+package schema
+
+const (
+	// UsdCurrency is the pseudo currency of USD credit balance, it can only be spent by apikey
+	UsdCurrency = "USD"
+	UsdDecimals = 6 // balance and fee in micro USD
+)
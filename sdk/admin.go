@@ -0,0 +1,116 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	arseedSchema "github.com/everFinance/arseeding/schema"
+	"gopkg.in/h2non/gentleman.v2"
+	"strconv"
+)
+
+// admin api need the login session of an admin wallet
+
+func (a *ArSeedCli) sendSessionReq(req *gentleman.Request, session string, res interface{}) error {
+	req.SetHeader("Authorization", "Bearer "+session)
+	resp, err := req.Send()
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return errors.New(fmt.Sprintf("resp failed: %s", resp.String()))
+	}
+	return resp.JSON(res)
+}
+
+func (a *ArSeedCli) AdminGetReceipts(session, status string, cursorId int64, num int) ([]arseedSchema.ReceiptEverTx, error) {
+	req := a.SCli.Get()
+	req.Path("/admin/receipts")
+	req.AddQuery("status", status)
+	req.AddQuery("cursorId", strconv.FormatInt(cursorId, 10))
+	req.AddQuery("num", strconv.Itoa(num))
+	rpts := make([]arseedSchema.ReceiptEverTx, 0)
+	err := a.sendSessionReq(req, session, &rpts)
+	return rpts, err
+}
+
+func (a *ArSeedCli) AdminRetryRefund(session, everHash string) (string, error) {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/admin/receipts/%s/refund", everHash))
+	res := struct {
+		RefundHash string `json:"refundHash"`
+	}{}
+	err := a.sendSessionReq(req, session, &res)
+	return res.RefundHash, err
+}
+
+func (a *ArSeedCli) AdminManualRefund(session, everHash string, refund arseedSchema.ReqAdminManualRefund) error {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/admin/receipts/%s/manual_refund", everHash))
+	req.JSON(refund)
+	res := ""
+	return a.sendSessionReq(req, session, &res)
+}
+
+func (a *ArSeedCli) AdminCredit(session, everHash string, credit arseedSchema.ReqAdminCredit) (string, error) {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/admin/receipts/%s/credit", everHash))
+	req.JSON(credit)
+	res := struct {
+		Address string `json:"address"`
+	}{}
+	err := a.sendSessionReq(req, session, &res)
+	return res.Address, err
+}
+
+// AdminUsdCredit return the USD balance of apikey in micro USD
+func (a *ArSeedCli) AdminUsdCredit(session, address string, credit arseedSchema.ReqAdminUsdCredit) (string, error) {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/admin/apikey/%s/usd_credit", address))
+	req.JSON(credit)
+	res := struct {
+		Balance string `json:"balance"`
+	}{}
+	err := a.sendSessionReq(req, session, &res)
+	return res.Balance, err
+}
+
+func (a *ArSeedCli) AdminSetPrice(session, symbol string, price arseedSchema.ReqAdminSetPrice) error {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/admin/prices/%s", symbol))
+	req.JSON(price)
+	res := ""
+	return a.sendSessionReq(req, session, &res)
+}
+
+func (a *ArSeedCli) AdminGetAudits(session, target string, cursorId int64, num int) ([]arseedSchema.AdminAudit, error) {
+	req := a.SCli.Get()
+	req.Path("/admin/audits")
+	if target != "" {
+		req.AddQuery("target", target)
+	}
+	req.AddQuery("cursorId", strconv.FormatInt(cursorId, 10))
+	req.AddQuery("num", strconv.Itoa(num))
+	audits := make([]arseedSchema.AdminAudit, 0)
+	err := a.sendSessionReq(req, session, &audits)
+	return audits, err
+}
+
+func (a *ArSeedCli) AdminGetFeeSweeps(session string, cursorId int64, num int) ([]arseedSchema.FeeSweep, error) {
+	req := a.SCli.Get()
+	req.Path("/admin/fee_sweeps")
+	req.AddQuery("cursorId", strconv.FormatInt(cursorId, 10))
+	req.AddQuery("num", strconv.Itoa(num))
+	sweeps := make([]arseedSchema.FeeSweep, 0)
+	err := a.sendSessionReq(req, session, &sweeps)
+	return sweeps, err
+}
+
+// AdminDryRunFeeSweep return what would be swept now, the sweep in "dryRun" status would be executed
+func (a *ArSeedCli) AdminDryRunFeeSweep(session string) ([]arseedSchema.FeeSweep, error) {
+	req := a.SCli.Get()
+	req.Path("/admin/fee_sweeps/dry_run")
+	sweeps := make([]arseedSchema.FeeSweep, 0)
+	err := a.sendSessionReq(req, session, &sweeps)
+	return sweeps, err
+}
@@ -0,0 +1,49 @@
+package sdk
+
+import (
+	arseedSchema "github.com/everFinance/arseeding/schema"
+)
+
+// apikey management api need the login session of apikey owner wallet
+
+// RotateApiKey the orders of old apikey can be queried by the new apikey
+func (a *ArSeedCli) RotateApiKey(session string) (arseedSchema.RespApiKeyAction, error) {
+	req := a.SCli.Post()
+	req.Path("/apikey/rotate")
+	res := arseedSchema.RespApiKeyAction{}
+	err := a.sendSessionReq(req, session, &res)
+	return res, err
+}
+
+func (a *ArSeedCli) RevokeApiKey(session string) error {
+	req := a.SCli.Post()
+	req.Path("/apikey/revoke")
+	res := arseedSchema.RespApiKeyAction{}
+	return a.sendSessionReq(req, session, &res)
+}
+
+// CreateApiSubKey the secret of sub-key is only returned here, RespApiKeyAction.SubKey
+func (a *ArSeedCli) CreateApiSubKey(session string, subKey arseedSchema.ReqSubKey) (arseedSchema.RespApiKeyAction, error) {
+	req := a.SCli.Post()
+	req.Path("/apikey/subkey")
+	req.JSON(subKey)
+	res := arseedSchema.RespApiKeyAction{}
+	err := a.sendSessionReq(req, session, &res)
+	return res, err
+}
+
+func (a *ArSeedCli) RevokeApiSubKey(session, name string) error {
+	req := a.SCli.Post()
+	req.Path("/apikey/subkey/revoke")
+	req.JSON(arseedSchema.ReqSubKey{Name: name})
+	res := arseedSchema.RespApiKeyAction{}
+	return a.sendSessionReq(req, session, &res)
+}
+
+func (a *ArSeedCli) GetApiSubKeys(session string) ([]arseedSchema.ApiSubKey, error) {
+	req := a.SCli.Get()
+	req.Path("/apikey/subkeys")
+	res := arseedSchema.RespApiKeyAction{}
+	err := a.sendSessionReq(req, session, &res)
+	return res.SubKeys, err
+}
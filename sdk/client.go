@@ -10,6 +10,7 @@ import (
 	"github.com/everFinance/goar/types"
 	"gopkg.in/h2non/gentleman.v2"
 	"io"
+	"mime/multipart"
 	"strconv"
 )
 
@@ -140,6 +141,15 @@ func (a *ArSeedCli) GetBundler() (string, error) {
 }
 
 func (a *ArSeedCli) SubmitItem(itemBinary []byte, currency string, apikey string, needSequence bool) (*schema.RespOrder, error) {
+	return a.SubmitItemWithLane(itemBinary, currency, apikey, schema.LaneStandard, needSequence)
+}
+
+// SubmitExpressItem the item is bundled at once, the express fee is charged
+func (a *ArSeedCli) SubmitExpressItem(itemBinary []byte, currency string, apikey string) (*schema.RespOrder, error) {
+	return a.SubmitItemWithLane(itemBinary, currency, apikey, schema.LaneExpress, false)
+}
+
+func (a *ArSeedCli) SubmitItemWithLane(itemBinary []byte, currency, apikey, lane string, needSequence bool) (*schema.RespOrder, error) {
 	req := a.SCli.Post()
 	if currency != "" {
 		req.Path(fmt.Sprintf("/bundle/tx/%s", currency))
@@ -154,6 +164,9 @@ func (a *ArSeedCli) SubmitItem(itemBinary []byte, currency string, apikey string
 	if needSequence {
 		req.SetHeader("Sort", "true")
 	}
+	if len(lane) > 0 {
+		req.SetHeader("X-LANE", lane)
+	}
 
 	req.Body(bytes.NewReader(itemBinary))
 
@@ -242,6 +255,48 @@ func (a *ArSeedCli) SubmitNativeDataStream(apiKey string, currency string, data
 	return br, err
 }
 
+// SubmitNativeFiles submit files by multipart form, every file is signed as a bundle item with the tags.
+// files key is file name, Content-Type is inferred by file name or file content if tags not include it
+func (a *ArSeedCli) SubmitNativeFiles(apiKey string, currency string, files map[string]io.Reader, tags map[string]string) (*schema.RespItemIds, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, file := range files {
+		part, err := writer.CreateFormFile("file", name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.Copy(part, file); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range tags {
+		if err := writer.WriteField(k, v); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/bundle/data/%s", currency))
+	req.SetHeader("X-API-KEY", apiKey)
+	req.SetHeader("Content-Type", writer.FormDataContentType())
+	req.Body(body)
+
+	resp, err := req.Send()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return nil, fmt.Errorf("resp failed.http code: %d, errMsg:%s", resp.StatusCode, resp.String())
+	}
+	br := &schema.RespItemIds{}
+	err = resp.JSON(br)
+	return br, err
+}
+
 func (a *ArSeedCli) GetItemMeta(itemId string) (types.BundleItem, error) {
 	req := a.SCli.Get()
 	req.Path(fmt.Sprintf("/bundle/tx/%s", itemId))
@@ -294,8 +349,16 @@ func (a *ArSeedCli) GetItemIds(arId string) ([]string, error) {
 }
 
 func (a *ArSeedCli) BundleFee(size int64, currency string) (schema.RespFee, error) {
+	return a.BundleFeeWithApiKey(size, currency, "")
+}
+
+// BundleFeeWithApiKey return the effective fee of the apikey pricing plan
+func (a *ArSeedCli) BundleFeeWithApiKey(size int64, currency, apiKey string) (schema.RespFee, error) {
 	req := a.SCli.Get()
 	req.Path(fmt.Sprintf("/bundle/fee/%d/%s", size, currency))
+	if len(apiKey) > 0 {
+		req.SetHeader("X-API-KEY", apiKey)
+	}
 
 	resp, err := req.Send()
 	if err != nil {
@@ -311,9 +374,18 @@ func (a *ArSeedCli) BundleFee(size int64, currency string) (schema.RespFee, erro
 	return fee, err
 }
 
+// GetOrders deprecated: the orders can not be read without login session after the bundler disabled legacy auth, use GetOrdersWithSession
 func (a *ArSeedCli) GetOrders(addr string, startId int) ([]schema.Order, error) {
+	return a.GetOrdersWithSession("", addr, startId)
+}
+
+// GetOrdersWithSession token is the login session of addr
+func (a *ArSeedCli) GetOrdersWithSession(token, addr string, startId int) ([]schema.Order, error) {
 	req := a.SCli.Get()
 	req.Path(fmt.Sprintf("/bundle/orders/%s", addr))
+	if len(token) > 0 {
+		req.SetHeader("Authorization", "Bearer "+token)
+	}
 	req.AddQuery("cursorId", strconv.Itoa(startId))
 	resp, err := req.Send()
 	if err != nil {
@@ -345,3 +417,500 @@ func (a *ArSeedCli) GetApiKey(addr string) (schema.RespApiKey, error) {
 	err = resp.JSON(&apiKey)
 	return apiKey, err
 }
+
+// GetApiKeyLedger token is the login session of addr
+func (a *ArSeedCli) GetApiKeyLedger(token, addr string, cursorId int64, num int) ([]schema.RespLedgerEntry, error) {
+	req := a.SCli.Get()
+	req.Path(fmt.Sprintf("/apikey_records/ledger/%s", addr))
+	req.SetHeader("Authorization", "Bearer "+token)
+	req.AddQuery("cursorId", strconv.FormatInt(cursorId, 10))
+	req.AddQuery("num", strconv.Itoa(num))
+	resp, err := req.Send()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return nil, errors.New(fmt.Sprintf("resp failed: %s", resp.String()))
+	}
+
+	entries := make([]schema.RespLedgerEntry, 0)
+	err = resp.JSON(&entries)
+	return entries, err
+}
+
+// GetApiKeyStatement token is the login session of addr, month format is 200601
+func (a *ArSeedCli) GetApiKeyStatement(token, addr, month string) (schema.BillingStatement, error) {
+	req := a.SCli.Get()
+	req.Path(fmt.Sprintf("/apikey_statement/%s/%s", addr, month))
+	req.SetHeader("Authorization", "Bearer "+token)
+	resp, err := req.Send()
+	if err != nil {
+		return schema.BillingStatement{}, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return schema.BillingStatement{}, errors.New(fmt.Sprintf("resp failed: %s", resp.String()))
+	}
+
+	st := schema.BillingStatement{}
+	err = resp.JSON(&st)
+	return st, err
+}
+
+func (a *ArSeedCli) GetApiKeyStatementCSV(token, addr, month string) ([]byte, error) {
+	req := a.SCli.Get()
+	req.Path(fmt.Sprintf("/apikey_statement/%s/%s", addr, month))
+	req.SetHeader("Authorization", "Bearer "+token)
+	req.AddQuery("format", schema.StatementFormatCSV)
+	resp, err := req.Send()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return nil, errors.New(fmt.Sprintf("resp failed: %s", resp.String()))
+	}
+	return resp.Bytes(), nil
+}
+
+func (a *ArSeedCli) GetItemProof(itemId string) (schema.RespItemProof, error) {
+	req := a.SCli.Get()
+	req.Path(fmt.Sprintf("/bundle/proof/%s", itemId))
+
+	resp, err := req.Send()
+	if err != nil {
+		return schema.RespItemProof{}, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return schema.RespItemProof{}, errors.New(fmt.Sprintf("resp failed: %s", resp.String()))
+	}
+
+	proof := schema.RespItemProof{}
+	err = resp.JSON(&proof)
+	return proof, err
+}
+
+func (a *ArSeedCli) GetUploadReceipt(itemId string) (schema.UploadReceipt, error) {
+	req := a.SCli.Get()
+	req.Path(fmt.Sprintf("/bundle/receipt/%s", itemId))
+
+	resp, err := req.Send()
+	if err != nil {
+		return schema.UploadReceipt{}, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return schema.UploadReceipt{}, errors.New(fmt.Sprintf("resp failed: %s", resp.String()))
+	}
+
+	receipt := schema.UploadReceipt{}
+	err = resp.JSON(&receipt)
+	return receipt, err
+}
+
+// resumable upload session
+
+func (a *ArSeedCli) CreateUploadSession(currency string, apikey string, needSequence bool) (schema.RespUploadSession, error) {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/bundle/upload/%s", currency))
+	req.SetHeader("X-API-KEY", apikey)
+	if needSequence {
+		req.SetHeader("Sort", "true")
+	}
+	return a.sendUploadSessionReq(req)
+}
+
+func (a *ArSeedCli) CreateDataUploadSession(apiKey string, currency string, contentType string, tags map[string]string, needSequence bool) (schema.RespUploadSession, error) {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/bundle/upload_data/%s", currency))
+	req.SetHeader("X-API-KEY", apiKey)
+	if needSequence {
+		req.SetHeader("Sort", "true")
+	}
+	req.AddQuery("Content-Type", contentType)
+	for k, v := range tags {
+		req.AddQuery(k, v)
+	}
+	return a.sendUploadSessionReq(req)
+}
+
+func (a *ArSeedCli) GetUploadSession(sessionId string) (schema.RespUploadSession, error) {
+	req := a.SCli.Get()
+	req.Path(fmt.Sprintf("/bundle/upload_parts/%s", sessionId))
+	return a.sendUploadSessionReq(req)
+}
+
+func (a *ArSeedCli) UploadPart(sessionId string, partNum int, data []byte) error {
+	req := a.SCli.Put()
+	req.Path(fmt.Sprintf("/bundle/upload_part/%s/%d", sessionId, partNum))
+	req.SetHeader("Content-Type", "application/octet-stream")
+	req.Body(bytes.NewReader(data))
+
+	resp, err := req.Send()
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return fmt.Errorf("upload part failed; http code: %d, errMsg:%s", resp.StatusCode, resp.String())
+	}
+	return nil
+}
+
+// ResumeUpload upload the parts of data which not received by the session, the same sessionId, data and partSize must be used when retry
+func (a *ArSeedCli) ResumeUpload(sessionId string, data io.ReaderAt, size int64, partSize int64) error {
+	if partSize <= 0 {
+		return errors.New("partSize must more than 0")
+	}
+	session, err := a.GetUploadSession(sessionId)
+	if err != nil {
+		return err
+	}
+	if partSize > session.MaxPartSize {
+		return fmt.Errorf("partSize can not more than %d", session.MaxPartSize)
+	}
+	received := make(map[int]int64)
+	for _, part := range session.Parts {
+		received[part.PartNum] = part.Size
+	}
+
+	for partNum, offset := 1, int64(0); offset < size; partNum, offset = partNum+1, offset+partSize {
+		partLen := partSize
+		if offset+partLen > size {
+			partLen = size - offset
+		}
+		if received[partNum] == partLen {
+			continue
+		}
+		buf := make([]byte, partLen)
+		if _, err = data.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return err
+		}
+		if err = a.UploadPart(sessionId, partNum, buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *ArSeedCli) FinalizeItemUpload(sessionId string) (*schema.RespOrder, error) {
+	br := &schema.RespOrder{}
+	err := a.finalizeUpload(sessionId, br)
+	return br, err
+}
+
+func (a *ArSeedCli) FinalizeDataUpload(sessionId string) (*schema.RespItemId, error) {
+	br := &schema.RespItemId{}
+	err := a.finalizeUpload(sessionId, br)
+	return br, err
+}
+
+// SubmitItemResumable upload item binary by upload session, if it returns error, call it again with the same sessionId to resume
+func (a *ArSeedCli) SubmitItemResumable(sessionId string, itemBinary io.ReaderAt, size int64, partSize int64) (*schema.RespOrder, error) {
+	if err := a.ResumeUpload(sessionId, itemBinary, size, partSize); err != nil {
+		return nil, err
+	}
+	return a.FinalizeItemUpload(sessionId)
+}
+
+// SubmitNativeDataResumable upload native data by data upload session, if it returns error, call it again with the same sessionId to resume
+func (a *ArSeedCli) SubmitNativeDataResumable(sessionId string, data io.ReaderAt, size int64, partSize int64) (*schema.RespItemId, error) {
+	if err := a.ResumeUpload(sessionId, data, size, partSize); err != nil {
+		return nil, err
+	}
+	return a.FinalizeDataUpload(sessionId)
+}
+
+func (a *ArSeedCli) finalizeUpload(sessionId string, result interface{}) error {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/bundle/upload_finalize/%s", sessionId))
+
+	resp, err := req.Send()
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return fmt.Errorf("finalize upload failed; http code: %d, errMsg:%s", resp.StatusCode, resp.String())
+	}
+	return resp.JSON(result)
+}
+
+func (a *ArSeedCli) sendUploadSessionReq(req *gentleman.Request) (schema.RespUploadSession, error) {
+	resp, err := req.Send()
+	if err != nil {
+		return schema.RespUploadSession{}, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return schema.RespUploadSession{}, fmt.Errorf("resp failed.http code: %d, errMsg:%s", resp.StatusCode, resp.String())
+	}
+	session := schema.RespUploadSession{}
+	err = resp.JSON(&session)
+	return session, err
+}
+
+// upload token
+
+func (a *ArSeedCli) CreateUploadToken(apiKey string, reqToken schema.ReqUploadToken) (schema.RespUploadToken, error) {
+	req := a.SCli.Post()
+	req.Path("/apikey/upload_token")
+	req.SetHeader("X-API-KEY", apiKey)
+	req.JSON(reqToken)
+
+	resp, err := req.Send()
+	if err != nil {
+		return schema.RespUploadToken{}, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return schema.RespUploadToken{}, fmt.Errorf("resp failed.http code: %d, errMsg:%s", resp.StatusCode, resp.String())
+	}
+	token := schema.RespUploadToken{}
+	err = resp.JSON(&token)
+	return token, err
+}
+
+func (a *ArSeedCli) SubmitItemWithToken(itemBinary []byte, currency string, uploadToken string, needSequence bool) (*schema.RespOrder, error) {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/bundle/tx/%s", currency))
+	req.SetHeader("Content-Type", "application/octet-stream")
+	req.SetHeader("X-UPLOAD-TOKEN", uploadToken)
+	if needSequence {
+		req.SetHeader("Sort", "true")
+	}
+	req.Body(bytes.NewReader(itemBinary))
+
+	resp, err := req.Send()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return nil, fmt.Errorf("send to bundler request failed; http code: %d, errMsg:%s", resp.StatusCode, resp.String())
+	}
+	br := &schema.RespOrder{}
+	err = resp.JSON(br)
+	return br, err
+}
+
+func (a *ArSeedCli) SubmitNativeDataWithToken(uploadToken string, currency string, data []byte, contentType string, tags map[string]string) (*schema.RespItemId, error) {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/bundle/data/%s", currency))
+	req.SetHeader("X-UPLOAD-TOKEN", uploadToken)
+	req.AddQuery("Content-Type", contentType)
+	for k, v := range tags {
+		req.AddQuery(k, v)
+	}
+	req.Body(bytes.NewReader(data))
+
+	resp, err := req.Send()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return nil, fmt.Errorf("resp failed.http code: %d, errMsg:%s", resp.StatusCode, resp.String())
+	}
+	br := &schema.RespItemId{}
+	err = resp.JSON(br)
+	return br, err
+}
+
+// fee quote
+
+// CreateFeeQuote lock the fee of currency for an item not bigger than size,
+// the item must be paid by the apiKey, or by the signer if apiKey is empty
+func (a *ArSeedCli) CreateFeeQuote(size int64, currency, apiKey, signer string) (schema.RespFeeQuote, error) {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/bundle/quote/%d/%s", size, currency))
+	if len(apiKey) > 0 {
+		req.SetHeader("X-API-KEY", apiKey)
+	} else {
+		req.AddQuery("signer", signer)
+	}
+	resp, err := req.Send()
+	if err != nil {
+		return schema.RespFeeQuote{}, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return schema.RespFeeQuote{}, fmt.Errorf("resp failed.http code: %d, errMsg:%s", resp.StatusCode, resp.String())
+	}
+	quote := schema.RespFeeQuote{}
+	err = resp.JSON(&quote)
+	return quote, err
+}
+
+func (a *ArSeedCli) SubmitItemWithQuote(itemBinary []byte, currency, apikey, quoteId string, needSequence bool) (*schema.RespOrder, error) {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/bundle/tx/%s", currency))
+	req.SetHeader("Content-Type", "application/octet-stream")
+	req.SetHeader("X-FEE-QUOTE", quoteId)
+	if len(apikey) > 0 {
+		req.SetHeader("X-API-KEY", apikey)
+	}
+	if needSequence {
+		req.SetHeader("Sort", "true")
+	}
+	req.Body(bytes.NewReader(itemBinary))
+
+	resp, err := req.Send()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return nil, fmt.Errorf("send to bundler request failed; http code: %d, errMsg:%s", resp.StatusCode, resp.String())
+	}
+	br := &schema.RespOrder{}
+	err = resp.JSON(br)
+	return br, err
+}
+
+func (a *ArSeedCli) SubmitNativeDataWithQuote(apiKey, quoteId string, currency string, data []byte, contentType string, tags map[string]string) (*schema.RespItemId, error) {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/bundle/data/%s", currency))
+	req.SetHeader("X-API-KEY", apiKey)
+	req.SetHeader("X-FEE-QUOTE", quoteId)
+	req.AddQuery("Content-Type", contentType)
+	for k, v := range tags {
+		req.AddQuery(k, v)
+	}
+	req.Body(bytes.NewReader(data))
+
+	resp, err := req.Send()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return nil, fmt.Errorf("resp failed.http code: %d, errMsg:%s", resp.StatusCode, resp.String())
+	}
+	br := &schema.RespItemId{}
+	err = resp.JSON(br)
+	return br, err
+}
+
+// wallet login
+
+func (a *ArSeedCli) GetAuthNonce(addr string, sigType string) (schema.RespAuthNonce, error) {
+	req := a.SCli.Get()
+	req.Path(fmt.Sprintf("/auth/nonce/%s", addr))
+	req.AddQuery("sigType", sigType)
+	resp, err := req.Send()
+	if err != nil {
+		return schema.RespAuthNonce{}, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return schema.RespAuthNonce{}, errors.New(fmt.Sprintf("resp failed: %s", resp.String()))
+	}
+	nonce := schema.RespAuthNonce{}
+	err = resp.JSON(&nonce)
+	return nonce, err
+}
+
+func (a *ArSeedCli) Login(login schema.ReqAuthLogin) (schema.RespAuthSession, error) {
+	req := a.SCli.Post()
+	req.Path("/auth/login")
+	req.JSON(login)
+	resp, err := req.Send()
+	if err != nil {
+		return schema.RespAuthSession{}, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return schema.RespAuthSession{}, errors.New(fmt.Sprintf("resp failed: %s", resp.String()))
+	}
+	session := schema.RespAuthSession{}
+	err = resp.JSON(&session)
+	return session, err
+}
+
+func (a *ArSeedCli) GetApiKeyBySession(token string) (string, error) {
+	req := a.SCli.Get()
+	req.Path("/auth/apikey")
+	req.SetHeader("Authorization", "Bearer "+token)
+	resp, err := req.Send()
+	if err != nil {
+		return "", err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return "", errors.New(fmt.Sprintf("resp failed: %s", resp.String()))
+	}
+	apiKey := ""
+	err = resp.JSON(&apiKey)
+	return apiKey, err
+}
+
+// CancelOrder cancel the unpaid orders of item, token is the login session of the item signer
+func (a *ArSeedCli) CancelOrder(token, itemId string) error {
+	req := a.SCli.Post()
+	req.Path(fmt.Sprintf("/bundle/order/%s/cancel", itemId))
+	req.SetHeader("Authorization", "Bearer "+token)
+	resp, err := req.Send()
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return errors.New(fmt.Sprintf("resp failed: %s", resp.String()))
+	}
+	return nil
+}
+
+func (a *ArSeedCli) CreateInvoice(currency string, itemIds []string) (schema.RespInvoice, error) {
+	req := a.SCli.Post()
+	req.Path("/bundle/invoice")
+	req.JSON(schema.ReqCreateInvoice{Currency: currency, ItemIds: itemIds})
+	resp, err := req.Send()
+	if err != nil {
+		return schema.RespInvoice{}, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return schema.RespInvoice{}, errors.New(fmt.Sprintf("resp failed: %s", resp.String()))
+	}
+	invoice := schema.RespInvoice{}
+	err = resp.JSON(&invoice)
+	return invoice, err
+}
+
+func (a *ArSeedCli) GetInvoice(invoiceId string) (schema.RespInvoice, error) {
+	req := a.SCli.Get()
+	req.Path(fmt.Sprintf("/bundle/invoice/%s", invoiceId))
+	resp, err := req.Send()
+	if err != nil {
+		return schema.RespInvoice{}, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return schema.RespInvoice{}, errors.New(fmt.Sprintf("resp failed: %s", resp.String()))
+	}
+	invoice := schema.RespInvoice{}
+	err = resp.JSON(&invoice)
+	return invoice, err
+}
+
+func (a *ArSeedCli) GetEconomicsReport(start, end string) (schema.EconomicsReport, error) {
+	req := a.SCli.Get()
+	req.Path("/statistic/economics")
+	req.AddQuery("start", start)
+	req.AddQuery("end", end)
+	resp, err := req.Send()
+	if err != nil {
+		return schema.EconomicsReport{}, err
+	}
+	defer resp.Close()
+	if !resp.Ok {
+		return schema.EconomicsReport{}, errors.New(fmt.Sprintf("resp failed: %s", resp.String()))
+	}
+
+	report := schema.EconomicsReport{}
+	err = resp.JSON(&report)
+	return report, err
+}
@@ -0,0 +1,132 @@
+package sdk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	arseedSchema "github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"strconv"
+)
+
+// VerifyItemProof checks offline that the item byte range is committed by the bundle data_root.
+// notice: BundleId and BlockId must be checked with a trusted arweave node, they are not covered by data_root.
+func VerifyItemProof(proof arseedSchema.RespItemProof) error {
+	dataRoot, err := utils.Base64Decode(proof.DataRoot)
+	if err != nil {
+		return err
+	}
+	dataSize, err := strconv.Atoi(proof.DataSize)
+	if err != nil {
+		return err
+	}
+	if len(proof.HeaderChunks) == 0 || len(proof.ItemChunks) == 0 {
+		return errors.New("proof chunks can not be null")
+	}
+
+	// 1. verify header chunks and assemble bundle header
+	header := make([]byte, 0)
+	rightBound := 0
+	for _, cp := range proof.HeaderChunks {
+		res, err := validateChunkProof(dataRoot, dataSize, cp, true)
+		if err != nil {
+			return err
+		}
+		if res.LeftBound != rightBound {
+			return errors.New("header chunks are not continuous")
+		}
+		chunkBy, err := utils.Base64Decode(cp.Chunk)
+		if err != nil {
+			return err
+		}
+		header = append(header, chunkBy...)
+		rightBound = res.RightBound
+	}
+
+	// 2. check item entry in bundle header
+	if len(header) < 32 {
+		return errors.New("bundle header length incorrect")
+	}
+	itemsNum := utils.ByteArrayToLong(header[:32])
+	if itemsNum != proof.ItemCount {
+		return fmt.Errorf("item count not match; header: %d, proof: %d", itemsNum, proof.ItemCount)
+	}
+	if proof.ItemIndex < 0 || proof.ItemIndex >= itemsNum || len(header) < 32+(proof.ItemIndex+1)*64 {
+		return errors.New("item index out of bundle header")
+	}
+	start := 32 + itemsNum*64
+	for i := 0; i < proof.ItemIndex; i++ {
+		start += utils.ByteArrayToLong(header[32+i*64 : 32+i*64+32])
+	}
+	entry := header[32+proof.ItemIndex*64 : 32+(proof.ItemIndex+1)*64]
+	if utils.Base64Encode(entry[32:]) != proof.ItemId {
+		return errors.New("item id not match bundle header")
+	}
+	end := start + utils.ByteArrayToLong(entry[:32])
+	if int64(start) != proof.StartOffset || int64(end) != proof.EndOffset {
+		return fmt.Errorf("item range not match; header: [%d,%d), proof: [%d,%d)", start, end, proof.StartOffset, proof.EndOffset)
+	}
+	if end > dataSize {
+		return errors.New("item range out of bundle data")
+	}
+
+	// 3. verify item chunks cover the item range
+	rightBound = -1
+	for i, cp := range proof.ItemChunks {
+		res, err := validateChunkProof(dataRoot, dataSize, cp, i == 0)
+		if err != nil {
+			return err
+		}
+		if i == 0 && (res.LeftBound > start || res.RightBound <= start) {
+			return errors.New("item chunks not cover item start")
+		}
+		if i > 0 && res.LeftBound != rightBound {
+			return errors.New("item chunks are not continuous")
+		}
+		rightBound = res.RightBound
+	}
+	if rightBound < end {
+		return errors.New("item chunks not cover item end")
+	}
+	return nil
+}
+
+// validateChunkProof the chunk data is checked with data path if it exists, needChunk means the data can not be null
+func validateChunkProof(dataRoot []byte, dataSize int, cp arseedSchema.ChunkProof, needChunk bool) (*utils.ValidateResult, error) {
+	offset, err := strconv.Atoi(cp.Offset)
+	if err != nil {
+		return nil, err
+	}
+	path, err := utils.Base64Decode(cp.DataPath)
+	if err != nil {
+		return nil, err
+	}
+	res, ok := utils.ValidatePath(dataRoot, offset, 0, dataSize, path)
+	if !ok {
+		return nil, fmt.Errorf("invalid data path; offset: %s", cp.Offset)
+	}
+	if cp.Chunk == "" {
+		if needChunk {
+			return nil, fmt.Errorf("chunk data can not be null; offset: %s", cp.Offset)
+		}
+		return res, nil
+	}
+
+	// leaf of data path is chunk data hash + chunk end offset
+	chunkBy, err := utils.Base64Decode(cp.Chunk)
+	if err != nil {
+		return nil, err
+	}
+	leafSize := types.HASH_SIZE + types.NOTE_SIZE
+	if len(path) < leafSize || len(chunkBy) != res.ChunkSize {
+		return nil, fmt.Errorf("chunk size not match; offset: %s", cp.Offset)
+	}
+	chunkHash := sha256.Sum256(chunkBy)
+	leaf := path[len(path)-leafSize:]
+	if !bytes.Equal(chunkHash[:], leaf[:types.HASH_SIZE]) {
+		return nil, fmt.Errorf("chunk data not match data path; offset: %s", cp.Offset)
+	}
+	return res, nil
+}
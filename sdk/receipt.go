@@ -0,0 +1,36 @@
+package sdk
+
+import (
+	"errors"
+	arseedSchema "github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar/utils"
+)
+
+// VerifyUploadReceipt checks the receipt is signed by the bundler wallet.
+// bundler is the expected bundler address, can get it by ArSeedCli.GetBundler
+func VerifyUploadReceipt(receipt arseedSchema.UploadReceipt, bundler string) error {
+	addr, err := utils.OwnerToAddress(receipt.Owner)
+	if err != nil {
+		return err
+	}
+	if addr != receipt.Bundler {
+		return errors.New("receipt owner not match bundler")
+	}
+	if bundler != "" && addr != bundler {
+		return errors.New("receipt not signed by the bundler")
+	}
+
+	pubKey, err := utils.OwnerToPubKey(receipt.Owner)
+	if err != nil {
+		return err
+	}
+	sig, err := utils.Base64Decode(receipt.Signature)
+	if err != nil {
+		return err
+	}
+	msg, err := receipt.SignData()
+	if err != nil {
+		return err
+	}
+	return utils.Verify(msg, pubKey, sig)
+}
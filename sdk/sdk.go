@@ -169,14 +169,24 @@ func (s *SDK) PayOrders(orders []*arseedSchema.RespOrder) (everTx *paySchema.Tra
 	if err != nil {
 		return
 	}
-	tokenTags := s.Pay.SymbolToTagArr(orders[0].Currency)
-	if len(tokenTags) == 0 {
-		err = errors.New("currency not exist token")
+	useTag, err := s.selectTokenTag(orders[0].Currency, totalFee)
+	if err != nil {
 		return
 	}
+
+	everTx, err = s.Pay.Transfer(useTag, totalFee, orders[0].Bundler, string(dataJs))
+	return
+}
+
+// selectTokenTag return the token tag of currency which balance is enough
+func (s *SDK) selectTokenTag(currency string, amount *big.Int) (string, error) {
+	tokenTags := s.Pay.SymbolToTagArr(currency)
+	if len(tokenTags) == 0 {
+		return "", errors.New("currency not exist token")
+	}
 	tokBals, err := s.Pay.Cli.Balances(s.Pay.AccId)
 	if err != nil {
-		return
+		return "", err
 	}
 	tagToBal := make(map[string]*big.Int)
 	for _, bal := range tokBals.Balances {
@@ -189,17 +199,40 @@ func (s *SDK) PayOrders(orders []*arseedSchema.RespOrder) (everTx *paySchema.Tra
 		if !ok {
 			continue
 		}
-		if amt.Cmp(totalFee) >= 0 {
+		if amt.Cmp(amount) >= 0 {
 			useTag = tag
 		}
 	}
 	if useTag == "" {
-		err = errors.New("token balance insufficient")
-		return
+		return "", errors.New("token balance insufficient")
 	}
+	return useTag, nil
+}
 
-	everTx, err = s.Pay.Transfer(useTag, totalFee, orders[0].Bundler, string(dataJs))
-	return
+// PayInvoice pay all the orders of invoice in one everTx, the tx data only contains the invoiceId
+func (s *SDK) PayInvoice(invoice arseedSchema.RespInvoice) (everTx *paySchema.Transaction, err error) {
+	amount, ok := new(big.Int).SetString(invoice.Amount, 10)
+	if !ok {
+		return nil, errors.New("invoice amount incorrect")
+	}
+	payTxData := struct {
+		AppName   string `json:"appName"`
+		Action    string `json:"action"`
+		InvoiceId string `json:"invoiceId"`
+	}{
+		AppName:   "arseeding",
+		Action:    "invoicePayment",
+		InvoiceId: invoice.InvoiceId,
+	}
+	dataJs, err := json.Marshal(&payTxData)
+	if err != nil {
+		return
+	}
+	useTag, err := s.selectTokenTag(invoice.Currency, amount)
+	if err != nil {
+		return
+	}
+	return s.Pay.Transfer(useTag, amount, invoice.Bundler, string(dataJs))
 }
 
 func (s *SDK) PayApikey(tokenTag string, amount *big.Int) (everHash string, err error) {
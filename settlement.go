@@ -0,0 +1,114 @@
+package arseeding
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	cfgSchema "github.com/everFinance/arseeding/config/schema"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"math/big"
+	"strings"
+)
+
+// creditSettlement return true if the unmatched part of the receipt can be credited to payer apikey balance
+func (s *Arseeding) creditSettlement(urtx schema.ReceiptEverTx) bool {
+	if s.config.Param.PaymentSettlement != cfgSchema.SettlementCredit {
+		return false
+	}
+	// apikey is recovered from the everTx signature, so only the everPay eth account is supported
+	if urtx.Provider != schema.PaymentProviderEverPay || !common.IsHexAddress(urtx.From) {
+		return false
+	}
+	return s.GetPerFee(urtx.Symbol) != nil
+}
+
+// settlePayItems pay the orders which can be covered by the receipt in itemIds order,
+// and credit the rest amount to payer apikey balance
+func settlePayItems(wdb *Wdb, itemIds []string, urtx schema.ReceiptEverTx) error {
+	amount, ok := new(big.Int).SetString(urtx.Amount, 10)
+	if !ok {
+		return errors.New("receipt amount incorrect")
+	}
+	remain := new(big.Int).Set(amount)
+	paidOrds := make([]schema.Order, 0, len(itemIds))
+	paidIds := make([]string, 0, len(itemIds))
+	unpaidIds := make([]string, 0)
+	visited := make(map[string]bool)
+	for _, itemId := range itemIds {
+		if visited[itemId] {
+			continue
+		}
+		visited[itemId] = true
+
+		ord, err := wdb.GetUnPaidOrder(itemId)
+		if err != nil {
+			if err != gorm.ErrRecordNotFound {
+				log.Error("wdb.GetUnPaidOrder(itemId)", "err", err, "itemId", itemId)
+				return err
+			}
+			unpaidIds = append(unpaidIds, itemId)
+			continue
+		}
+		fee, ok := new(big.Int).SetString(ord.Fee, 10)
+		if !ok || !strings.EqualFold(ord.Currency, urtx.Symbol) || remain.Cmp(fee) < 0 {
+			unpaidIds = append(unpaidIds, itemId)
+			continue
+		}
+		remain = new(big.Int).Sub(remain, fee)
+		paidOrds = append(paidOrds, ord)
+		paidIds = append(paidIds, itemId)
+	}
+
+	settlement := &schema.PaymentSettlement{
+		EverHash:     urtx.EverHash,
+		Provider:     urtx.Provider,
+		Currency:     urtx.Symbol,
+		Amount:       urtx.Amount,
+		PaidAmount:   new(big.Int).Sub(amount, remain).String(),
+		CreditAmount: remain.String(),
+	}
+	settlement.PaidItemIds, _ = json.Marshal(paidIds)
+	settlement.UnpaidItemIds, _ = json.Marshal(unpaidIds)
+	if remain.Sign() > 0 {
+		from, err := getOrCreatePayerApikey(wdb, urtx)
+		if err != nil {
+			return err
+		}
+		settlement.Address = from
+	}
+
+	dbTx := wdb.Db.Begin()
+	for _, ord := range paidOrds {
+		if err := wdb.UpdateOrderPay(ord.ID, urtx.EverHash, schema.SuccPayment, dbTx); err != nil {
+			log.Error("wdb.UpdateOrderPay(ord.ID,schema.SuccPayment,dbTx)", "err", err)
+			dbTx.Rollback()
+			return err
+		}
+	}
+	if err := addPaidUsage(wdb, paidOrds, dbTx); err != nil {
+		log.Error("addPaidUsage(wdb, paidOrds, dbTx)", "err", err, "id", urtx.RawId)
+		dbTx.Rollback()
+		return err
+	}
+	if settlement.Address != "" {
+		credit := decimal.NewFromBigInt(remain, 0)
+		if _, err := postLedgerEntry(wdb, settlement.Address, urtx.Symbol, schema.LedgerCredit, credit, urtx.EverHash, "", nil, dbTx); err != nil {
+			log.Error("postLedgerEntry(credit)", "err", err, "id", urtx.RawId)
+			dbTx.Rollback()
+			return err
+		}
+	}
+	if err := wdb.InsertPaymentSettlement(settlement, dbTx); err != nil {
+		log.Error("wdb.InsertPaymentSettlement", "err", err, "id", urtx.RawId)
+		dbTx.Rollback()
+		return err
+	}
+	if err := wdb.UpdateReceiptStatus(urtx.RawId, schema.Spent, dbTx); err != nil {
+		log.Error("wdb.UpdateReceiptStatus(urtx.RawId,schema.Spent,dbTx)", "err", err, "id", urtx.RawId)
+		dbTx.Rollback()
+		return err
+	}
+	return dbTx.Commit().Error
+}
@@ -0,0 +1,50 @@
+package arseeding
+
+import (
+	"encoding/json"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSettlePayItems(t *testing.T) {
+	sqliteDir := "./data/settlement"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+
+	addr := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	assert.NoError(t, wdb.InsertApiKey(schema.AutoApiKey{ApiKey: "apikey-test", Address: addr, TokenBalance: map[string]interface{}{}}))
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-1", Signer: addr, Size: 1024, Currency: "USDC", Fee: "100", PaymentStatus: schema.UnPayment}))
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-2", Currency: "USDC", Fee: "200", PaymentStatus: schema.UnPayment}))
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-3", Currency: "AR", Fee: "10", PaymentStatus: schema.UnPayment}))
+
+	urtx := schema.ReceiptEverTx{RawId: 1, EverHash: "everHash-1", Symbol: "USDC", From: addr, Amount: "250", Status: schema.UnSpent, Provider: schema.PaymentProviderEverPay}
+	assert.NoError(t, wdb.InsertReceiptTx(urtx))
+	assert.NoError(t, settlePayItems(wdb, []string{"item-1", "item-2", "item-3", "item-4", "item-1"}, urtx))
+
+	// item-1 is paid, item-2 amount not enough, item-3 currency mismatch, item-4 not found
+	assert.True(t, wdb.ExistPaidOrd("item-1"))
+	assert.False(t, wdb.ExistPaidOrd("item-2"))
+	// the bytes are counted when the order is paid
+	bytes, err := wdb.GetMonthlyBytes(addr, usageMonth(time.Now()))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), bytes)
+	settlement, err := wdb.GetPaymentSettlement("everHash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "100", settlement.PaidAmount)
+	assert.Equal(t, "150", settlement.CreditAmount)
+	assert.Equal(t, addr, settlement.Address)
+	unpaidIds := make([]string, 0)
+	assert.NoError(t, json.Unmarshal(settlement.UnpaidItemIds, &unpaidIds))
+	assert.Equal(t, []string{"item-2", "item-3", "item-4"}, unpaidIds)
+
+	apikey, err := wdb.GetApiKeyDetail("apikey-test")
+	assert.NoError(t, err)
+	assert.Equal(t, "150", apikey.TokenBalance["USDC"])
+	rpt := schema.ReceiptEverTx{}
+	assert.NoError(t, wdb.Db.Where("raw_id = ?", 1).First(&rpt).Error)
+	assert.Equal(t, schema.Spent, rpt.Status)
+}
@@ -0,0 +1,158 @@
+package arseeding
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/shopspring/decimal"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// the orders are queried in batches to limit the sql size
+const statementOrderBatch = 500
+
+func statementMonthRange(month string) (schema.TimeRange, error) {
+	start, err := time.ParseInLocation("200601", month, time.UTC)
+	if err != nil {
+		return schema.TimeRange{}, fmt.Errorf("month format must be 200601: %s", month)
+	}
+	return schema.TimeRange{Start: start, End: start.AddDate(0, 1, 0)}, nil
+}
+
+type statementAmounts struct {
+	opening, deposits, fees, refunds, closing decimal.Decimal
+}
+
+// BuildBillingStatement summarize the apikey ledger entries and the paid orders of the month
+func (s *Arseeding) BuildBillingStatement(address, month string) (*schema.BillingStatement, error) {
+	r, err := statementMonthRange(month)
+	if err != nil {
+		return nil, err
+	}
+	lastEntries, err := s.wdb.GetLastLedgerEntries(address, r.Start)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.wdb.GetLedgerEntriesByTime(address, r)
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := make(map[string]*statementAmounts)
+	getAmounts := func(currency string) *statementAmounts {
+		if _, ok := amounts[currency]; !ok {
+			amounts[currency] = &statementAmounts{}
+		}
+		return amounts[currency]
+	}
+	for _, entry := range lastEntries {
+		bal, err := decimal.NewFromString(entry.Balance)
+		if err != nil {
+			return nil, err
+		}
+		am := getAmounts(entry.Currency)
+		am.opening, am.closing = bal, bal
+	}
+
+	itemIds := make([]string, 0)
+	for _, entry := range entries {
+		amount, err := decimal.NewFromString(entry.Amount)
+		if err != nil {
+			return nil, err
+		}
+		bal, err := decimal.NewFromString(entry.Balance)
+		if err != nil {
+			return nil, err
+		}
+		am := getAmounts(entry.Currency)
+		switch entry.Type {
+		case schema.LedgerCredit:
+			am.deposits = am.deposits.Add(amount)
+		case schema.LedgerDebit:
+			am.fees = am.fees.Add(amount)
+			itemIds = append(itemIds, entry.Reference)
+		case schema.LedgerRefund:
+			am.refunds = am.refunds.Add(amount)
+		}
+		am.closing = bal
+	}
+
+	st := &schema.BillingStatement{Address: address, Month: month}
+	// the debit of a rejected item is refunded and has no order
+	for i := 0; i < len(itemIds); i += statementOrderBatch {
+		end := i + statementOrderBatch
+		if end > len(itemIds) {
+			end = len(itemIds)
+		}
+		orders, err := s.wdb.GetOrdersByItemIds(itemIds[i:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, ord := range orders {
+			st.ItemCount++
+			st.Bytes += ord.Size
+		}
+	}
+
+	currencies := make([]schema.StatementCurrency, 0, len(amounts))
+	for currency, am := range amounts {
+		decimals := 0
+		if perFee := s.GetPerFee(currency); perFee != nil {
+			decimals = perFee.Decimals
+		}
+		currencies = append(currencies, schema.StatementCurrency{
+			Currency:       currency,
+			Decimals:       decimals,
+			OpeningBalance: am.opening.String(),
+			Deposits:       am.deposits.String(),
+			Fees:           am.fees.String(),
+			Refunds:        am.refunds.String(),
+			ClosingBalance: am.closing.String(),
+		})
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i].Currency < currencies[j].Currency
+	})
+	if st.Currencies, err = json.Marshal(currencies); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// GetBillingStatement return the produced statement, or build it if the month is not closed
+func (s *Arseeding) GetBillingStatement(address, month string) (*schema.BillingStatement, error) {
+	if st, err := s.wdb.GetBillingStatement(address, month); err == nil {
+		return &st, nil
+	}
+	r, err := statementMonthRange(month)
+	if err != nil {
+		return nil, err
+	}
+	if r.Start.After(time.Now()) {
+		return nil, fmt.Errorf("month %s is not started", month)
+	}
+	return s.BuildBillingStatement(address, month)
+}
+
+// billingStatementCSV export one row per currency
+func billingStatementCSV(st schema.BillingStatement) ([]byte, error) {
+	currencies := make([]schema.StatementCurrency, 0)
+	if err := json.Unmarshal(st.Currencies, &currencies); err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	rows := [][]string{{"month", "address", "itemCount", "bytes", "currency", "decimals", "openingBalance", "deposits", "fees", "refunds", "closingBalance"}}
+	for _, c := range currencies {
+		rows = append(rows, []string{st.Month, st.Address, strconv.FormatInt(st.ItemCount, 10), strconv.FormatInt(st.Bytes, 10),
+			c.Currency, strconv.Itoa(c.Decimals), c.OpeningBalance, c.Deposits, c.Fees, c.Refunds, c.ClosingBalance})
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,76 @@
+package arseeding
+
+import (
+	"encoding/json"
+	"github.com/everFinance/arseeding/config"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBillingStatement(t *testing.T) {
+	sqliteDir := "./data/statement"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	aa := &Arseeding{
+		wdb:    wdb,
+		config: &config.Config{},
+		bundlePerFeeMap: map[string]schema.Fee{
+			"USDC": {Currency: "USDC", Decimals: 6, Base: decimal.Zero, PerChunk: decimal.Zero},
+		},
+	}
+
+	addr := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	day := func(m time.Month, d int) time.Time {
+		return time.Date(2024, m, d, 8, 0, 0, 0, time.UTC)
+	}
+	entries := []schema.LedgerEntry{
+		{CreatedAt: day(2, 10), Currency: "USDC", Type: schema.LedgerCredit, Amount: "100", Balance: "100", Reference: "everHash-1"},
+		{CreatedAt: day(3, 1), Currency: "USDC", Type: schema.LedgerDebit, Amount: "10", Balance: "90", Reference: "item-1"},
+		{CreatedAt: day(3, 2), Currency: "USDC", Type: schema.LedgerDebit, Amount: "10", Balance: "80", Reference: "item-2"},
+		{CreatedAt: day(3, 2), Currency: "USDC", Type: schema.LedgerRefund, Amount: "10", Balance: "90", Reference: "item-2"},
+		{CreatedAt: day(3, 20), Currency: "USDC", Type: schema.LedgerCredit, Amount: "50", Balance: "140", Reference: "everHash-2"},
+		{CreatedAt: day(3, 25), Currency: "USD", Type: schema.LedgerCredit, Amount: "1000", Balance: "1000", Reference: "invoice-1"},
+		{CreatedAt: day(4, 1), Currency: "USDC", Type: schema.LedgerDebit, Amount: "10", Balance: "130", Reference: "item-3"},
+	}
+	for i := range entries {
+		entries[i].Address = addr
+		assert.NoError(t, wdb.InsertLedgerEntry(&entries[i], nil))
+	}
+	// item-2 is rejected and has no order
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-1", Size: 1024}))
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-3", Size: 2048}))
+
+	_, err := aa.BuildBillingStatement(addr, "2024-03")
+	assert.Error(t, err)
+	st, err := aa.BuildBillingStatement(addr, "202403")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), st.ItemCount)
+	assert.Equal(t, int64(1024), st.Bytes)
+	currencies := make([]schema.StatementCurrency, 0)
+	assert.NoError(t, json.Unmarshal(st.Currencies, &currencies))
+	assert.Equal(t, []schema.StatementCurrency{
+		{Currency: "USD", OpeningBalance: "0", Deposits: "1000", Fees: "0", Refunds: "0", ClosingBalance: "1000"},
+		{Currency: "USDC", Decimals: 6, OpeningBalance: "100", Deposits: "50", Fees: "20", Refunds: "10", ClosingBalance: "140"},
+	}, currencies)
+
+	data, err := billingStatementCSV(*st)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, "202403,"+addr+",1,1024,USDC,6,100,50,20,10,140", lines[2])
+
+	// the produced statement is returned
+	assert.NoError(t, wdb.InsertBillingStatement(*st))
+	st2, err := aa.GetBillingStatement(addr, "202403")
+	assert.NoError(t, err)
+	assert.NotZero(t, st2.ID)
+	addrs, err := wdb.GetLedgerAddresses(day(3, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{addr}, addrs)
+}
@@ -0,0 +1,146 @@
+package arseeding
+
+import (
+	"fmt"
+	cfgSchema "github.com/everFinance/arseeding/config/schema"
+	"github.com/everFinance/arseeding/schema"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// the receipts in these status may be refunded, their amount is kept in the bundler balance
+var pendingRefundStatus = []string{schema.UnSpent, schema.UnRefund, schema.RefundErr}
+
+// PlanFeeSweeps return what would be swept now, the sweep in FeeSweepDryRun status is executed by collectFee
+func (s *Arseeding) PlanFeeSweeps() ([]schema.FeeSweep, error) {
+	names := make([]string, 0, len(s.paymentProviders))
+	for name := range s.paymentProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	collectAddr := s.config.FeeCollectAddress()
+	now := time.Now()
+	plans := make([]schema.FeeSweep, 0)
+	for _, name := range names {
+		bals, err := s.paymentProviders[name].FeeBalances()
+		if err != nil {
+			log.Error("provider.FeeBalances()", "err", err, "provider", name)
+			return nil, err
+		}
+		for _, bal := range bals {
+			pending, err := s.wdb.GetPendingRefundAmount(name, bal.Symbol, pendingRefundStatus)
+			if err != nil {
+				return nil, err
+			}
+			lastSweep := time.Time{}
+			if last, err := s.wdb.GetLastFeeSweep(name, bal.Tag); err == nil {
+				lastSweep = last.CreatedAt
+			}
+			plans = append(plans, planFeeSweep(name, bal, s.config.GetSweepPolicy(name, bal.Symbol), pending, lastSweep, collectAddr, now))
+		}
+	}
+	return plans, nil
+}
+
+// planFeeSweep keep the pending refunds and the min reserve of policy, the rest is swept.
+// The everPay token without policy is swept daily; the AR is only swept with policy, it pays for the bundle txs
+func planFeeSweep(provider string, bal schema.FeeBalance, policy *cfgSchema.SweepPolicy, pendingRefund *big.Int, lastSweep time.Time, collectAddr string, now time.Time) schema.FeeSweep {
+	sweep := schema.FeeSweep{
+		Provider:      provider,
+		Symbol:        bal.Symbol,
+		Tag:           bal.Tag,
+		Decimals:      bal.Decimals,
+		Balance:       bal.Amount,
+		PendingRefund: pendingRefund.String(),
+		MinReserve:    "0",
+		Amount:        "0",
+		Status:        schema.FeeSweepSkipped,
+	}
+	if policy == nil {
+		if provider == schema.PaymentProviderArweave {
+			sweep.Reason = "no sweep policy"
+			return sweep
+		}
+		policy = &cfgSchema.SweepPolicy{}
+	}
+
+	interval := policy.Interval
+	if interval <= 0 {
+		interval = schema.DefaultSweepInterval
+	}
+	if !lastSweep.IsZero() && now.Before(lastSweep.Add(time.Duration(interval)*time.Second)) {
+		sweep.Reason = fmt.Sprintf("last sweep at %s", lastSweep.UTC().Format(time.RFC3339))
+		return sweep
+	}
+	sweep.Destination = policy.Destination
+	if sweep.Destination == "" {
+		sweep.Destination = collectAddr
+	}
+	if sweep.Destination == "" {
+		sweep.Reason = "no destination"
+		return sweep
+	}
+
+	balance, ok := new(big.Int).SetString(bal.Amount, 10)
+	if !ok {
+		sweep.Reason = "balance incorrect"
+		return sweep
+	}
+	minReserve, threshold := big.NewInt(0), big.NewInt(0)
+	if policy.MinReserve != "" {
+		if minReserve, ok = new(big.Int).SetString(policy.MinReserve, 10); !ok {
+			sweep.Reason = "policy minReserve incorrect"
+			return sweep
+		}
+	}
+	if policy.Threshold != "" {
+		if threshold, ok = new(big.Int).SetString(policy.Threshold, 10); !ok {
+			sweep.Reason = "policy threshold incorrect"
+			return sweep
+		}
+	}
+	sweep.MinReserve = minReserve.String()
+
+	amount := new(big.Int).Sub(balance, new(big.Int).Add(pendingRefund, minReserve))
+	if amount.Sign() <= 0 {
+		sweep.Reason = "balance not more than the reserve"
+		return sweep
+	}
+	sweep.Amount = amount.String()
+	if amount.Cmp(threshold) < 0 {
+		sweep.Reason = "amount below the threshold"
+		return sweep
+	}
+	sweep.Status = schema.FeeSweepDryRun
+	return sweep
+}
+
+func (s *Arseeding) collectFee() {
+	plans, err := s.PlanFeeSweeps()
+	if err != nil {
+		log.Error("s.PlanFeeSweeps()", "err", err)
+		return
+	}
+	for _, sweep := range plans {
+		if sweep.Status != schema.FeeSweepDryRun {
+			continue
+		}
+		amount, _ := new(big.Int).SetString(sweep.Amount, 10)
+		bal := schema.FeeBalance{Symbol: sweep.Symbol, Tag: sweep.Tag, Decimals: sweep.Decimals, Amount: sweep.Balance}
+		sweep.TxHash, err = s.paymentProviders[sweep.Provider].TransferFee(bal, amount, sweep.Destination)
+		if err != nil {
+			log.Error("provider.TransferFee(bal, amount, sweep.Destination)", "err", err, "provider", sweep.Provider, "tag", sweep.Tag)
+			sweep.Status = schema.FeeSweepFailed
+			sweep.Reason = err.Error()
+		} else {
+			sweep.Status = schema.FeeSweepSuccess
+		}
+		if err = s.wdb.InsertFeeSweep(sweep); err != nil {
+			log.Error("s.wdb.InsertFeeSweep(sweep)", "err", err, "provider", sweep.Provider, "tag", sweep.Tag, "txHash", sweep.TxHash)
+		}
+		// the everPay nonce is millisecond timestamp, the transfers from the same signer must be spaced
+		time.Sleep(5 * time.Second)
+	}
+}
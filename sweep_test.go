@@ -0,0 +1,106 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/config"
+	cfgSchema "github.com/everFinance/arseeding/config/schema"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPlanFeeSweep(t *testing.T) {
+	now := time.Now()
+	bal := schema.FeeBalance{Symbol: "USDC", Tag: "ethereum-usdc-0x", Decimals: 6, Amount: "1000"}
+	collectAddr := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+
+	// default policy sweep all except the pending refunds
+	sweep := planFeeSweep(schema.PaymentProviderEverPay, bal, nil, big.NewInt(300), time.Time{}, collectAddr, now)
+	assert.Equal(t, schema.FeeSweepDryRun, sweep.Status)
+	assert.Equal(t, "700", sweep.Amount)
+	assert.Equal(t, collectAddr, sweep.Destination)
+
+	// AR is not swept without policy
+	sweep = planFeeSweep(schema.PaymentProviderArweave, schema.FeeBalance{Symbol: "AR", Tag: "AR", Amount: "1000"}, nil, big.NewInt(0), time.Time{}, collectAddr, now)
+	assert.Equal(t, schema.FeeSweepSkipped, sweep.Status)
+
+	policy := &cfgSchema.SweepPolicy{MinReserve: "200", Threshold: "600", Destination: "0xa06b79E655Db7D7C3B3E7B2ccEEb068c3259d0C9", Interval: 3600}
+	sweep = planFeeSweep(schema.PaymentProviderEverPay, bal, policy, big.NewInt(300), now.Add(-2*time.Hour), collectAddr, now)
+	assert.Equal(t, schema.FeeSweepSkipped, sweep.Status) // 500 below the threshold
+	assert.Equal(t, "500", sweep.Amount)
+	policy.Threshold = "500"
+	sweep = planFeeSweep(schema.PaymentProviderEverPay, bal, policy, big.NewInt(300), now.Add(-2*time.Hour), collectAddr, now)
+	assert.Equal(t, schema.FeeSweepDryRun, sweep.Status)
+	assert.Equal(t, policy.Destination, sweep.Destination)
+	sweep = planFeeSweep(schema.PaymentProviderEverPay, bal, policy, big.NewInt(300), now.Add(-time.Minute), collectAddr, now)
+	assert.Equal(t, schema.FeeSweepSkipped, sweep.Status) // swept in the interval
+	sweep = planFeeSweep(schema.PaymentProviderEverPay, bal, policy, big.NewInt(900), time.Time{}, collectAddr, now)
+	assert.Equal(t, schema.FeeSweepSkipped, sweep.Status) // not more than the reserve
+}
+
+type testFeeProvider struct {
+	PaymentProvider
+	bals      []schema.FeeBalance
+	transfers map[string]string
+}
+
+func (p *testFeeProvider) FeeBalances() ([]schema.FeeBalance, error) {
+	return p.bals, nil
+}
+
+func (p *testFeeProvider) TransferFee(bal schema.FeeBalance, amount *big.Int, to string) (string, error) {
+	p.transfers[bal.Tag] = amount.String()
+	return "tx-" + bal.Tag, nil
+}
+
+func TestCollectFee(t *testing.T) {
+	sqliteDir := "./data/sweep"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	provider := &testFeeProvider{
+		bals: []schema.FeeBalance{
+			{Symbol: "USDC", Tag: "usdc-tag", Amount: "1000"},
+			{Symbol: "ETH", Tag: "eth-tag", Amount: "0"},
+		},
+		transfers: map[string]string{},
+	}
+	aa := &Arseeding{
+		wdb:              wdb,
+		config:           config.New("", sqliteDir, true), // share the sqlite file
+		paymentProviders: map[string]PaymentProvider{schema.PaymentProviderEverPay: provider},
+	}
+	assert.NoError(t, wdb.InsertReceiptTx(schema.ReceiptEverTx{RawId: 1, EverHash: "everHash-1", Symbol: "usdc", Amount: "100", Status: schema.UnRefund, Provider: schema.PaymentProviderEverPay}))
+	assert.NoError(t, wdb.InsertReceiptTx(schema.ReceiptEverTx{RawId: 2, EverHash: "everHash-2", Symbol: "USDC", Amount: "50", Status: schema.Spent, Provider: schema.PaymentProviderEverPay}))
+	pending, err := wdb.GetPendingRefundAmount(schema.PaymentProviderEverPay, "USDC", pendingRefundStatus)
+	assert.NoError(t, err)
+	assert.Equal(t, "100", pending.String())
+
+	// no fee collection address
+	plans, err := aa.PlanFeeSweeps()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(plans))
+	assert.Equal(t, schema.FeeSweepSkipped, plans[0].Status)
+
+	assert.NoError(t, wdb.InsertFeeSweep(schema.FeeSweep{Provider: schema.PaymentProviderEverPay, Tag: "usdc-tag", Status: schema.FeeSweepFailed}))
+	_, err = wdb.GetLastFeeSweep(schema.PaymentProviderEverPay, "usdc-tag")
+	assert.Error(t, err) // the failed sweep is retried
+
+	assert.NoError(t, wdb.Db.Create(&cfgSchema.FeeConfig{FeeCollectAddress: "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"}).Error)
+	aa.collectFee()
+	assert.Equal(t, map[string]string{"usdc-tag": "900"}, provider.transfers)
+	last, err := wdb.GetLastFeeSweep(schema.PaymentProviderEverPay, "usdc-tag")
+	assert.NoError(t, err)
+	assert.Equal(t, "tx-usdc-tag", last.TxHash)
+	assert.Equal(t, "100", last.PendingRefund)
+
+	// swept in the default interval
+	plans, err = aa.PlanFeeSweeps()
+	assert.NoError(t, err)
+	assert.Equal(t, schema.FeeSweepSkipped, plans[0].Status)
+	sweeps, err := wdb.GetFeeSweeps(0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(sweeps))
+}
@@ -0,0 +1,165 @@
+package arseeding
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"strings"
+	"time"
+)
+
+func (s *Arseeding) CreateUploadToken(apiKey string, req schema.ReqUploadToken) (schema.UploadToken, error) {
+	if s.GetPerFee(req.Currency) == nil {
+		return schema.UploadToken{}, fmt.Errorf("not support currency: %s", req.Currency)
+	}
+	if req.MaxSize <= 0 || req.MaxSize > schema.SubmitMaxSize {
+		return schema.UploadToken{}, fmt.Errorf("maxSize must be in range (0, %d]", schema.SubmitMaxSize)
+	}
+	expiration := req.Expiration
+	if expiration == 0 {
+		expiration = schema.DefaultUploadTokenExpiration
+	}
+	if expiration < 0 || expiration > schema.MaxUploadTokenExpiration {
+		return schema.UploadToken{}, fmt.Errorf("expiration must be in range (0, %d]", schema.MaxUploadTokenExpiration)
+	}
+	for _, tag := range req.Tags {
+		if tag.Name == "" {
+			return schema.UploadToken{}, errors.New("tag name can not be null")
+		}
+	}
+	tagsJs, err := json.Marshal(req.Tags)
+	if err != nil {
+		return schema.UploadToken{}, err
+	}
+
+	tokenBy := make([]byte, 32)
+	if _, err = rand.Read(tokenBy); err != nil {
+		return schema.UploadToken{}, err
+	}
+	token := schema.UploadToken{
+		Token:       utils.Base64Encode(tokenBy),
+		ApiKey:      apiKey,
+		Currency:    strings.ToUpper(req.Currency),
+		MaxSize:     req.MaxSize,
+		Tags:        tagsJs,
+		ExpiredTime: time.Now().Unix() + expiration,
+		Status:      schema.UploadTokenUnused,
+	}
+	err = s.wdb.InsertUploadToken(token)
+	return token, err
+}
+
+// CheckUploadToken check the token can be used to upload data with currency, size is ignored if it is 0
+func (s *Arseeding) CheckUploadToken(token, currency string, size int64) (schema.UploadToken, error) {
+	ut, err := s.wdb.GetUploadToken(token)
+	if err != nil {
+		return ut, errors.New("upload token not exist")
+	}
+	if ut.Status != schema.UploadTokenUnused {
+		return ut, errors.New("upload token has been used")
+	}
+	if ut.ExpiredTime < time.Now().Unix() {
+		return ut, errors.New("upload token expired")
+	}
+	if !strings.EqualFold(ut.Currency, currency) {
+		return ut, fmt.Errorf("upload token only allow currency: %s", ut.Currency)
+	}
+	if size > ut.MaxSize {
+		return ut, fmt.Errorf("data size can not more than %d with the upload token", ut.MaxSize)
+	}
+	return ut, nil
+}
+
+// UseUploadToken lock the token for a upload request, the caller must call ReleaseUploadToken after the request processed
+func (s *Arseeding) UseUploadToken(token, currency string, size int64) (schema.UploadToken, error) {
+	ut, err := s.CheckUploadToken(token, currency, size)
+	if err != nil {
+		return ut, err
+	}
+	ok, err := s.wdb.LockUploadToken(token)
+	if err != nil {
+		return ut, err
+	}
+	if !ok {
+		return ut, errors.New("upload token has been used")
+	}
+	return ut, nil
+}
+
+// ReleaseUploadToken the token is consumed if the upload succeed, otherwise it can be used again
+func (s *Arseeding) ReleaseUploadToken(token string, succeed bool) {
+	status := schema.UploadTokenUnused
+	if succeed {
+		status = schema.UploadTokenUsed
+	}
+	if err := s.wdb.UpdateUploadTokenStatus(token, status); err != nil {
+		log.Error("s.wdb.UpdateUploadTokenStatus(token, status)", "err", err, "status", status)
+	}
+}
+
+// checkForcedTags signed items can not be changed, so they must contain all the forced tags
+func checkForcedTags(tags, forcedTags []types.Tag) error {
+	for _, ft := range forcedTags {
+		found := false
+		for _, tag := range tags {
+			if tag.Name == ft.Name && tag.Value == ft.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("item must contain tag %s: %s", ft.Name, ft.Value)
+		}
+	}
+	return nil
+}
+
+// mergeForcedTags the forced tags overwrite the tags with the same name
+func mergeForcedTags(tags, forcedTags []types.Tag) []types.Tag {
+	forced := make(map[string]struct{}, len(forcedTags))
+	for _, ft := range forcedTags {
+		forced[ft.Name] = struct{}{}
+	}
+	res := make([]types.Tag, 0, len(tags)+len(forcedTags))
+	for _, tag := range tags {
+		if _, ok := forced[tag.Name]; !ok {
+			res = append(res, tag)
+		}
+	}
+	return append(res, forcedTags...)
+}
+
+// UseItemUploadToken lock the upload token for a signed item, return the apikey to pay the item
+func (s *Arseeding) UseItemUploadToken(token, currency string, size int64, itemTags []types.Tag) (string, error) {
+	ut, err := s.UseUploadToken(token, currency, size)
+	if err != nil {
+		return "", err
+	}
+	forcedTags, err := ut.ForcedTags()
+	if err == nil {
+		err = checkForcedTags(itemTags, forcedTags)
+	}
+	if err != nil {
+		s.ReleaseUploadToken(token, false)
+		return "", err
+	}
+	return ut.ApiKey, nil
+}
+
+// UseDataUploadToken lock the upload token for native data, return the apikey to pay the data and the tags merged with forced tags
+func (s *Arseeding) UseDataUploadToken(token, currency string, size int64, tags []types.Tag) (string, []types.Tag, error) {
+	ut, err := s.UseUploadToken(token, currency, size)
+	if err != nil {
+		return "", nil, err
+	}
+	forcedTags, err := ut.ForcedTags()
+	if err != nil {
+		s.ReleaseUploadToken(token, false)
+		return "", nil, err
+	}
+	return ut.ApiKey, mergeForcedTags(tags, forcedTags), nil
+}
@@ -0,0 +1,54 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar/types"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestUseUploadToken(t *testing.T) {
+	sqliteDir := "./data/token"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	aa := &Arseeding{wdb: wdb}
+
+	assert.NoError(t, wdb.InsertUploadToken(schema.UploadToken{
+		Token:       "token-test",
+		ApiKey:      "apikey-test",
+		Currency:    "USDC",
+		MaxSize:     1024,
+		Tags:        []byte(`[{"name":"App-Name","value":"arseeding"}]`),
+		ExpiredTime: time.Now().Unix() + 60,
+		Status:      schema.UploadTokenUnused,
+	}))
+
+	_, err := aa.UseItemUploadToken("token-test", "ar", 10, nil)
+	assert.Error(t, err) // wrong currency
+	_, err = aa.UseItemUploadToken("token-test", "usdc", 1025, nil)
+	assert.Error(t, err) // too big
+	_, err = aa.UseItemUploadToken("token-test", "usdc", 10, []types.Tag{{Name: "App-Name", Value: "other"}})
+	assert.Error(t, err) // missing forced tag, the token is released
+
+	apiKey, tags, err := aa.UseDataUploadToken("token-test", "usdc", 10, []types.Tag{{Name: "Content-Type", Value: "text/plain"}, {Name: "App-Name", Value: "other"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "apikey-test", apiKey)
+	assert.Equal(t, []types.Tag{{Name: "Content-Type", Value: "text/plain"}, {Name: "App-Name", Value: "arseeding"}}, tags)
+	// token is locked by the processing request
+	_, _, err = aa.UseDataUploadToken("token-test", "usdc", 10, nil)
+	assert.Error(t, err)
+
+	// failed request release the token
+	aa.ReleaseUploadToken("token-test", false)
+	apiKey, err = aa.UseItemUploadToken("token-test", "usdc", 10, []types.Tag{{Name: "App-Name", Value: "arseeding"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "apikey-test", apiKey)
+
+	// token is single-use
+	aa.ReleaseUploadToken("token-test", true)
+	_, err = aa.CheckUploadToken("token-test", "usdc", 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,314 @@
+package arseeding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"github.com/google/uuid"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const uploadPartPrefix = "part-"
+
+func (s *Arseeding) CreateUploadSession(sessionType, currency, apiKey string, needSort bool, tags []types.Tag) (schema.UploadSession, error) {
+	if s.GetPerFee(currency) == nil {
+		return schema.UploadSession{}, fmt.Errorf("not support currency: %s", currency)
+	}
+	tagsJs, err := json.Marshal(tags)
+	if err != nil {
+		return schema.UploadSession{}, err
+	}
+	session := schema.UploadSession{
+		SessionId:   uuid.NewString(),
+		Type:        sessionType,
+		Currency:    strings.ToUpper(currency),
+		ApiKey:      apiKey,
+		Sort:        needSort,
+		Tags:        tagsJs,
+		Status:      schema.SessionUploading,
+		ExpiredTime: time.Now().Unix() + schema.UploadSessionExpiredRange,
+	}
+	if err = os.MkdirAll(uploadSessionDir(session.SessionId), os.ModePerm); err != nil {
+		return schema.UploadSession{}, err
+	}
+	if err = s.wdb.InsertUploadSession(session); err != nil {
+		os.RemoveAll(uploadSessionDir(session.SessionId))
+		return schema.UploadSession{}, err
+	}
+	return session, nil
+}
+
+func (s *Arseeding) SaveUploadPart(sessionId string, partNum int, body io.Reader) (schema.UploadPart, error) {
+	if partNum < 1 {
+		return schema.UploadPart{}, errors.New("partNum must start from 1")
+	}
+	session, err := s.getUploadingSession(sessionId)
+	if err != nil {
+		return schema.UploadPart{}, err
+	}
+	parts, err := loadUploadParts(session.SessionId)
+	if err != nil {
+		return schema.UploadPart{}, err
+	}
+	totalSize := int64(0)
+	for _, part := range parts {
+		if part.PartNum != partNum { // the part can be uploaded again
+			totalSize += part.Size
+		}
+	}
+
+	dir := uploadSessionDir(session.SessionId)
+	partFile, err := os.CreateTemp(dir, "tmp-")
+	if err != nil {
+		return schema.UploadPart{}, err
+	}
+	defer func() {
+		partFile.Close()
+		os.Remove(partFile.Name())
+	}()
+	size, err := io.CopyN(partFile, body, schema.UploadMaxPartSize+1)
+	if err != nil && err != io.EOF {
+		return schema.UploadPart{}, err
+	}
+	if size == 0 {
+		return schema.UploadPart{}, schema.ErrNullData
+	}
+	if size > schema.UploadMaxPartSize || totalSize+size > schema.SubmitMaxSize {
+		return schema.UploadPart{}, schema.ErrDataTooBig
+	}
+	if err = os.Rename(partFile.Name(), path.Join(dir, uploadPartPrefix+strconv.Itoa(partNum))); err != nil {
+		return schema.UploadPart{}, err
+	}
+	return schema.UploadPart{PartNum: partNum, Size: size}, nil
+}
+
+func (s *Arseeding) GetUploadSession(sessionId string) (*schema.RespUploadSession, error) {
+	session, err := s.wdb.GetUploadSession(sessionId)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]schema.UploadPart, 0)
+	if session.Status == schema.SessionUploading {
+		if parts, err = loadUploadParts(sessionId); err != nil {
+			return nil, err
+		}
+	}
+	return &schema.RespUploadSession{
+		SessionId:   session.SessionId,
+		Type:        session.Type,
+		Currency:    session.Currency,
+		Status:      session.Status,
+		ExpiredTime: session.ExpiredTime,
+		MaxPartSize: schema.UploadMaxPartSize,
+		Parts:       parts,
+		ItemId:      session.ItemId,
+	}, nil
+}
+
+// FinalizeUploadSession assemble all parts, return *schema.RespOrder for item session and *schema.RespItemId for data session
+func (s *Arseeding) FinalizeUploadSession(sessionId string) (resp interface{}, err error) {
+	session, err := s.getUploadingSession(sessionId)
+	if err != nil {
+		return
+	}
+	ok, err := s.wdb.LockUploadSession(sessionId)
+	if err != nil {
+		return
+	}
+	if !ok {
+		return nil, errors.New("upload session is finalizing")
+	}
+	// the item is accepted and charged once it is submitted, the session can not be finalized again
+	submitted := false
+	defer func() {
+		// unlock session, the client can fix parts and retry
+		if err != nil && !submitted {
+			if err2 := s.wdb.UpdateUploadSession(sessionId, schema.SessionUploading, ""); err2 != nil {
+				log.Error("s.wdb.UpdateUploadSession(sessionId, schema.SessionUploading)", "err", err2, "sessionId", sessionId)
+			}
+		}
+	}()
+
+	dataFile, size, err := assembleUploadParts(sessionId)
+	if err != nil {
+		return
+	}
+	defer func() {
+		dataFile.Close()
+		os.Remove(dataFile.Name())
+	}()
+
+	itemId := ""
+	switch session.Type {
+	case schema.UploadSessionItem:
+		var item *types.BundleItem
+		if size > schema.AllowStreamMinItemSize {
+			item, err = utils.DecodeBundleItemStream(dataFile)
+		} else {
+			var itemBinary []byte
+			if itemBinary, err = io.ReadAll(dataFile); err == nil {
+				item, err = utils.DecodeBundleItem(itemBinary)
+			}
+		}
+		if err != nil {
+			return nil, errors.New("decode item binary failed")
+		}
+		defer func() {
+			if item.DataReader != nil {
+				item.DataReader.Close()
+				os.Remove(item.DataReader.Name())
+			}
+		}()
+		if err = s.wdb.UpdateUploadSession(sessionId, schema.SessionFinalizing, item.Id); err != nil {
+			return
+		}
+		var respOrd *schema.RespOrder
+		if respOrd, err = s.submitItemOrder(*item, session.Currency, session.ApiKey, session.Sort, schema.LaneStandard, size, ""); err != nil {
+			return
+		}
+		submitted = true
+		itemId, resp = respOrd.ItemId, respOrd
+
+	case schema.UploadSessionData:
+		tags := make([]types.Tag, 0)
+		if err = json.Unmarshal(session.Tags, &tags); err != nil {
+			return
+		}
+		var item types.BundleItem
+		if size > schema.AllowStreamMinItemSize {
+			item, err = s.bundlerItemSigner.CreateAndSignItemStream(dataFile, "", "", tags)
+		} else {
+			var data []byte
+			if data, err = io.ReadAll(dataFile); err == nil {
+				item, err = s.bundlerItemSigner.CreateAndSignItem(data, "", "", tags)
+			}
+		}
+		if err != nil {
+			log.Error("s.bundlerItemSigner.CreateAndSignItem", "err", err, "sessionId", sessionId)
+			return nil, errors.New("assemble bundle item failed")
+		}
+		if err = s.wdb.UpdateUploadSession(sessionId, schema.SessionFinalizing, item.Id); err != nil {
+			return
+		}
+		var respItemId *schema.RespItemId
+		if respItemId, err = s.submitNativeItem(item, session.Currency, session.ApiKey, session.Sort, schema.LaneStandard, size, ""); err != nil {
+			return
+		}
+		submitted = true
+		itemId, resp = respItemId.ItemId, respItemId
+
+	default:
+		return nil, fmt.Errorf("not support session type: %s", session.Type)
+	}
+
+	if err2 := s.wdb.UpdateUploadSession(sessionId, schema.SessionFinalized, itemId); err2 != nil {
+		log.Error("s.wdb.UpdateUploadSession(sessionId, schema.SessionFinalized)", "err", err2, "sessionId", sessionId)
+	}
+	os.RemoveAll(uploadSessionDir(sessionId))
+	return
+}
+
+func (s *Arseeding) getUploadingSession(sessionId string) (schema.UploadSession, error) {
+	session, err := s.wdb.GetUploadSession(sessionId)
+	if err != nil {
+		return session, err
+	}
+	if session.Status != schema.SessionUploading {
+		return session, fmt.Errorf("upload session status is %s", session.Status)
+	}
+	if session.ExpiredTime < time.Now().Unix() {
+		return session, errors.New("upload session expired")
+	}
+	return session, nil
+}
+
+func uploadSessionDir(sessionId string) string {
+	return path.Join(schema.TmpFileDir, "upload-"+sessionId)
+}
+
+func loadUploadParts(sessionId string) ([]schema.UploadPart, error) {
+	entries, err := os.ReadDir(uploadSessionDir(sessionId))
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]schema.UploadPart, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), uploadPartPrefix) {
+			continue
+		}
+		partNum, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), uploadPartPrefix))
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, schema.UploadPart{PartNum: partNum, Size: info.Size()})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNum < parts[j].PartNum
+	})
+	return parts, nil
+}
+
+// assembleUploadParts write all parts to a tmp file by partNum, it's caller's responsibility to delete the tmp file
+func assembleUploadParts(sessionId string) (*os.File, int64, error) {
+	parts, err := loadUploadParts(sessionId)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(parts) == 0 {
+		return nil, 0, errors.New("upload parts is empty")
+	}
+	for i, part := range parts {
+		if part.PartNum != i+1 {
+			return nil, 0, fmt.Errorf("missing upload part: %d", i+1)
+		}
+	}
+
+	dataFile, err := os.CreateTemp(schema.TmpFileDir, "arseedupload-")
+	if err != nil {
+		return nil, 0, err
+	}
+	size := int64(0)
+	for _, part := range parts {
+		n, err := appendPartFile(dataFile, path.Join(uploadSessionDir(sessionId), uploadPartPrefix+strconv.Itoa(part.PartNum)))
+		if err != nil {
+			dataFile.Close()
+			os.Remove(dataFile.Name())
+			return nil, 0, err
+		}
+		size += n
+	}
+	if size > schema.SubmitMaxSize {
+		dataFile.Close()
+		os.Remove(dataFile.Name())
+		return nil, 0, schema.ErrDataTooBig
+	}
+	// reset io stream to origin of the file
+	if _, err = dataFile.Seek(0, 0); err != nil {
+		dataFile.Close()
+		os.Remove(dataFile.Name())
+		return nil, 0, err
+	}
+	return dataFile, size, nil
+}
+
+func appendPartFile(dst *os.File, partFileName string) (int64, error) {
+	partFile, err := os.Open(partFileName)
+	if err != nil {
+		return 0, err
+	}
+	defer partFile.Close()
+	return io.Copy(dst, partFile)
+}
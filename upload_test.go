@@ -0,0 +1,77 @@
+package arseeding
+
+import (
+	"bytes"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestAssembleUploadParts(t *testing.T) {
+	sessionId := "assemble-test"
+	dir := uploadSessionDir(sessionId)
+	assert.NoError(t, os.MkdirAll(dir, os.ModePerm))
+	defer os.RemoveAll(dir)
+
+	// missing part 2
+	assert.NoError(t, os.WriteFile(path.Join(dir, uploadPartPrefix+"1"), []byte("hello "), 0644))
+	assert.NoError(t, os.WriteFile(path.Join(dir, uploadPartPrefix+"3"), []byte("!"), 0644))
+	_, _, err := assembleUploadParts(sessionId)
+	assert.Error(t, err)
+
+	assert.NoError(t, os.WriteFile(path.Join(dir, uploadPartPrefix+"2"), []byte("arseeding"), 0644))
+	parts, err := loadUploadParts(sessionId)
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.UploadPart{{PartNum: 1, Size: 6}, {PartNum: 2, Size: 9}, {PartNum: 3, Size: 1}}, parts)
+
+	dataFile, size, err := assembleUploadParts(sessionId)
+	assert.NoError(t, err)
+	defer func() {
+		dataFile.Close()
+		os.Remove(dataFile.Name())
+	}()
+	assert.Equal(t, int64(16), size)
+	data, err := io.ReadAll(dataFile)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal([]byte("hello arseeding!"), data))
+}
+
+func TestProcessExpiredUploadSession(t *testing.T) {
+	sqliteDir := "./data/upload"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	aa := &Arseeding{wdb: wdb}
+
+	expired := time.Now().Unix() - 1
+	crashed := time.Now().Add(-2 * time.Hour)
+	for _, session := range []schema.UploadSession{
+		{SessionId: "session-1", Status: schema.SessionUploading, ExpiredTime: expired},
+		{SessionId: "session-2", Status: schema.SessionFinalizing, ExpiredTime: expired, UpdatedAt: crashed, ItemId: "item-2"},
+		{SessionId: "session-3", Status: schema.SessionFinalizing, ExpiredTime: expired, UpdatedAt: crashed, ItemId: "item-3"},
+		{SessionId: "session-4", Status: schema.SessionFinalizing, ExpiredTime: expired, ItemId: "item-4"},
+	} {
+		assert.NoError(t, wdb.InsertUploadSession(session))
+		assert.NoError(t, os.MkdirAll(uploadSessionDir(session.SessionId), os.ModePerm))
+		defer os.RemoveAll(uploadSessionDir(session.SessionId))
+	}
+	assert.NoError(t, wdb.InsertOrder(schema.Order{ItemId: "item-2"}))
+
+	aa.processExpiredUploadSession()
+	for sessionId, status := range map[string]string{
+		"session-1": schema.SessionExpired,
+		"session-2": schema.SessionFinalized, // the item is submitted before crash
+		"session-3": schema.SessionExpired,
+		"session-4": schema.SessionFinalizing, // the session is still finalizing
+	} {
+		session, err := wdb.GetUploadSession(sessionId)
+		assert.NoError(t, err)
+		assert.Equal(t, status, session.Status, sessionId)
+		_, err = os.Stat(uploadSessionDir(sessionId))
+		assert.Equal(t, status == schema.SessionFinalizing, err == nil, sessionId)
+	}
+}
@@ -0,0 +1,54 @@
+package arseeding
+
+import (
+	"github.com/everFinance/arseeding/config"
+	"github.com/everFinance/arseeding/schema"
+	"github.com/everFinance/goar/types"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestUsdBalance(t *testing.T) {
+	sqliteDir := "./data/usd"
+	defer os.RemoveAll(sqliteDir)
+	wdb := NewSqliteDb(sqliteDir)
+	assert.NoError(t, wdb.Migrate(false, false))
+	aa := &Arseeding{
+		wdb:    wdb,
+		config: &config.Config{},
+		bundlePerFeeMap: map[string]schema.Fee{
+			"USDC": {Currency: "USDC", Decimals: 6, Base: decimal.New(2000, 0), PerChunk: decimal.New(1000, 0), UsdPrice: 0.99, ArPrice: 8},
+			"USD":  {Currency: "USD", Decimals: 6, Base: decimal.New(2000, 0), PerChunk: decimal.New(1000, 0), UsdPrice: 1, ArPrice: 8},
+		},
+	}
+
+	// usd quote next to token quote
+	fee, err := aa.CalcItemFee("usdc", types.MAX_CHUNK_SIZE)
+	assert.NoError(t, err)
+	assert.Equal(t, "3000", fee.FinalFee)
+	assert.Equal(t, "0.00297", fee.UsdFee)
+
+	addr := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	apiKey := "usd-test-apikey"
+	assert.NoError(t, wdb.InsertApiKey(schema.AutoApiKey{
+		ApiKey:       apiKey,
+		Address:      addr,
+		TokenBalance: map[string]interface{}{},
+	}))
+	_, err = aa.AdminUsdCredit("admin", addr, schema.ReqAdminUsdCredit{Amount: "0"})
+	assert.Error(t, err)
+	bal, err := aa.AdminUsdCredit("admin", addr, schema.ReqAdminUsdCredit{Amount: "1.5", Reference: "invoice-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1500000", bal)
+
+	// debit at the prevailing rate and record the rate
+	debit, fee, err := aa.processApikeySpendBal("USD", apiKey, "item-1", types.MAX_CHUNK_SIZE, "", schema.LaneStandard)
+	assert.NoError(t, err)
+	assert.Equal(t, debit.Amount, fee.FinalFee)
+	assert.Equal(t, "3000", debit.Amount)
+	assert.Equal(t, "1497000", debit.Balance)
+	assert.Equal(t, "1", debit.UsdPrice)
+	assert.Equal(t, "8", debit.ArPrice)
+}
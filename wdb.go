@@ -2,6 +2,7 @@ package arseeding
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/everFinance/arseeding/schema"
 	"github.com/everFinance/goar/types"
 	"gorm.io/datatypes"
@@ -11,6 +12,7 @@ import (
 	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 	"math"
+	"math/big"
 	"os"
 	"path"
 	"strings"
@@ -56,12 +58,12 @@ func NewSqliteDb(dbDir string) *Wdb {
 // when use sqlite,same index name in different table will lead to migrate failed,
 
 func (w *Wdb) Migrate(noFee, enableManifest bool) error {
-	err := w.Db.AutoMigrate(&schema.Order{}, &schema.OnChainTx{}, &schema.AutoApiKey{}, &schema.OrderStatistic{})
+	err := w.Db.AutoMigrate(&schema.Order{}, &schema.OnChainTx{}, &schema.AutoApiKey{}, &schema.OrderStatistic{}, &schema.UploadReceipt{}, &schema.UploadSession{}, &schema.UploadToken{}, &schema.IdempotencyRecord{}, &schema.LedgerEntry{}, &schema.ApiSubKey{}, &schema.AuthNonce{}, &schema.AuthSession{}, &schema.AdminAudit{}, &schema.MonthlyUsage{}, &schema.FeeQuote{}, &schema.BillingStatement{}, &schema.Invoice{})
 	if err != nil {
 		return err
 	}
 	if !noFee {
-		err = w.Db.AutoMigrate(&schema.TokenPrice{}, &schema.ReceiptEverTx{})
+		err = w.Db.AutoMigrate(&schema.TokenPrice{}, &schema.ReceiptEverTx{}, &schema.PaymentSettlement{}, &schema.FeeSweep{}, &schema.TokenPriceSnapshot{})
 	}
 	if err != nil {
 		return err
@@ -82,6 +84,13 @@ func (w *Wdb) GetUnPaidOrder(itemId string) (schema.Order, error) {
 	return res, err
 }
 
+// GetUnPaidOrdersByItemIds the orders are sorted by id, an item may have more than one unpaid order
+func (w *Wdb) GetUnPaidOrdersByItemIds(itemIds []string) ([]schema.Order, error) {
+	res := make([]schema.Order, 0, len(itemIds))
+	err := w.Db.Model(&schema.Order{}).Where("item_id IN ? and payment_status = ?", itemIds, schema.UnPayment).Order("id").Find(&res).Error
+	return res, err
+}
+
 func (w *Wdb) GetExpiredOrders() ([]schema.Order, error) {
 	now := time.Now().Unix()
 	ords := make([]schema.Order, 0, 10)
@@ -106,6 +115,19 @@ func (w *Wdb) IsLatestUnpaidOrd(itemId string, CurExpiredTime int64) bool {
 	return false
 }
 
+// CancelUnpaidOrders cancel all the unpaid orders of the item signed by signer, return the number of cancelled orders
+func (w *Wdb) CancelUnpaidOrders(itemId, signer string) (int64, error) {
+	data := make(map[string]interface{})
+	data["payment_status"] = schema.CancelPayment
+	data["on_chain_status"] = schema.FailedOnChain
+	db := w.Db.Model(&schema.Order{}).Where("item_id = ? and signer = ? and payment_status = ?", itemId, signer, schema.UnPayment).Updates(data)
+	return db.RowsAffected, db.Error
+}
+
+func (w *Wdb) DelOrder(id uint) error {
+	return w.Db.Delete(&schema.Order{}, id).Error
+}
+
 func (w *Wdb) UpdateOrdToExpiredStatus(id uint) error {
 	data := make(map[string]interface{})
 	data["payment_status"] = schema.ExpiredPayment
@@ -121,12 +143,61 @@ func (w *Wdb) UpdateOrderPay(id uint, everHash string, paymentStatus string, tx
 	data := make(map[string]interface{})
 	data["payment_status"] = paymentStatus
 	data["payment_id"] = everHash
-	return db.Model(&schema.Order{}).Where("id = ?", id).Updates(data).Error
+	// the order may be expired or cancelled after it is queried
+	db = db.Model(&schema.Order{}).Where("id = ? and payment_status = ?", id, schema.UnPayment).Updates(data)
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return schema.ErrOrderNotUnpaid
+	}
+	return nil
 }
 
-func (w *Wdb) GetNeedOnChainOrders() ([]schema.Order, error) {
+// GetNeedOnChainOrders the orders are ordered by id, or by fee of each currency if orderBy is fee.
+// The fee of different currencies can not be compared in sql, so the top orders of every currency are returned,
+// with the oldest orders created before overdueTime which must be bundled whatever the fee is
+func (w *Wdb) GetNeedOnChainOrders(orderBy string, overdueTime time.Time) ([]schema.Order, error) {
+	needOnChain := func() *gorm.DB {
+		return w.Db.Model(&schema.Order{}).Where("payment_status = ?  and on_chain_status = ? and sort = ?", schema.SuccPayment, schema.WaitOnChain, false).Where("lane <> ?", schema.LaneExpress)
+	}
 	res := make([]schema.Order, 0)
-	err := w.Db.Model(&schema.Order{}).Where("payment_status = ?  and on_chain_status = ? and sort = ?", schema.SuccPayment, schema.WaitOnChain, false).Limit(2000).Find(&res).Error
+	if orderBy != schema.BundleOrderByFee {
+		err := needOnChain().Order("id").Limit(2000).Find(&res).Error
+		return res, err
+	}
+
+	currencies := make([]string, 0)
+	if err := needOnChain().Distinct("currency").Pluck("currency", &currencies).Error; err != nil {
+		return nil, err
+	}
+	visited := make(map[uint]bool)
+	for _, currency := range currencies {
+		ords := make([]schema.Order, 0)
+		// fee is the integer string of token base unit
+		if err := needOnChain().Where("currency = ?", currency).Order("length(fee) desc, fee desc, id").Limit(2000).Find(&ords).Error; err != nil {
+			return nil, err
+		}
+		for _, ord := range ords {
+			visited[ord.ID] = true
+		}
+		res = append(res, ords...)
+	}
+	overdue := make([]schema.Order, 0)
+	if err := needOnChain().Where("created_at < ?", overdueTime).Order("id").Limit(2000).Find(&overdue).Error; err != nil {
+		return nil, err
+	}
+	for _, ord := range overdue {
+		if !visited[ord.ID] {
+			res = append(res, ord)
+		}
+	}
+	return res, nil
+}
+
+func (w *Wdb) GetNeedOnChainExpressOrders() ([]schema.Order, error) {
+	res := make([]schema.Order, 0)
+	err := w.Db.Model(&schema.Order{}).Where("payment_status = ?  and on_chain_status = ? and lane = ?", schema.SuccPayment, schema.WaitOnChain, schema.LaneExpress).Order("id").Limit(2000).Find(&res).Error
 	return res, err
 }
 
@@ -170,6 +241,41 @@ func (w *Wdb) GetOrdersByApiKey(apiKey string, cursorId int64, pageSize int, sor
 	return records, err
 }
 
+func (w *Wdb) GetPaidOrdersByItemIds(itemIds []string) ([]schema.Order, error) {
+	res := make([]schema.Order, 0, len(itemIds))
+	err := w.Db.Model(&schema.Order{}).Where("item_id IN ? and payment_status = ?", itemIds, schema.SuccPayment).Find(&res).Error
+	return res, err
+}
+
+func (w *Wdb) GetOrdersByItemIds(itemIds []string) ([]schema.Order, error) {
+	res := make([]schema.Order, 0, len(itemIds))
+	err := w.Db.Model(&schema.Order{}).Where("item_id IN ?", itemIds).Find(&res).Error
+	return res, err
+}
+
+func (w *Wdb) InsertInvoice(invoice schema.Invoice) error {
+	return w.Db.Create(&invoice).Error
+}
+
+func (w *Wdb) GetInvoice(invoiceId string) (schema.Invoice, error) {
+	res := schema.Invoice{}
+	err := w.Db.Model(&schema.Invoice{}).Where("invoice_id = ?", invoiceId).First(&res).Error
+	return res, err
+}
+
+// LockInvoicePaid change the invoice status from unpaid to paid, return false if the invoice is not unpaid
+func (w *Wdb) LockInvoicePaid(invoiceId, everHash string, tx *gorm.DB) (bool, error) {
+	db := w.Db
+	if tx != nil {
+		db = tx
+	}
+	data := make(map[string]interface{})
+	data["status"] = schema.InvoicePaid
+	data["payment_id"] = everHash
+	db = db.Model(&schema.Invoice{}).Where("invoice_id = ? and status = ?", invoiceId, schema.InvoiceUnpaid).Updates(data)
+	return db.RowsAffected == 1, db.Error
+}
+
 func (w *Wdb) ExistProcessedOrderItem(itemId string) (res schema.Order, exist bool) {
 	err := w.Db.Model(&schema.Order{}).Where("item_id = ? and (on_chain_status = ? or on_chain_status = ?)", itemId, schema.PendingOnChain, schema.SuccOnChain).First(&res).Error
 	if err == nil {
@@ -186,6 +292,18 @@ func (w *Wdb) UpdatePrice(symbol string, newPrice float64) error {
 	return w.Db.Model(&schema.TokenPrice{}).Where("symbol = ?", symbol).Update("price", newPrice).Error
 }
 
+// SetPrice the price set by admin is accepted without the price move check
+func (w *Wdb) SetPrice(symbol string, price float64, manualSet bool) error {
+	db := w.Db.Model(&schema.TokenPrice{}).Where("symbol = ?", symbol).Updates(map[string]interface{}{"price": price, "manual_set": manualSet})
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (w *Wdb) GetPrices() ([]schema.TokenPrice, error) {
 	res := make([]schema.TokenPrice, 0, 10)
 	err := w.Db.Find(&res).Error
@@ -198,19 +316,45 @@ func (w *Wdb) GetArPrice() (float64, error) {
 	return res.Price, err
 }
 
+func (w *Wdb) InsertPriceSnapshots(snapshots []schema.TokenPriceSnapshot) error {
+	return w.Db.Create(&snapshots).Error
+}
+
+// GetPriceSnapshots return the snapshots in range and the last snapshot of every symbol before range
+func (w *Wdb) GetPriceSnapshots(r schema.TimeRange) ([]schema.TokenPriceSnapshot, error) {
+	res := make([]schema.TokenPriceSnapshot, 0)
+	lastIds := w.Db.Model(&schema.TokenPriceSnapshot{}).Select("MAX(id)").Where("created_at < ?", r.Start).Group("symbol")
+	err := w.Db.Model(&schema.TokenPriceSnapshot{}).Where("id IN (?) or (created_at >= ? and created_at < ?)", lastIds, r.Start, r.End).Order("id").Find(&res).Error
+	return res, err
+}
+
 func (w *Wdb) InsertReceiptTx(tx schema.ReceiptEverTx) error {
 	return w.Db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tx).Error
 }
 
-func (w *Wdb) GetLastEverRawId() (uint64, error) {
+func (w *Wdb) GetLastReceiptRawId(provider string) (uint64, error) {
 	tx := schema.ReceiptEverTx{}
-	err := w.Db.Model(&schema.ReceiptEverTx{}).Last(&tx).Error
+	err := w.Db.Model(&schema.ReceiptEverTx{}).Where("provider = ?", provider).Last(&tx).Error
 	if err == gorm.ErrRecordNotFound {
 		return 0, nil
 	}
 	return tx.RawId, err
 }
 
+func (w *Wdb) InsertPaymentSettlement(settlement *schema.PaymentSettlement, tx *gorm.DB) error {
+	db := w.Db
+	if tx != nil {
+		db = tx
+	}
+	return db.Create(settlement).Error
+}
+
+func (w *Wdb) GetPaymentSettlement(everHash string) (schema.PaymentSettlement, error) {
+	res := schema.PaymentSettlement{}
+	err := w.Db.Model(&schema.PaymentSettlement{}).Where("ever_hash = ?", everHash).First(&res).Error
+	return res, err
+}
+
 func (w *Wdb) GetReceiptsByStatus(status string) ([]schema.ReceiptEverTx, error) {
 	res := make([]schema.ReceiptEverTx, 0)
 	timestamp := time.Now().UnixMilli() - 24*60*60*1000 // latest 1 day
@@ -233,10 +377,108 @@ func (w *Wdb) UpdateRefundErr(rawId uint64, errMsg string) error {
 	return w.Db.Model(&schema.ReceiptEverTx{}).Where("raw_id = ?", rawId).Updates(data).Error
 }
 
+func (w *Wdb) UpdateRefundHash(rawId uint64, refundHash string) error {
+	return w.Db.Model(&schema.ReceiptEverTx{}).Where("raw_id = ?", rawId).Update("refund_hash", refundHash).Error
+}
+
+func (w *Wdb) GetReceiptByEverHash(everHash string) (schema.ReceiptEverTx, error) {
+	res := schema.ReceiptEverTx{}
+	err := w.Db.Model(&schema.ReceiptEverTx{}).Where("ever_hash = ?", everHash).First(&res).Error
+	return res, err
+}
+
+// GetReceiptsByStatusPage return all the receipts with status, not only the latest 1 day
+func (w *Wdb) GetReceiptsByStatusPage(status string, cursorId int64, num int) ([]schema.ReceiptEverTx, error) {
+	if cursorId <= 0 {
+		cursorId = math.MaxInt64
+	}
+	res := make([]schema.ReceiptEverTx, 0, num)
+	err := w.Db.Model(&schema.ReceiptEverTx{}).Where("status = ? and raw_id < ?", status, cursorId).Order("raw_id DESC").Limit(num).Find(&res).Error
+	return res, err
+}
+
+// LockReceiptStatus update the receipt status only when the current status is in fromStatus
+func (w *Wdb) LockReceiptStatus(rawId uint64, fromStatus []string, toStatus string, tx *gorm.DB) (bool, error) {
+	db := w.Db
+	if tx != nil {
+		db = tx
+	}
+	db = db.Model(&schema.ReceiptEverTx{}).Where("raw_id = ? and status in ?", rawId, fromStatus).Update("status", toStatus)
+	return db.RowsAffected == 1, db.Error
+}
+
+func (w *Wdb) InsertAdminAudit(audit schema.AdminAudit) error {
+	return w.Db.Create(&audit).Error
+}
+
+// GetPendingRefundAmount return the total amount of the provider receipts in status
+func (w *Wdb) GetPendingRefundAmount(provider, symbol string, status []string) (*big.Int, error) {
+	amounts := make([]string, 0)
+	err := w.Db.Model(&schema.ReceiptEverTx{}).Where("provider = ? and UPPER(symbol) = ? and status IN ?", provider, strings.ToUpper(symbol), status).Pluck("amount", &amounts).Error
+	if err != nil {
+		return nil, err
+	}
+	total := big.NewInt(0)
+	for _, amount := range amounts {
+		amt, ok := new(big.Int).SetString(amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("receipt amount incorrect: %s", amount)
+		}
+		total = new(big.Int).Add(total, amt)
+	}
+	return total, nil
+}
+
+func (w *Wdb) InsertFeeSweep(sweep schema.FeeSweep) error {
+	return w.Db.Create(&sweep).Error
+}
+
+// GetLastFeeSweep return the last succeed sweep of the token
+func (w *Wdb) GetLastFeeSweep(provider, tag string) (schema.FeeSweep, error) {
+	res := schema.FeeSweep{}
+	err := w.Db.Model(&schema.FeeSweep{}).Where("provider = ? and tag = ? and status = ?", provider, tag, schema.FeeSweepSuccess).Last(&res).Error
+	return res, err
+}
+
+func (w *Wdb) GetFeeSweeps(cursorId int64, num int) ([]schema.FeeSweep, error) {
+	if cursorId <= 0 {
+		cursorId = math.MaxInt64
+	}
+	res := make([]schema.FeeSweep, 0, num)
+	err := w.Db.Model(&schema.FeeSweep{}).Where("id < ?", cursorId).Order("id DESC").Limit(num).Find(&res).Error
+	return res, err
+}
+
+func (w *Wdb) GetAdminAudits(target string, cursorId int64, num int) ([]schema.AdminAudit, error) {
+	if cursorId <= 0 {
+		cursorId = math.MaxInt64
+	}
+	db := w.Db.Model(&schema.AdminAudit{}).Where("id < ?", cursorId)
+	if target != "" {
+		db = db.Where("target = ?", target)
+	}
+	res := make([]schema.AdminAudit, 0, num)
+	err := db.Order("id DESC").Limit(num).Find(&res).Error
+	return res, err
+}
+
 func (w *Wdb) InsertArTx(tx schema.OnChainTx) error {
 	return w.Db.Create(&tx).Error
 }
 
+// InsertBundleArTx insert the bundle arTx and update the on chain status of the bundled items
+func (w *Wdb) InsertBundleArTx(tx schema.OnChainTx, itemIds []string, onChainStatus string) error {
+	return w.Db.Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Create(&tx).Error; err != nil {
+			return err
+		}
+		if len(itemIds) == 0 {
+			return nil
+		}
+		return dbTx.Model(&schema.Order{}).Where("item_id IN ?", itemIds).Updates(map[string]interface{}{"on_chain_status": onChainStatus, "on_chain_tx_id": tx.ID}).Error
+	})
+}
+
 func (w *Wdb) GetArTxByStatus(status string) ([]schema.OnChainTx, error) {
 	res := make([]schema.OnChainTx, 0, 10)
 	err := w.Db.Model(schema.OnChainTx{}).Where("status = ?", status).Find(&res).Error
@@ -257,9 +499,10 @@ func (w *Wdb) UpdateArTxStatus(arId, status string, arTxStatus *types.TxStatus,
 	return db.Model(&schema.OnChainTx{}).Where("ar_id = ?", arId).Updates(data).Error
 }
 
-func (w *Wdb) UpdateArTx(id uint, arId string, curHeight int64, dataSize, reward string, status string) error {
+func (w *Wdb) UpdateArTx(id uint, arId string, curHeight int64, dataSize, reward string, status, bundler string) error {
 	data := make(map[string]interface{})
 	data["ar_id"] = arId
+	data["bundler"] = bundler
 	data["cur_height"] = curHeight
 	data["data_size"] = dataSize
 	data["reward"] = reward
@@ -267,6 +510,29 @@ func (w *Wdb) UpdateArTx(id uint, arId string, curHeight int64, dataSize, reward
 	return w.Db.Model(&schema.OnChainTx{}).Where("id = ?", id).Updates(data).Error
 }
 
+// GetPendingArTxNums return the pending arTx number of every bundler wallet
+func (w *Wdb) GetPendingArTxNums() (map[string]int, error) {
+	rows := make([]struct {
+		Bundler string
+		Num     int
+	}, 0)
+	err := w.Db.Model(&schema.OnChainTx{}).Select("bundler, count(*) as num").Where("status = ?", schema.PendingOnChain).Group("bundler").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]int, len(rows))
+	for _, row := range rows {
+		res[row.Bundler] = row.Num
+	}
+	return res, nil
+}
+
+func (w *Wdb) GetArTxsByTime(status string, r schema.TimeRange) ([]schema.OnChainTx, error) {
+	res := make([]schema.OnChainTx, 0)
+	err := w.Db.Model(&schema.OnChainTx{}).Where("status = ? and created_at >= ? and created_at < ?", status, r.Start, r.End).Order("id").Find(&res).Error
+	return res, err
+}
+
 func (w *Wdb) GetKafkaOnChains() ([]schema.OnChainTx, error) {
 	results := make([]schema.OnChainTx, 0)
 	err := w.Db.Model(&schema.OnChainTx{}).Where("block_height > ? and kafka = ? and status = ?", 1188855, false, schema.SuccOnChain).Limit(10).Find(&results).Error
@@ -311,8 +577,135 @@ func (w *Wdb) ExistApikey(addr string) (bool, schema.AutoApiKey) {
 	return err == nil, apikey
 }
 
-func (w *Wdb) UpdateApikeyTokenBal(addr string, newTokBal datatypes.JSONMap) error {
-	return w.Db.Model(&schema.AutoApiKey{}).Where("address = ?", addr).Update("token_balance", newTokBal).Error
+// GetApikeyForUpdate lock the apikey row until the transaction end
+func (w *Wdb) GetApikeyForUpdate(addr string, tx *gorm.DB) (schema.AutoApiKey, error) {
+	res := schema.AutoApiKey{}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&schema.AutoApiKey{}).Where("address = ?", addr).First(&res).Error
+	return res, err
+}
+
+func (w *Wdb) RotateApiKey(addr, newKey, encryptedKey string) error {
+	return w.Db.Transaction(func(tx *gorm.DB) error {
+		detail, err := w.GetApikeyForUpdate(addr, tx)
+		if err != nil {
+			return err
+		}
+		data := make(map[string]interface{})
+		data["api_key"] = newKey
+		data["encrypted_key"] = encryptedKey
+		data["revoked"] = false
+		if err = tx.Model(&schema.AutoApiKey{}).Where("address = ?", addr).Updates(data).Error; err != nil {
+			return err
+		}
+		return tx.Model(&schema.Order{}).Where("api_key = ?", detail.ApiKey).Update("api_key", newKey).Error
+	})
+}
+
+func (w *Wdb) GetApiKeys(cursorId uint, num int) ([]schema.AutoApiKey, error) {
+	res := make([]schema.AutoApiKey, 0, num)
+	err := w.Db.Model(&schema.AutoApiKey{}).Where("id > ?", cursorId).Order("id").Limit(num).Find(&res).Error
+	return res, err
+}
+
+func (w *Wdb) RevokeApiKey(addr string) error {
+	return w.Db.Model(&schema.AutoApiKey{}).Where("address = ?", addr).Update("revoked", true).Error
+}
+
+func (w *Wdb) InsertApiSubKey(subKey schema.ApiSubKey) error {
+	return w.Db.Create(&subKey).Error
+}
+
+func (w *Wdb) GetApiSubKey(subKey string) (schema.ApiSubKey, error) {
+	res := schema.ApiSubKey{}
+	err := w.Db.Model(&schema.ApiSubKey{}).Where("sub_key = ?", subKey).First(&res).Error
+	return res, err
+}
+
+func (w *Wdb) GetApiSubKeyByName(addr, name string) (schema.ApiSubKey, error) {
+	res := schema.ApiSubKey{}
+	err := w.Db.Model(&schema.ApiSubKey{}).Where("address = ? and name = ?", addr, name).First(&res).Error
+	return res, err
+}
+
+func (w *Wdb) GetApiSubKeys(addr string) ([]schema.ApiSubKey, error) {
+	res := make([]schema.ApiSubKey, 0)
+	err := w.Db.Model(&schema.ApiSubKey{}).Where("address = ?", addr).Find(&res).Error
+	return res, err
+}
+
+// GetApiSubKeyForUpdate lock the sub-key row until the transaction end
+func (w *Wdb) GetApiSubKeyForUpdate(subKey string, tx *gorm.DB) (schema.ApiSubKey, error) {
+	res := schema.ApiSubKey{}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&schema.ApiSubKey{}).Where("sub_key = ?", subKey).First(&res).Error
+	return res, err
+}
+
+func (w *Wdb) UpdateApiSubKeySpent(subKey string, spent datatypes.JSONMap, tx *gorm.DB) error {
+	db := w.Db
+	if tx != nil {
+		db = tx
+	}
+	return db.Model(&schema.ApiSubKey{}).Where("sub_key = ?", subKey).Update("spent", spent).Error
+}
+
+func (w *Wdb) RevokeApiSubKey(addr, name string) error {
+	return w.Db.Model(&schema.ApiSubKey{}).Where("address = ? and name = ?", addr, name).Update("revoked", true).Error
+}
+
+func (w *Wdb) UpdateApikeyTokenBal(addr string, newTokBal datatypes.JSONMap, tx *gorm.DB) error {
+	db := w.Db
+	if tx != nil {
+		db = tx
+	}
+	return db.Model(&schema.AutoApiKey{}).Where("address = ?", addr).Update("token_balance", newTokBal).Error
+}
+
+func (w *Wdb) InsertLedgerEntry(entry *schema.LedgerEntry, tx *gorm.DB) error {
+	db := w.Db
+	if tx != nil {
+		db = tx
+	}
+	return db.Create(entry).Error
+}
+
+func (w *Wdb) GetLedgerEntries(addr string, cursorId int64, num int) ([]schema.LedgerEntry, error) {
+	if cursorId <= 0 {
+		cursorId = math.MaxInt64
+	}
+	entries := make([]schema.LedgerEntry, 0, num)
+	err := w.Db.Model(&schema.LedgerEntry{}).Where("id < ? and address = ?", cursorId, addr).Order("id DESC").Limit(num).Find(&entries).Error
+	return entries, err
+}
+
+// GetLastLedgerEntries return the last entry of every currency before the time
+func (w *Wdb) GetLastLedgerEntries(addr string, before time.Time) ([]schema.LedgerEntry, error) {
+	entries := make([]schema.LedgerEntry, 0)
+	lastIds := w.Db.Model(&schema.LedgerEntry{}).Select("MAX(id)").Where("address = ? and created_at < ?", addr, before).Group("currency")
+	err := w.Db.Model(&schema.LedgerEntry{}).Where("id IN (?)", lastIds).Find(&entries).Error
+	return entries, err
+}
+
+func (w *Wdb) GetLedgerEntriesByTime(addr string, r schema.TimeRange) ([]schema.LedgerEntry, error) {
+	entries := make([]schema.LedgerEntry, 0)
+	err := w.Db.Model(&schema.LedgerEntry{}).Where("address = ? and created_at >= ? and created_at < ?", addr, r.Start, r.End).Order("id").Find(&entries).Error
+	return entries, err
+}
+
+// GetLedgerAddresses return the apikey addresses which have ledger entries before the time
+func (w *Wdb) GetLedgerAddresses(before time.Time) ([]string, error) {
+	addrs := make([]string, 0)
+	err := w.Db.Model(&schema.LedgerEntry{}).Distinct("address").Where("created_at < ?", before).Pluck("address", &addrs).Error
+	return addrs, err
+}
+
+func (w *Wdb) InsertBillingStatement(st schema.BillingStatement) error {
+	return w.Db.Create(&st).Error
+}
+
+func (w *Wdb) GetBillingStatement(addr, month string) (schema.BillingStatement, error) {
+	res := schema.BillingStatement{}
+	err := w.Db.Model(&schema.BillingStatement{}).Where("address = ? and month = ?", addr, month).First(&res).Error
+	return res, err
 }
 
 func (w *Wdb) GetApiKeyDepositRecords(addr string, cursorId int64, num int) ([]schema.ReceiptEverTx, error) {
@@ -386,3 +779,199 @@ func (w *Wdb) GetKafkaOrderInfos() ([]schema.KafkaOrderInfo, error) {
 func (w *Wdb) KafkaDone(id uint) error {
 	return w.Db.Model(&schema.Order{}).Where("id = ?", id).Update("kafka", true).Error
 }
+
+// GetOnChainTxByItemId the order of item is found first, only the orders bundled before the OnChainTxId is set need to search item_ids
+func (w *Wdb) GetOnChainTxByItemId(itemId string) (schema.OnChainTx, error) {
+	res := schema.OnChainTx{}
+	ord := schema.Order{}
+	if err := w.Db.Model(&schema.Order{}).Where("item_id = ? and on_chain_status = ?", itemId, schema.SuccOnChain).Last(&ord).Error; err != nil {
+		return res, err
+	}
+	if ord.OnChainTxId > 0 {
+		err := w.Db.Model(&schema.OnChainTx{}).Where("id = ? and status = ?", ord.OnChainTxId, schema.SuccOnChain).First(&res).Error
+		return res, err
+	}
+	err := w.Db.Model(&schema.OnChainTx{}).Where("status = ? and item_ids LIKE ?", schema.SuccOnChain, "%\""+itemId+"\"%").Last(&res).Error
+	return res, err
+}
+
+func (w *Wdb) InsertUploadReceipt(receipt schema.UploadReceipt) error {
+	return w.Db.Create(&receipt).Error
+}
+
+func (w *Wdb) GetUploadReceipt(itemId string) (schema.UploadReceipt, error) {
+	res := schema.UploadReceipt{}
+	err := w.Db.Model(&schema.UploadReceipt{}).Where("item_id = ?", itemId).Last(&res).Error
+	return res, err
+}
+
+func (w *Wdb) InsertUploadSession(session schema.UploadSession) error {
+	return w.Db.Create(&session).Error
+}
+
+func (w *Wdb) GetUploadSession(sessionId string) (schema.UploadSession, error) {
+	res := schema.UploadSession{}
+	err := w.Db.Model(&schema.UploadSession{}).Where("session_id = ?", sessionId).First(&res).Error
+	return res, err
+}
+
+// LockUploadSession change session status from uploading to finalizing, return false if the session is not uploading
+func (w *Wdb) LockUploadSession(sessionId string) (bool, error) {
+	db := w.Db.Model(&schema.UploadSession{}).Where("session_id = ? and status = ?", sessionId, schema.SessionUploading).Update("status", schema.SessionFinalizing)
+	return db.RowsAffected == 1, db.Error
+}
+
+func (w *Wdb) UpdateUploadSession(sessionId, status, itemId string) error {
+	data := make(map[string]interface{})
+	data["status"] = status
+	data["item_id"] = itemId
+	return w.Db.Model(&schema.UploadSession{}).Where("session_id = ?", sessionId).Updates(data).Error
+}
+
+// GetExpiredUploadSessions return the expired uploading sessions and the finalizing sessions left by crash
+func (w *Wdb) GetExpiredUploadSessions() ([]schema.UploadSession, error) {
+	now := time.Now()
+	res := make([]schema.UploadSession, 0, 10)
+	err := w.Db.Model(&schema.UploadSession{}).Where("status = ? and expired_time < ?", schema.SessionUploading, now.Unix()).
+		Or("status = ? and expired_time < ? and updated_at < ?", schema.SessionFinalizing, now.Unix(), now.Add(-time.Duration(schema.UploadSessionFinalizeTime)*time.Second)).Find(&res).Error
+	return res, err
+}
+
+func (w *Wdb) InsertUploadToken(token schema.UploadToken) error {
+	return w.Db.Create(&token).Error
+}
+
+func (w *Wdb) GetUploadToken(token string) (schema.UploadToken, error) {
+	res := schema.UploadToken{}
+	err := w.Db.Model(&schema.UploadToken{}).Where("token = ?", token).First(&res).Error
+	return res, err
+}
+
+// LockUploadToken change token status from unused to using, return false if the token has been used
+func (w *Wdb) LockUploadToken(token string) (bool, error) {
+	db := w.Db.Model(&schema.UploadToken{}).Where("token = ? and status = ?", token, schema.UploadTokenUnused).Update("status", schema.UploadTokenUsing)
+	return db.RowsAffected == 1, db.Error
+}
+
+func (w *Wdb) UpdateUploadTokenStatus(token, status string) error {
+	return w.Db.Model(&schema.UploadToken{}).Where("token = ?", token).Update("status", status).Error
+}
+
+func (w *Wdb) InsertIdempotencyRecord(record schema.IdempotencyRecord) error {
+	return w.Db.Create(&record).Error
+}
+
+func (w *Wdb) GetIdempotencyRecord(scope, idempotencyKey string) (schema.IdempotencyRecord, error) {
+	res := schema.IdempotencyRecord{}
+	err := w.Db.Model(&schema.IdempotencyRecord{}).Where("scope = ? and idempotency_key = ?", scope, idempotencyKey).First(&res).Error
+	return res, err
+}
+
+func (w *Wdb) CompleteIdempotencyRecord(scope, idempotencyKey string, response []byte) error {
+	data := make(map[string]interface{})
+	data["status"] = schema.IdempotencyCompleted
+	data["response"] = datatypes.JSON(response)
+	return w.Db.Model(&schema.IdempotencyRecord{}).Where("scope = ? and idempotency_key = ?", scope, idempotencyKey).Updates(data).Error
+}
+
+func (w *Wdb) DelIdempotencyRecord(scope, idempotencyKey string) error {
+	return w.Db.Where("scope = ? and idempotency_key = ?", scope, idempotencyKey).Delete(&schema.IdempotencyRecord{}).Error
+}
+
+func (w *Wdb) DelExpiredIdempotencyRecords() error {
+	return w.Db.Where("expired_time < ?", time.Now().Unix()).Delete(&schema.IdempotencyRecord{}).Error
+}
+
+func (w *Wdb) InsertAuthNonce(nonce schema.AuthNonce) error {
+	return w.Db.Create(&nonce).Error
+}
+
+func (w *Wdb) GetAuthNonce(nonce string) (schema.AuthNonce, error) {
+	res := schema.AuthNonce{}
+	err := w.Db.Model(&schema.AuthNonce{}).Where("nonce = ?", nonce).First(&res).Error
+	return res, err
+}
+
+// UseAuthNonce return false if the nonce has been used
+func (w *Wdb) UseAuthNonce(nonce string) (bool, error) {
+	db := w.Db.Model(&schema.AuthNonce{}).Where("nonce = ? and used = ?", nonce, false).Update("used", true)
+	return db.RowsAffected == 1, db.Error
+}
+
+func (w *Wdb) InsertAuthSession(session schema.AuthSession) error {
+	return w.Db.Create(&session).Error
+}
+
+func (w *Wdb) GetAuthSession(token string) (schema.AuthSession, error) {
+	res := schema.AuthSession{}
+	err := w.Db.Model(&schema.AuthSession{}).Where("token = ?", token).First(&res).Error
+	return res, err
+}
+
+func (w *Wdb) DelAuthSession(token string) error {
+	return w.Db.Where("token = ?", token).Delete(&schema.AuthSession{}).Error
+}
+
+func (w *Wdb) DelExpiredAuth() error {
+	now := time.Now().Unix()
+	if err := w.Db.Where("expired_time < ?", now).Delete(&schema.AuthNonce{}).Error; err != nil {
+		return err
+	}
+	return w.Db.Where("expired_time < ?", now).Delete(&schema.AuthSession{}).Error
+}
+
+func (w *Wdb) GetMonthlyBytes(owner, month string) (int64, error) {
+	usage := schema.MonthlyUsage{}
+	err := w.Db.Model(&schema.MonthlyUsage{}).Where("owner = ? and month = ?", owner, month).First(&usage).Error
+	if err == gorm.ErrRecordNotFound {
+		return 0, nil
+	}
+	return usage.Bytes, err
+}
+
+func (w *Wdb) AddMonthlyUsage(owner, month string, bytes int64, tx *gorm.DB) error {
+	db := w.Db
+	if tx != nil {
+		db = tx
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner"}, {Name: "month"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"bytes": gorm.Expr("bytes + ?", bytes)}),
+	}).Create(&schema.MonthlyUsage{Owner: owner, Month: month, Bytes: bytes}).Error
+}
+
+func (w *Wdb) InsertFeeQuote(quote schema.FeeQuote) error {
+	return w.Db.Create(&quote).Error
+}
+
+func (w *Wdb) GetFeeQuote(quoteId string) (schema.FeeQuote, error) {
+	res := schema.FeeQuote{}
+	err := w.Db.Model(&schema.FeeQuote{}).Where("quote_id = ?", quoteId).First(&res).Error
+	return res, err
+}
+
+// UseFeeQuote bind the quote to the item, return false if the quote has been used by other item
+func (w *Wdb) UseFeeQuote(quoteId, itemId string) (bool, error) {
+	db := w.Db.Model(&schema.FeeQuote{}).Where("quote_id = ? and item_id = ?", quoteId, "").Update("item_id", itemId)
+	if db.Error != nil {
+		return false, db.Error
+	}
+	if db.RowsAffected == 1 {
+		return true, nil
+	}
+	// the item is retried
+	quote, err := w.GetFeeQuote(quoteId)
+	if err != nil {
+		return false, err
+	}
+	return quote.ItemId == itemId, nil
+}
+
+// ReleaseFeeQuote the quote used by the item which is not accepted can be used again
+func (w *Wdb) ReleaseFeeQuote(quoteId, itemId string) error {
+	return w.Db.Model(&schema.FeeQuote{}).Where("quote_id = ? and item_id = ?", quoteId, itemId).Update("item_id", "").Error
+}
+
+func (w *Wdb) DelExpiredFeeQuotes() error {
+	return w.Db.Where("expired_time < ?", time.Now().Unix()).Delete(&schema.FeeQuote{}).Error
+}